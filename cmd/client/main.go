@@ -9,6 +9,9 @@ import (
 	"procspy/internal/procspy/config"
 	"syscall"
 	"time"
+	// Embedded so user timezones resolve on systems without a zone
+	// database, such as Windows.
+	_ "time/tzdata"
 
 	rotatelogs "github.com/lestrrat/go-file-rotatelogs"
 )
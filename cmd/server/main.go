@@ -7,8 +7,13 @@ import (
 	"os/signal"
 	"procspy/internal/procspy/config"
 	"procspy/internal/procspy/server"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
 	"syscall"
 	"time"
+	// Embedded so user timezones resolve on systems without a zone
+	// database, such as Windows.
+	_ "time/tzdata"
 
 	rotatelogs "github.com/lestrrat/go-file-rotatelogs"
 )
@@ -17,7 +22,7 @@ var buildDate string
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Print("Usage: procspy-server <config_file>\n")
+		fmt.Print("Usage: procspy-server <config_file> [import [<user> <url_or_file>]]\n")
 		os.Exit(1)
 	}
 
@@ -36,6 +41,10 @@ func main() {
 		log.SetOutput(os.Stdout)
 	}
 
+	if len(os.Args) > 2 && os.Args[2] == "import" {
+		os.Exit(importTargets(cfg, os.Args[3:]))
+	}
+
 	PrintLogo()
 	fmt.Printf("\nStarting...\n")
 
@@ -49,6 +58,40 @@ func main() {
 	log.Print("Stopping...\n")
 }
 
+// importTargets loads target lists in the original JSON format into the
+// database: every user in user_targets or a single user from an URL or file.
+func importTargets(cfg *config.Server, args []string) int {
+	dbConn := storage.NewDbConnection(cfg.DBPath)
+	defer dbConn.Close()
+
+	targets := service.NewTarget(cfg, dbConn)
+
+	if len(args) == 0 {
+		if err := targets.ImportConfigured(false); err != nil {
+			fmt.Printf("Error importing targets: %s\n", err)
+			return 1
+		}
+
+		fmt.Printf("Targets of %d users imported\n", len(cfg.UserTarges))
+		return 0
+	}
+
+	if len(args) != 2 {
+		fmt.Print("Usage: procspy-server <config_file> import [<user> <url_or_file>]\n")
+		return 1
+	}
+
+	count, err := targets.ImportTargets(args[0], args[1])
+
+	if err != nil {
+		fmt.Printf("Error importing targets for %s: %s\n", args[0], err)
+		return 1
+	}
+
+	fmt.Printf("%d targets imported for %s\n", count, args[0])
+	return 0
+}
+
 func initLogger(path string) error {
 	if err := os.Mkdir(path, 0755); !os.IsExist(err) {
 		fmt.Printf("Error creating directory %s: %s", path, err)
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,13 +13,16 @@ import (
 	"procspy/internal/procspy/config"
 	"procspy/internal/procspy/domain"
 	"procspy/internal/procspy/handlers"
+	"procspy/internal/procspy/storage"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mitchellh/go-ps"
-	_ "github.com/mitchellh/go-ps"
 )
 
 type Spy struct {
@@ -26,27 +30,108 @@ type Spy struct {
 	enabled            bool
 	currentDay         int
 	targets            *domain.TargetList
-	commandBuf         chan *domain.Command
-	matchBuf           chan *domain.Match
+	spool              *storage.Spool
+	draining           atomic.Bool
 	healthcheckHandler *handlers.Healthcheck
 	router             *gin.Engine
 	srv                *http.Server
+	ledger             *storage.Ledger
+	online             bool
+	syncMu             sync.Mutex
+	targetsETag        string
+	serverElapsed      map[string]float64
+	serverDay          string
+	quotaBase          map[string]quotaUsage
+	scanMu             sync.Mutex
+	pushCancel         context.CancelFunc
+	focus              FocusProvider
+	idle               IdleProvider
+	children           map[string]*Spy
+	accounts           map[string]bool
 }
 
+const SPOOL_KIND_MATCH = "match"
+const SPOOL_KIND_COMMAND = "command"
+const SPOOL_DRAIN_LIMIT = 100
+const SPOOL_MAX_BACKOFF = 10 * 60
+
+// errRejected marks a payload the server refused as invalid. Sending it
+// again would fail the same way, so it is dead-lettered instead of retried.
+var errRejected = errors.New("rejected by the server")
+
+// rejected reports whether an HTTP status refuses the payload itself, and
+// not the credentials, the endpoint or the availability of the server.
+func rejected(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+
+	return status >= 400 && status < 500
+}
+
+// NewSpy builds the spy of the configured user. On a shared computer it
+// builds a spy for each procspy user instead, and keeps no ledger or spool of
+// its own: all usage is kept and posted by the children.
 func NewSpy(config *config.Client) *Spy {
 	ret := &Spy{
 		config:             config,
 		enabled:            false,
 		currentDay:         time.Now().Day(),
 		targets:            domain.NewTargetList(),
-		commandBuf:         make(chan *domain.Command, 1000),
-		matchBuf:           make(chan *domain.Match, 1000),
-		healthcheckHandler: handlers.NewHealthcheck(),
+		healthcheckHandler: handlers.NewHealthcheck(nil),
+		idle:               newIdleProvider(),
+	}
+
+	if config.Accounting == ACCOUNTING_ACTIVE {
+		ret.focus = newFocusProvider()
+	}
+
+	if len(config.OSUsers) > 0 {
+		ret.children = newChildren(config)
+		return ret
 	}
 
+	conn := newLocalConnection(config.DBPath)
+	ret.ledger = storage.NewLedger(conn)
+	ret.spool = storage.NewSpool(conn, config.SpoolSize)
+
 	return ret
 }
 
+func newLocalConnection(path string) *storage.DbConnection {
+	if path == "" {
+		log.Printf("[newLocalConnection] No db_path configured, usage ledger and spool will be kept in memory only")
+		return storage.NewDbConnection(":memory:")
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		log.Printf("[newLocalConnection] Error creating directory %s: %s, usage ledger and spool will be kept in memory only", path, err)
+		return storage.NewDbConnection(":memory:")
+	}
+
+	return storage.NewDbConnection(path)
+}
+
+// ledgerDay is the day under which usage at a moment is kept on the local
+// ledger. Callers pass the moment in the zone of the user.
+func ledgerDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// joinKeys joins the keys of a set in order, for logs and matches.
+func joinKeys(set map[string]struct{}) string {
+	ret := make([]string, 0, len(set))
+
+	for k := range set {
+		ret = append(ret, k)
+	}
+
+	sort.Strings(ret)
+
+	return strings.Join(ret, " / ")
+}
+
 func (s *Spy) startHttpServer() {
 	gin.ForceConsoleColor()
 	gin.DefaultWriter = log.Writer()
@@ -59,6 +144,8 @@ func (s *Spy) startHttpServer() {
 
 	s.router = gin.Default()
 	s.router.GET("/healthcheck", s.healthcheckHandler.GetStatus)
+	s.router.GET("/spool", s.getSpoolStats)
+	s.router.POST("/request-extension", s.requestExtension)
 
 	log.Print("[startHttpServer] Router started")
 
@@ -88,29 +175,70 @@ func (s *Spy) stopHttpServer() {
 	}
 }
 
+// newRequest builds a request to the server carrying the device token.
+func (s *Spy) newRequest(method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
 func (s *Spy) httpGet(url string) (string, int, error) {
-	res, err := http.Get(url)
+	body, _, status, err := s.httpGetConditional(url, "")
+	return body, status, err
+}
+
+// httpGetConditional sends If-None-Match when an ETag is known and returns
+// the ETag of the response. A 304 comes back with an empty body.
+func (s *Spy) httpGetConditional(url string, etag string) (string, string, int, error) {
+	req, err := s.newRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Printf("[httpGet] Error creating request to URL %s: %s", url, err)
+		return "", "", http.StatusInternalServerError, err
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("[httpGet] Error getting URL %s: %s", url, err)
-		return "", http.StatusInternalServerError, err
+		return "", "", http.StatusInternalServerError, err
 	}
 
 	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		log.Printf("[httpGet] Error reading response body from %s: %s", url, err)
-		return "", res.StatusCode, err
+		return "", "", res.StatusCode, err
 	}
 
 	if s.config.Debug {
 		log.Printf("[httpGet] %d Response from %s: %s", res.StatusCode, url, body)
 	}
 
-	return string(body), res.StatusCode, nil
+	return string(body), res.Header.Get("ETag"), res.StatusCode, nil
 }
 
 func (s *Spy) httpPost(url string, data string) (string, int, error) {
-	res, err := http.Post(url, "application/json", strings.NewReader(data))
+	req, err := s.newRequest(http.MethodPost, url, strings.NewReader(data))
+	if err != nil {
+		log.Printf("[httpPost] Error creating request to URL %s: %s", url, err)
+		return "", http.StatusInternalServerError, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("[httpPost] Error posting to URL %s: %s", url, err)
 		return "", http.StatusInternalServerError, err
@@ -135,37 +263,263 @@ func (s *Spy) updateTargets() {
 		s.targets = domain.NewTargetList()
 	}
 
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	s.online = s.fetchTargets()
+
+	if !s.online {
+		s.loadCachedTargets()
+	}
+
+	s.reconcileTargets()
+}
+
+func (s *Spy) fetchTargets() bool {
 	targetUrl := fmt.Sprintf("%s/targets/%s", s.config.ServerURL, s.config.User)
 
-	data, status, err := s.httpGet(targetUrl)
+	data, etag, status, err := s.httpGetConditional(targetUrl, s.targetsETag)
 
 	if err != nil {
 		log.Printf("[updateTargets] Failed to fetch targets for user '%s' (HTTP %d) from %s: %s", s.config.User, status, targetUrl, err)
-		return
+		return false
+	}
+
+	if status == http.StatusNotModified {
+		return true
 	}
 
 	if status != http.StatusOK {
 		log.Printf("[updateTargets] Unexpected HTTP status %d when fetching targets for user '%s' from %s", status, s.config.User, targetUrl)
-		return
+		return false
 	}
 
 	targets, err := domain.TargetListFromJson(data)
 
 	if err != nil {
 		log.Printf("[updateTargets] Failed to parse targets JSON for user '%s': %s", s.config.User, err)
-		return
+		return false
 	}
 
 	if targets == nil {
 		log.Printf("[updateTargets] Received nil targets for user '%s'", s.config.User)
-		return
+		return false
 	}
 
 	if len(targets.Targets) == 0 {
 		log.Printf("[updateTargets] No targets configured for user '%s'", s.config.User)
 	}
 
+	s.setTargets(targets, data, etag)
+
+	return true
+}
+
+// setTargets replaces the targets with a list received from the server,
+// keeping its totals for reconciliation and a copy on the ledger for when
+// the server is unreachable. Callers hold syncMu.
+func (s *Spy) setTargets(targets *domain.TargetList, data string, etag string) {
+	day := ledgerDay(targets.Now())
+
+	if err := s.ledger.SaveTargets(s.config.User, day, data); err != nil {
+		log.Printf("[updateTargets] Failed to save targets for user '%s' on local ledger: %s", s.config.User, err)
+	}
+
+	serverElapsed := make(map[string]float64, len(targets.Targets)+len(targets.Groups))
+
+	for _, target := range targets.Targets {
+		serverElapsed[target.Name] = target.Elapsed
+	}
+
+	for _, group := range targets.Groups {
+		serverElapsed[groupLedgerName(group.Name)] = group.Elapsed
+	}
+
+	s.applyAllowlist(targets)
+	s.setQuotaBase(targets, day)
+	targets.ReusePatterns(s.targets)
+
+	s.targets = targets
+	s.targetsETag = etag
+	s.serverElapsed = serverElapsed
+}
+
+// loadCachedTargets restores the last known target list from the ledger when
+// the server cannot be reached and nothing is loaded yet (e.g. after a restart).
+func (s *Spy) loadCachedTargets() {
+	if len(s.targets.Targets) > 0 {
+		return
+	}
+
+	data, day, err := s.ledger.LoadTargets(s.config.User)
+
+	if err != nil || data == "" {
+		log.Printf("[updateTargets] No cached targets available for user '%s'", s.config.User)
+		return
+	}
+
+	targets, err := domain.TargetListFromJson(data)
+
+	if err != nil {
+		log.Printf("[updateTargets] Failed to parse cached targets for user '%s': %s", s.config.User, err)
+		return
+	}
+
+	log.Printf("[updateTargets] Server unreachable, using %d cached targets for user '%s'", len(targets.Targets), s.config.User)
+	s.applyAllowlist(targets)
+	s.setQuotaBase(targets, day)
 	s.targets = targets
+	s.targetsETag = ""
+}
+
+// applyAllowlist adds the allowlist of the client config to the one served
+// with the targets.
+func (s *Spy) applyAllowlist(targets *domain.TargetList) {
+	targets.SetAllowlist(domain.MergeAllowlists(targets.Allowlist, s.config.Allowlist))
+}
+
+// reconcileTargets sets the elapsed time of each target and group from the
+// local ledger. When online, the server totals of the last full response are
+// merged into the ledger first.
+func (s *Spy) reconcileTargets() {
+	day := ledgerDay(s.targets.Now())
+
+	for _, target := range s.targets.Targets {
+		elapsed, err := s.reconcileElapsed(day, target.Name)
+
+		if err != nil {
+			log.Printf("[updateTargets] Failed to reconcile elapsed for target '%s': %s", target.Name, err)
+			continue
+		}
+
+		target.SetElapsed(elapsed)
+
+		if !target.HasQuota() {
+			continue
+		}
+
+		weekly, monthly, err := s.quotaElapsed(target.Name)
+
+		if err != nil {
+			log.Printf("[updateTargets] Failed to compute quota usage for target '%s': %s", target.Name, err)
+			continue
+		}
+
+		target.SetQuotaElapsed(weekly, monthly)
+	}
+
+	for _, group := range s.targets.Groups {
+		elapsed, err := s.reconcileElapsed(day, groupLedgerName(group.Name))
+
+		if err != nil {
+			log.Printf("[updateTargets] Failed to reconcile elapsed for group '%s': %s", group.Name, err)
+			continue
+		}
+
+		group.SetElapsed(elapsed)
+	}
+}
+
+func (s *Spy) reconcileElapsed(day string, name string) (float64, error) {
+	if s.online {
+		return s.ledger.Reconcile(s.config.User, day, name, s.serverElapsed[name])
+	}
+
+	return s.ledger.GetElapsed(s.config.User, day, name)
+}
+
+// quotaUsage is the usage of a target in the current week and month.
+type quotaUsage struct {
+	weekly  float64
+	monthly float64
+}
+
+// setQuotaBase keeps the weekly and monthly usage a list received from the
+// server had before the day of its totals, which the local ledger may not
+// hold, e.g. when other devices were used. See quotaElapsed.
+func (s *Spy) setQuotaBase(targets *domain.TargetList, day string) {
+	s.serverDay = day
+	s.quotaBase = make(map[string]quotaUsage, len(targets.Targets))
+
+	for _, target := range targets.Targets {
+		s.quotaBase[target.Name] = quotaUsage{
+			weekly:  max(target.WeeklyElapsed-target.Elapsed, 0),
+			monthly: max(target.MonthlyElapsed-target.Elapsed, 0),
+		}
+	}
+}
+
+// quotaElapsed computes the usage of a target in the current week and month,
+// today included, as the server usage before the day of its last list plus
+// the local ledger from that day on. A week or month that started after that
+// day only counts the ledger, so quotas keep following the days while the
+// server is unreachable.
+func (s *Spy) quotaElapsed(name string) (float64, float64, error) {
+	now := s.targets.Now()
+	base := s.quotaBase[name]
+
+	weekly, err := s.elapsedSince(name, ledgerDay(domain.WeekStart(now, s.targets.WeekStart)), base.weekly)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	monthly, err := s.elapsedSince(name, ledgerDay(domain.MonthStart(now)), base.monthly)
+
+	return weekly, monthly, err
+}
+
+// elapsedSince sums the usage of a target from the start day of a period to
+// today, starting from the server usage when its list is from that period.
+func (s *Spy) elapsedSince(name string, start string, base float64) (float64, error) {
+	if s.serverDay < start {
+		base = 0
+	} else {
+		start = s.serverDay
+	}
+
+	local, err := s.ledger.SumElapsed(s.config.User, name, start, ledgerDay(s.targets.Now()))
+
+	return base + local, err
+}
+
+// groupLedgerName is the name under which the usage of a group is kept on
+// the local ledger, apart from the targets.
+func groupLedgerName(name string) string {
+	return "group:" + name
+}
+
+// creditGroups adds the elapsed time of a scan to the groups of a matched
+// target. A group is credited once per scan however many of its members
+// matched, so its usage is the union of theirs. It returns the first group
+// of the target without time left, or nil.
+func (s *Spy) creditGroups(target *domain.Target, elapsed float64, credited map[string]bool) *domain.Group {
+	var exhausted *domain.Group
+
+	for _, name := range target.Groups {
+		group := s.targets.Group(name)
+
+		if group == nil {
+			continue
+		}
+
+		if !credited[name] && elapsed > 0 {
+			credited[name] = true
+
+			if err := s.ledger.AddElapsed(s.config.User, ledgerDay(s.targets.Now()), groupLedgerName(name), elapsed); err != nil {
+				log.Printf("[run]  > [%s] Error adding elapsed of group '%s' to ledger: %s", target.Name, name, err)
+			}
+
+			group.AddElapsed(elapsed)
+			log.Printf("[run]  > [%s] Add %.2fs to group '%s' -> Use %.2f from %.2fs", target.Name, elapsed, name, group.Elapsed, group.DailyLimit)
+		}
+
+		if exhausted == nil && group.CheckLimit() {
+			exhausted = group
+		}
+	}
+
+	return exhausted
 }
 
 func (s *Spy) postMatch(match *domain.Match) error {
@@ -182,6 +536,11 @@ func (s *Spy) postMatch(match *domain.Match) error {
 		return err
 	}
 
+	if rejected(status) {
+		log.Printf("[postMatch] Match rejected, http status code: %d to %s", status, matchUrl)
+		return fmt.Errorf("%w: http post match error, http status code: %d", errRejected, status)
+	}
+
 	if status != http.StatusCreated {
 		log.Printf("[postMatch] Error posting match, http status code: %d to %s", status, matchUrl)
 		return fmt.Errorf("http post match error, http status code: %d", status)
@@ -208,6 +567,11 @@ func (s *Spy) postCommand(cmd *domain.Command) error {
 		return err
 	}
 
+	if rejected(status) {
+		log.Printf("[postCommand] Command rejected, http status code: %d to %s", status, commandUrl)
+		return fmt.Errorf("%w: http post command error, http status code: %d", errRejected, status)
+	}
+
 	if status != http.StatusCreated {
 		log.Printf("[postCommand] Error posting command, http status code: %d to %s", status, commandUrl)
 		return fmt.Errorf("http post command error, http status code: %d", status)
@@ -218,63 +582,332 @@ func (s *Spy) postCommand(cmd *domain.Command) error {
 	return nil
 }
 
-func (s *Spy) consumeBuffers() {
-	if s.matchBuf == nil {
-		log.Printf("[Spy] Match buffer is nil")
-		s.matchBuf = make(chan *domain.Match, 1000)
+func (s *Spy) enqueueMatch(match *domain.Match) {
+	if err := s.spool.Push(SPOOL_KIND_MATCH, match.ToLog()); err != nil {
+		log.Printf("[enqueueMatch] Error adding match to spool: %s -> %s", err, match.ToLog())
+	}
+}
+
+func (s *Spy) enqueueCommand(cmd *domain.Command) {
+	if err := s.spool.Push(SPOOL_KIND_COMMAND, cmd.ToLog()); err != nil {
+		log.Printf("[enqueueCommand] Error adding command to spool: %s -> %s", err, cmd.ToLog())
 	}
+}
 
-	if s.commandBuf == nil {
-		log.Printf("[consumeBuffers] Command buffer is nil")
-		s.commandBuf = make(chan *domain.Command, 1000)
+// consumeBuffers drains the spool in background. Only one drain runs at a
+// time; a scan that finds a drain in progress leaves the work to it.
+func (s *Spy) consumeBuffers() {
+	if !s.draining.CompareAndSwap(false, true) {
+		if s.config.Debug {
+			log.Printf("[consumeBuffers] Drain already in progress, skipping")
+		}
+		return
 	}
 
 	go func() {
-		//Buffer
-		matchDlq := make(chan *domain.Match, len(s.matchBuf))
-		for len(s.matchBuf) > 0 {
-			if s.config.Debug {
-				log.Printf("[consumeBuffers] %d matches in buffer", len(s.matchBuf))
+		defer s.draining.Store(false)
+
+		if s.drainBatches() {
+			return
+		}
+
+		s.drainSpool(SPOOL_KIND_MATCH, func(payload string) error {
+			match, err := domain.MatchFromJson(payload)
+			if err != nil {
+				log.Printf("[consumeBuffers] Discarding invalid match from spool: %s -> %s", err, payload)
+				return nil
 			}
+			return s.postAndAckMatch(match)
+		})
 
-			match := <-s.matchBuf
-			err := s.postMatch(match)
+		s.drainSpool(SPOOL_KIND_COMMAND, func(payload string) error {
+			cmd, err := domain.CommandFromJson(payload)
 			if err != nil {
-				log.Printf("[consumeBuffers] Error posting match: %s, waiting for next process", err)
-				matchDlq <- match
+				log.Printf("[consumeBuffers] Discarding invalid command from spool: %s -> %s", err, payload)
+				return nil
 			}
+			return s.postCommand(cmd)
+		})
+	}()
+}
+
+// drainBatches sends due spool items through POST /batch, matches and
+// commands together. A kind whose oldest item waits for a retry is held
+// back whole, as Spool.Next returns nothing for it. It returns false when
+// the items must go one request each, such as when the server does not
+// offer the batch endpoint.
+func (s *Spy) drainBatches() bool {
+	for {
+		matchItems, err := s.spool.Next(SPOOL_KIND_MATCH, SPOOL_DRAIN_LIMIT)
+		if err != nil {
+			log.Printf("[consumeBuffers] Error reading %s spool: %s", SPOOL_KIND_MATCH, err)
+			return true
+		}
+
+		cmdItems, err := s.spool.Next(SPOOL_KIND_COMMAND, SPOOL_DRAIN_LIMIT)
+		if err != nil {
+			log.Printf("[consumeBuffers] Error reading %s spool: %s", SPOOL_KIND_COMMAND, err)
+			return true
 		}
 
-		cmdDlq := make(chan *domain.Command, len(s.commandBuf))
-		for len(s.commandBuf) > 0 {
-			if s.config.Debug {
-				log.Printf("[consumeBuffers] %d commands in buffer", len(s.commandBuf))
+		if len(matchItems) == 0 && len(cmdItems) == 0 {
+			return true
+		}
+
+		batch := domain.NewBatch()
+		sentMatches := make([]*storage.SpoolItem, 0, len(matchItems))
+		sentCommands := make([]*storage.SpoolItem, 0, len(cmdItems))
+
+		for _, item := range matchItems {
+			match, err := domain.MatchFromJson(item.Payload)
+			if err != nil {
+				log.Printf("[consumeBuffers] Discarding invalid match from spool: %s -> %s", err, item.Payload)
+				s.spool.Delete(item.ID)
+				continue
 			}
+			batch.Matches = append(batch.Matches, match)
+			sentMatches = append(sentMatches, item)
+		}
 
-			cmd := <-s.commandBuf
-			err := s.postCommand(cmd)
+		for _, item := range cmdItems {
+			cmd, err := domain.CommandFromJson(item.Payload)
 			if err != nil {
-				log.Printf("[consumeBuffers] Error posting command: %s, waiting for next process", err)
-				cmdDlq <- cmd
+				log.Printf("[consumeBuffers] Discarding invalid command from spool: %s -> %s", err, item.Payload)
+				s.spool.Delete(item.ID)
+				continue
 			}
+			batch.Commands = append(batch.Commands, cmd)
+			sentCommands = append(sentCommands, item)
 		}
 
-		//DLQ
-		for len(matchDlq) > 0 {
-			match := <-matchDlq
-			log.Printf("[consumeBuffers] Add match to post dlq: %s", match.ToJson())
-			s.matchBuf <- match
+		if len(sentMatches) == 0 && len(sentCommands) == 0 {
+			continue
 		}
 
-		for len(cmdDlq) > 0 {
-			cmd := <-cmdDlq
-			log.Printf("[consumeBuffers] Add command to post dlq: %s", cmd.ToJson())
-			s.commandBuf <- cmd
+		if s.config.Debug {
+			log.Printf("[consumeBuffers] Posting batch with %d matches and %d commands", len(batch.Matches), len(batch.Commands))
 		}
-	}()
+
+		ok, supported := s.postAndAckBatch(batch, sentMatches, sentCommands)
+
+		if !supported {
+			return false
+		}
+
+		if !ok {
+			return true
+		}
+	}
+}
+
+// postAndAckBatch posts a batch and settles each spool item from its result.
+// It returns ok=false when any item must be retried, and supported=false when
+// the items must be posted one by one: the server has no batch endpoint, or
+// it refused the batch, so the refused item can be told apart.
+func (s *Spy) postAndAckBatch(batch *domain.Batch, sentMatches []*storage.SpoolItem, sentCommands []*storage.SpoolItem) (bool, bool) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	result, status, err := s.postBatch(batch)
+
+	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+		log.Printf("[consumeBuffers] Server does not support batch ingestion, posting items one by one")
+		return false, false
+	}
+
+	if rejected(status) {
+		log.Printf("[consumeBuffers] Server rejected the batch (HTTP %d), posting items one by one", status)
+		return false, false
+	}
+
+	if err == nil && (len(result.Matches) != len(sentMatches) || len(result.Commands) != len(sentCommands)) {
+		err = fmt.Errorf("batch result size mismatch: %d/%d matches, %d/%d commands", len(result.Matches), len(sentMatches), len(result.Commands), len(sentCommands))
+	}
+
+	if err != nil {
+		log.Printf("[consumeBuffers] Error posting batch: %s, waiting for next process", err)
+		for _, item := range append(sentMatches, sentCommands...) {
+			s.spool.Retry(item.ID, err.Error(), spoolBackoff(item.Retries, s.config.Interval))
+		}
+		return false, true
+	}
+
+	ok := true
+
+	for i, res := range result.Matches {
+		item := sentMatches[i]
+
+		if !res.Accepted() {
+			log.Printf("[consumeBuffers] Server rejected match %s: %s", res.EventID, res.Error)
+			s.spool.Retry(item.ID, res.Error, spoolBackoff(item.Retries, s.config.Interval))
+			ok = false
+			continue
+		}
+
+		match := batch.Matches[i]
+		if err := s.ledger.Ack(match.User, ledgerDay(match.CreatedAt.In(s.targets.Location())), match.Name, match.Elapsed); err != nil {
+			log.Printf("[consumeBuffers] Error acking match on ledger: %s", err)
+		}
+
+		s.spool.Delete(item.ID)
+	}
+
+	for i, res := range result.Commands {
+		item := sentCommands[i]
+
+		if !res.Accepted() {
+			log.Printf("[consumeBuffers] Server rejected command %s: %s", res.EventID, res.Error)
+			s.spool.Retry(item.ID, res.Error, spoolBackoff(item.Retries, s.config.Interval))
+			ok = false
+			continue
+		}
+
+		s.spool.Delete(item.ID)
+	}
+
+	return ok, true
+}
+
+func (s *Spy) postBatch(batch *domain.Batch) (*domain.BatchResult, int, error) {
+	batchUrl := fmt.Sprintf("%s/batch/%s", s.config.ServerURL, s.config.User)
+
+	data, status, err := s.httpPost(batchUrl, batch.ToLog())
+
+	if err != nil {
+		log.Printf("[postBatch] Error posting batch, http status code: %d to %s -> error: %s", status, batchUrl, err)
+		return nil, status, err
+	}
+
+	if status != http.StatusCreated {
+		log.Printf("[postBatch] Error posting batch, http status code: %d to %s", status, batchUrl)
+		return nil, status, fmt.Errorf("http post batch error, http status code: %d", status)
+	}
+
+	result, err := domain.BatchResultFromJson(data)
+
+	if err != nil {
+		log.Printf("[postBatch] Error parsing batch result from %s: %s", batchUrl, err)
+		return nil, status, err
+	}
+
+	return result, status, nil
+}
+
+// drainSpool sends due items of a kind in order. On the first failure the
+// item is rescheduled with exponential backoff and the drain stops, so
+// later items are never delivered ahead of it. Items the server refuses are
+// dead-lettered and the drain goes on.
+func (s *Spy) drainSpool(kind string, send func(payload string) error) {
+	for {
+		items, err := s.spool.Next(kind, SPOOL_DRAIN_LIMIT)
+
+		if err != nil {
+			log.Printf("[consumeBuffers] Error reading %s spool: %s", kind, err)
+			return
+		}
+
+		if len(items) == 0 {
+			return
+		}
+
+		if s.config.Debug {
+			log.Printf("[consumeBuffers] %d %s items due in spool", len(items), kind)
+		}
+
+		for _, item := range items {
+			err := send(item.Payload)
+
+			if errors.Is(err, errRejected) {
+				log.Printf("[consumeBuffers] Server refused %s %d, moving it to dead letters: %s", kind, item.ID, err)
+				s.spool.DeadLetter(item.ID, err.Error())
+				continue
+			}
+
+			if err != nil {
+				backoff := spoolBackoff(item.Retries, s.config.Interval)
+				log.Printf("[consumeBuffers] Error posting %s %d (retry %d): %s, next attempt in %ds", kind, item.ID, item.Retries+1, err, backoff)
+				s.spool.Retry(item.ID, err.Error(), backoff)
+				return
+			}
+
+			s.spool.Delete(item.ID)
+		}
+	}
+}
+
+// spoolBackoff doubles the wait for each failed attempt, starting at the
+// scan interval and capped at SPOOL_MAX_BACKOFF seconds.
+func spoolBackoff(retries int, interval int) int {
+	if interval <= 0 {
+		interval = 1
+	}
+
+	backoff := interval
+	for i := 0; i < retries && backoff < SPOOL_MAX_BACKOFF; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, SPOOL_MAX_BACKOFF)
+}
+
+func (s *Spy) getSpoolStats(ctx *gin.Context) {
+	if len(s.children) > 0 {
+		if child := s.child(ctx); child != nil {
+			child.getSpoolStats(ctx)
+		}
+		return
+	}
+
+	stats, err := s.spool.Stats()
+
+	if err != nil {
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     err.Error(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"spool":     stats,
+		"dropped":   s.spool.Dropped(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// postAndAckMatch posts a match and acknowledges its elapsed time on the
+// ledger. Both happen under syncMu so a concurrent reconcile never sees the
+// same usage both on the server total and as pending.
+func (s *Spy) postAndAckMatch(match *domain.Match) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	err := s.postMatch(match)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ledger.Ack(match.User, ledgerDay(match.CreatedAt.In(s.targets.Location())), match.Name, match.Elapsed); err != nil {
+		log.Printf("[consumeBuffers] Error acking match on ledger: %s", err)
+	}
+
+	return nil
 }
 
 func (s *Spy) run(last time.Time) error {
+	if len(s.children) > 0 {
+		return s.runShared(last)
+	}
+
+	return s.scan(last, s.processes)
+}
+
+// scan credits and enforces the targets on the processes returned by list,
+// called once the targets are up to date.
+func (s *Spy) scan(last time.Time, list func() ([]*domain.Process, error)) error {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
 	var startedAt = time.Now()
 	defer func() {
 		log.Printf("[run] Process scan finished on %s", time.Since(startedAt).String())
@@ -285,30 +918,42 @@ func (s *Spy) run(last time.Time) error {
 	defer s.consumeBuffers()
 	s.updateTargets()
 
-	processes, err := ps.Processes()
+	processes, err := list()
 	if err != nil {
 		log.Printf("[run] Error getting processes: %s", err)
 		return err
 	}
 
+	credited := make(map[string]bool)
+	mode := s.targets.Mode(time.Now())
+	focused := s.focusedPid()
+	idle := s.idleSeconds()
+
+	if mode != domain.OVERRIDE_NORMAL {
+		log.Printf("[run] User is %s", s.targets.Override.Describe())
+	}
+
 	for _, target := range s.targets.Targets {
 		match := false
 		pids := make([]int, 0)
 		names := make(map[string]struct{})
+		fields := make(map[string]struct{})
 
 		for _, proc := range processes {
-			name := proc.Executable()
-
-			if target.Match(name) {
-				pid := proc.Pid()
+			if field, ok := target.MatchProcess(proc); ok {
 				match = true
-				pids = append(pids, pid)
-				names[name] = struct{}{}
+				pids = append(pids, proc.Pid)
+				names[proc.Executable] = struct{}{}
+				fields[field] = struct{}{}
+			} else if s.config.LogExcluded {
+				if by := target.Excluded(proc); by != "" {
+					log.Printf("[run]  > [%s] Process %s (%d) excluded by '%s'", target.Name, proc.Executable, proc.Pid, by)
+				}
 			}
 		}
 
 		if len(target.CheckCommand) > 0 {
-			log.Printf("[run]  > [%s] Use %.2f from %.2fs", target.Name, target.Elapsed, target.Limit)
+			log.Printf("[run]  > [%s] Use %.2f from %.2fs", target.Name, target.Elapsed, target.DailyLimit)
 			cmdLog, err := executeCommand(target.CheckCommand)
 
 			if err != nil {
@@ -319,7 +964,7 @@ func (s *Spy) run(last time.Time) error {
 
 			cmd := domain.NewCommand(s.config.User, target.Name, target.LimitCommand, cmdLog)
 			cmd.Source = "Check"
-			s.commandBuf <- cmd
+			s.enqueueCommand(cmd)
 		}
 
 		if match {
@@ -333,13 +978,53 @@ func (s *Spy) run(last time.Time) error {
 			strMatches := strings.Join(matches, " / ")
 
 			log.Printf("[run]  > [%s] Match process with pattern %s (%s) -> %v", target.Name, target.Pattern, matches, pids)
-			s.matchBuf <- domain.NewMatch(s.config.User, target.Name, target.Pattern, strMatches, elapsed)
+			credit := elapsed
+
+			// In the active accounting mode, processes in the background are
+			// still enforced but not credited.
+			if focused != nil && !slices.Contains(pids, *focused) {
+				log.Printf("[run]  > [%s] Not focused, not crediting %.2fs", target.Name, elapsed)
+				credit = 0
+			} else {
+				paused := target.IdlePause(idle, elapsed)
+				credit = roundFloat(elapsed-paused, 2)
+
+				if paused > 0 {
+					log.Printf("[run]  > [%s] Idle for %.0fs, pausing %.2fs", target.Name, idle, paused)
+				}
 
-			target.AddElapsed(elapsed)
-			log.Printf("[run]  > [%s] Add %.2fs -> Use %.2f from %.2fs", target.Name, elapsed, target.Elapsed, target.Limit)
+				newMatch := domain.NewMatch(s.config.User, target.Name, target.Pattern, strMatches, credit)
+				newMatch.Field = joinKeys(fields)
+				newMatch.Idle = paused
+				s.enqueueMatch(newMatch)
 
-			if target.CheckLimit() {
-				log.Printf("[run]  >> [%s] Exceeded limit of %.2f seconds", target.Name, target.Limit)
+				if err := s.ledger.AddElapsed(s.config.User, ledgerDay(newMatch.CreatedAt.In(s.targets.Location())), target.Name, credit); err != nil {
+					log.Printf("[run]  > [%s] Error adding elapsed to ledger: %s", target.Name, err)
+				}
+
+				target.AddElapsed(credit)
+				log.Printf("[run]  > [%s] Add %.2fs -> Use %.2f from %.2fs", target.Name, credit, target.Elapsed, target.DailyLimit)
+			}
+
+			allowed := target.Allowed(time.Now())
+			exhausted := s.creditGroups(target, credit, credited)
+
+			if mode == domain.OVERRIDE_LOCKED {
+				log.Printf("[run]  >> [%s] Locked, killing processes: %v", target.Name, pids)
+				s.kill(target.Name, strMatches, pids)
+			} else if mode == domain.OVERRIDE_UNLIMITED {
+				log.Printf("[run]  >> [%s] Limits lifted, not enforcing", target.Name)
+			} else if !allowed || target.CheckLimit() || exhausted != nil {
+				switch {
+				case !allowed:
+					log.Printf("[run]  >> [%s] Outside allowed schedule", target.Name)
+				case target.CheckQuota():
+					log.Printf("[run]  >> [%s] Exceeded quota, %.2f seconds used this week and %.2f this month", target.Name, target.WeeklyElapsed, target.MonthlyElapsed)
+				case target.CheckLimit():
+					log.Printf("[run]  >> [%s] Exceeded limit of %.2f seconds", target.Name, target.DailyLimit)
+				default:
+					log.Printf("[run]  >> [%s] Exceeded limit of %.2f seconds of group '%s'", target.Name, exhausted.DailyLimit, exhausted.Name)
+				}
 
 				if len(target.LimitCommand) > 0 {
 					cmdLog, err := executeCommand(target.LimitCommand)
@@ -352,7 +1037,7 @@ func (s *Spy) run(last time.Time) error {
 
 					cmd := domain.NewCommand(s.config.User, target.Name, target.LimitCommand, cmdLog)
 					cmd.Source = "Limit"
-					s.commandBuf <- cmd
+					s.enqueueCommand(cmd)
 				}
 
 				if target.Kill {
@@ -375,7 +1060,7 @@ func (s *Spy) run(last time.Time) error {
 
 						cmd := domain.NewCommand(s.config.User, target.Name, target.WarningCommand, cmdLog)
 						cmd.Source = "Warning"
-						s.commandBuf <- cmd
+						s.enqueueCommand(cmd)
 					}
 				}
 			}
@@ -405,7 +1090,7 @@ func (s *Spy) kill(name string, pattern string, pids []int) {
 
 			cmd := domain.NewCommand(s.config.User, name, fmt.Sprintf("PID %d from %s", pid, pattern), msg)
 			cmd.Source = fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
-			s.commandBuf <- cmd
+			s.enqueueCommand(cmd)
 		}
 	}
 }
@@ -417,7 +1102,18 @@ func (s *Spy) Start() {
 
 	s.enabled = true
 
-	log.Printf("[Start] Starting with config ->\n%s", s.config.ToJson())
+	log.Printf("[Start] Starting with config ->\n%s", s.config.Redacted().ToJson())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.pushCancel = cancel
+
+	if len(s.children) > 0 {
+		for _, child := range s.children {
+			go child.listenEvents(ctx)
+		}
+	} else {
+		go s.listenEvents(ctx)
+	}
 
 	for s.enabled {
 		s.run(last)
@@ -429,6 +1125,23 @@ func (s *Spy) Start() {
 func (s *Spy) Stop() {
 	s.enabled = false
 	s.stopHttpServer()
+
+	if s.pushCancel != nil {
+		s.pushCancel()
+	}
+
+	if s.ledger != nil {
+		if err := s.ledger.Close(); err != nil {
+			log.Printf("[Stop] Error closing ledger: %s", err)
+		}
+	}
+
+	for user, child := range s.children {
+		if err := child.ledger.Close(); err != nil {
+			log.Printf("[Stop] Error closing ledger of user '%s': %s", user, err)
+		}
+	}
+
 	log.Printf("[Stop] Stopping...")
 }
 
@@ -1,11 +1,15 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"procspy/internal/procspy/config"
 	"procspy/internal/procspy/domain"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -38,12 +42,12 @@ func TestNewSpy(t *testing.T) {
 		t.Error("Targets não foi inicializado")
 	}
 
-	if spy.commandBuf == nil {
-		t.Error("commandBuf não foi inicializado")
+	if spy.spool == nil {
+		t.Error("spool não foi inicializado")
 	}
 
-	if spy.matchBuf == nil {
-		t.Error("matchBuf não foi inicializado")
+	if spy.ledger == nil {
+		t.Error("ledger não foi inicializado")
 	}
 }
 
@@ -163,6 +167,35 @@ func TestSpy_httpGet(t *testing.T) {
 	})
 }
 
+// TestSpy_httpGet_Token testa envio do token do dispositivo
+func TestSpy_httpGet_Token(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer device-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		User:      "test",
+		Token:     "device-token",
+	}
+
+	spy := NewSpy(cfg)
+
+	if _, status, _ := spy.httpGet(server.URL); status != http.StatusOK {
+		t.Errorf("GET status = %d, esperado 200", status)
+	}
+
+	if _, status, _ := spy.httpPost(server.URL, "{}"); status != http.StatusOK {
+		t.Errorf("POST status = %d, esperado 200", status)
+	}
+}
+
 // TestSpy_httpPost testa requisições HTTP POST
 func TestSpy_httpPost(t *testing.T) {
 	cfg := &config.Client{
@@ -305,6 +338,258 @@ func TestSpy_updateTargets(t *testing.T) {
 	})
 }
 
+// TestSpy_updateTargets_Offline testa uso do ledger local sem servidor
+func TestSpy_updateTargets_Offline(t *testing.T) {
+	online := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !online {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"targets":[{"name":"games","pattern":"steam","elapsed":120}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		User:      "test",
+	}
+
+	spy := NewSpy(cfg)
+	spy.updateTargets()
+
+	if !spy.online {
+		t.Fatal("Spy deveria estar online")
+	}
+
+	if spy.targets.Targets[0].Elapsed != 120 {
+		t.Errorf("Elapsed = %.2f, esperado 120.00 vindo do servidor", spy.targets.Targets[0].Elapsed)
+	}
+
+	// Uso registrado localmente enquanto o servidor está fora
+	online = false
+	spy.ledger.AddElapsed("test", ledgerDay(time.Now()), "games", 30)
+
+	// Simula reinício: targets em memória perdidos
+	spy.targets = domain.NewTargetList()
+	spy.updateTargets()
+
+	if spy.online {
+		t.Error("Spy deveria estar offline")
+	}
+
+	if len(spy.targets.Targets) != 1 {
+		t.Fatalf("Esperado 1 target vindo do cache, obteve %d", len(spy.targets.Targets))
+	}
+
+	if spy.targets.Targets[0].Elapsed != 150 {
+		t.Errorf("Elapsed = %.2f, esperado 150.00 vindo do ledger", spy.targets.Targets[0].Elapsed)
+	}
+
+	// Servidor volta: pendente somado ao total do servidor
+	online = true
+	spy.updateTargets()
+
+	if spy.targets.Targets[0].Elapsed != 150 {
+		t.Errorf("Elapsed = %.2f, esperado 150.00 após reconciliação", spy.targets.Targets[0].Elapsed)
+	}
+}
+
+// TestSpy_updateTargets_OfflineQuota testa as cotas após a virada do dia sem servidor
+func TestSpy_updateTargets_OfflineQuota(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	today := ledgerDay(now)
+	yesterday := ledgerDay(now.AddDate(0, 0, -1))
+
+	tests := []struct {
+		name      string
+		weekStart time.Weekday
+		expected  float64
+	}{
+		// Ontem conta: 2000 de antes de ontem no servidor, 1000 de ontem e 200 de hoje no ledger
+		{"Semana começou anteontem", (now.Weekday() + 5) % 7, 3200},
+		{"Semana começou hoje", now.Weekday(), 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spy := NewSpy(&config.Client{
+				Interval:  30,
+				ServerURL: server.URL,
+				User:      "test",
+			})
+
+			// Targets recebidos ontem, antes de o servidor cair
+			data := fmt.Sprintf(`{"targets":[{"name":"games","pattern":"steam","weekly_limit":36000,"elapsed":1000,"weekly_elapsed":3000}],"week_start":%d}`, tt.weekStart)
+			spy.ledger.SaveTargets("test", yesterday, data)
+			spy.ledger.Reconcile("test", yesterday, "games", 1000)
+			spy.ledger.AddElapsed("test", today, "games", 200)
+
+			spy.updateTargets()
+
+			if spy.online || len(spy.targets.Targets) != 1 {
+				t.Fatalf("Esperado 1 target vindo do cache sem servidor, obteve %d", len(spy.targets.Targets))
+			}
+
+			if target := spy.targets.Targets[0]; target.Elapsed != 200 || target.WeeklyElapsed != tt.expected {
+				t.Errorf("Elapsed = %.0f, WeeklyElapsed = %.0f, esperado 200 e %.0f", target.Elapsed, target.WeeklyElapsed, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSpy_updateTargets_NotModified testa a revalidação por ETag
+func TestSpy_updateTargets_NotModified(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"targets":[{"name":"games","pattern":"steam","elapsed":120}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		User:      "test",
+	}
+
+	spy := NewSpy(cfg)
+	spy.updateTargets()
+
+	target := spy.targets.Targets[0]
+	target.Match("steam")
+
+	spy.ledger.AddElapsed("test", ledgerDay(time.Now()), "games", 30)
+	spy.updateTargets()
+
+	if requests != 2 || !spy.online {
+		t.Fatalf("Requisições = %d, online = %t, esperado 2 e online", requests, spy.online)
+	}
+
+	if spy.targets.Targets[0] != target {
+		t.Error("Targets e padrões compilados deveriam ser mantidos com 304")
+	}
+
+	// Pendente local somado ao último total do servidor, sem duplicar
+	if target.Elapsed != 150 {
+		t.Errorf("Elapsed = %.2f, esperado 150.00", target.Elapsed)
+	}
+
+	spy.updateTargets()
+
+	if target.Elapsed != 150 {
+		t.Errorf("Elapsed = %.2f, esperado 150.00 na revalidação seguinte", target.Elapsed)
+	}
+}
+
+// TestSpy_creditGroups testa o orçamento compartilhado por grupos de targets
+func TestSpy_creditGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"targets": [
+				{"name": "games", "pattern": "steam", "elapsed": 600, "groups": ["entertainment"]},
+				{"name": "videos", "pattern": "vlc", "elapsed": 600, "groups": ["entertainment", "unknown"]},
+				{"name": "school", "pattern": "libreoffice"}
+			],
+			"groups": [{"name": "entertainment", "limit": "15m", "elapsed": 840}]
+		}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		User:      "test",
+	}
+
+	spy := NewSpy(cfg)
+	spy.updateTargets()
+
+	group := spy.targets.Group("entertainment")
+	if group == nil || group.Elapsed != 840 {
+		t.Fatalf("Grupo não carregado com o uso do servidor: %+v", group)
+	}
+
+	games, videos, school := spy.targets.Targets[0], spy.targets.Targets[1], spy.targets.Targets[2]
+	credited := make(map[string]bool)
+
+	// Primeiro membro da varredura credita o grupo, que ainda tem tempo
+	if exhausted := spy.creditGroups(games, 30, credited); exhausted != nil {
+		t.Errorf("Grupo não deveria estar esgotado com %.0fs", group.Elapsed)
+	}
+
+	// Segundo membro na mesma varredura não conta de novo
+	spy.creditGroups(videos, 30, credited)
+
+	if group.Elapsed != 870 {
+		t.Errorf("Elapsed do grupo = %.0f, esperado 870 (união dos membros)", group.Elapsed)
+	}
+
+	if exhausted := spy.creditGroups(school, 30, credited); exhausted != nil {
+		t.Error("Target fora do grupo não deveria ser bloqueado")
+	}
+
+	// Próxima varredura esgota o grupo para todos os membros
+	credited = make(map[string]bool)
+	if exhausted := spy.creditGroups(videos, 30, credited); exhausted != group {
+		t.Errorf("Grupo deveria estar esgotado com %.0fs de %.0fs", group.Elapsed, group.DailyLimit)
+	}
+
+	if exhausted := spy.creditGroups(games, 30, credited); exhausted != group {
+		t.Error("Grupo deveria bloquear também os demais membros")
+	}
+
+	// Uso local do grupo fica no ledger e é mantido na próxima atualização
+	spy.updateTargets()
+
+	if group := spy.targets.Group("entertainment"); group.Elapsed != 900 {
+		t.Errorf("Elapsed do grupo = %.0f, esperado 900 após reconciliação", group.Elapsed)
+	}
+}
+
+// TestSpy_postAndAckMatch testa confirmação do pendente no ledger
+func TestSpy_postAndAckMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := &config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		User:      "test",
+	}
+
+	spy := NewSpy(cfg)
+	match := domain.NewMatch("test", "games", "steam", "steam", 30)
+	day := ledgerDay(match.CreatedAt)
+	spy.ledger.AddElapsed("test", day, "games", 30)
+
+	if err := spy.postAndAckMatch(match); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	// Servidor já contém os 30s, nada pendente: merge não duplica
+	merged, _ := spy.ledger.Reconcile("test", day, "games", 30)
+	if merged != 30 {
+		t.Errorf("merged = %.2f, esperado 30.00", merged)
+	}
+}
+
 // TestSpy_postMatch testa envio de matches
 func TestSpy_postMatch(t *testing.T) {
 	t.Run("POST match com sucesso", func(t *testing.T) {
@@ -449,10 +734,14 @@ func TestSpy_postCommand(t *testing.T) {
 	})
 }
 
-// TestSpy_consumeBuffers testa consumo de buffers
+// TestSpy_consumeBuffers testa consumo do spool
 func TestSpy_consumeBuffers(t *testing.T) {
-	t.Run("Consumir buffers com sucesso", func(t *testing.T) {
+	t.Run("Consumir spool com servidor sem /batch", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/batch/") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
 			w.WriteHeader(http.StatusCreated)
 			w.Write([]byte(`{"status":"created"}`))
 		}))
@@ -465,24 +754,77 @@ func TestSpy_consumeBuffers(t *testing.T) {
 		}
 
 		spy := NewSpy(cfg)
-		match := domain.NewMatch("test", "app", ".*app.*", "app.exe", 10.5)
-		cmd := domain.NewCommand("test", "app", "shutdown", "executed")
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 10.5))
+		spy.enqueueCommand(domain.NewCommand("test", "app", "shutdown", "executed"))
 
-		spy.matchBuf <- match
-		spy.commandBuf <- cmd
+		spy.consumeBuffers()
+		waitDrain(spy)
+
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_MATCH); depth > 0 {
+			t.Errorf("Spool de matches deveria estar vazio, obteve %d", depth)
+		}
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_COMMAND); depth > 0 {
+			t.Errorf("Spool de commands deveria estar vazio, obteve %d", depth)
+		}
+	})
+
+	t.Run("Consumir spool em lote", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.URL.Path != "/batch/test" {
+				t.Errorf("Path inesperado: %s", r.URL.Path)
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			batch, _ := domain.BatchFromJson(string(body))
+			result := domain.NewBatchResult()
+			for i, m := range batch.Matches {
+				status := domain.BATCH_STATUS_INSERTED
+				if i == 1 {
+					status = domain.BATCH_STATUS_DUPLICATE
+				}
+				result.Matches = append(result.Matches, &domain.BatchItemResult{EventID: m.EventID, Status: status})
+			}
+			for _, c := range batch.Commands {
+				result.Commands = append(result.Commands, &domain.BatchItemResult{EventID: c.EventID, Status: domain.BATCH_STATUS_ERROR, Error: "fail"})
+			}
+			data, _ := json.Marshal(result)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+		}))
+		defer server.Close()
+
+		cfg := &config.Client{
+			Interval:  30,
+			ServerURL: server.URL,
+			User:      "test",
+		}
+
+		spy := NewSpy(cfg)
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 10.5))
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 5))
+		spy.enqueueCommand(domain.NewCommand("test", "app", "shutdown", "executed"))
 
 		spy.consumeBuffers()
-		time.Sleep(100 * time.Millisecond)
+		waitDrain(spy)
 
-		if len(spy.matchBuf) > 0 {
-			t.Error("Match buffer deveria estar vazio")
+		if requests != 1 {
+			t.Errorf("Esperado 1 requisição em lote, obteve %d", requests)
 		}
-		if len(spy.commandBuf) > 0 {
-			t.Error("Command buffer deveria estar vazio")
+
+		// Inseridos e duplicados saem do spool
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_MATCH); depth != 0 {
+			t.Errorf("Spool de matches deveria estar vazio, obteve %d", depth)
+		}
+
+		// Item com erro fica no spool para nova tentativa
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_COMMAND); depth != 1 {
+			t.Errorf("Spool de commands deveria manter 1 item, obteve %d", depth)
 		}
 	})
 
-	t.Run("Consumir buffers com erro", func(t *testing.T) {
+	t.Run("Consumir spool com erro", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 		}))
@@ -495,41 +837,160 @@ func TestSpy_consumeBuffers(t *testing.T) {
 		}
 
 		spy := NewSpy(cfg)
-		match := domain.NewMatch("test", "app", ".*app.*", "app.exe", 10.5)
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 10.5))
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 5))
 
-		spy.matchBuf <- match
 		spy.consumeBuffers()
-		time.Sleep(100 * time.Millisecond)
+		waitDrain(spy)
+
+		// Com erro, os matches continuam no spool e o primeiro ganha backoff
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_MATCH); depth != 2 {
+			t.Errorf("Spool deveria manter 2 matches após erro, obteve %d", depth)
+		}
 
-		// Com erro, o match volta para o buffer (DLQ)
-		if len(spy.matchBuf) == 0 {
-			t.Error("Match deveria estar de volta no buffer após erro")
+		stats, _ := spy.spool.Stats()
+		if stats[SPOOL_KIND_MATCH].MaxRetries != 1 {
+			t.Errorf("MaxRetries = %d, esperado 1", stats[SPOOL_KIND_MATCH].MaxRetries)
 		}
 	})
 
-	t.Run("Consumir buffers nil", func(t *testing.T) {
+	t.Run("Payload inválido é descartado", func(t *testing.T) {
 		cfg := &config.Client{
 			Interval:  30,
-			ServerURL: "http://localhost:8080",
+			ServerURL: "http://localhost:1",
 			User:      "test",
 		}
 
 		spy := NewSpy(cfg)
-		spy.matchBuf = nil
-		spy.commandBuf = nil
+		spy.spool.Push(SPOOL_KIND_MATCH, "invalid json")
 
 		spy.consumeBuffers()
-		time.Sleep(50 * time.Millisecond)
+		waitDrain(spy)
 
-		if spy.matchBuf == nil {
-			t.Error("Match buffer deveria ser inicializado")
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_MATCH); depth != 0 {
+			t.Errorf("Payload inválido deveria ser descartado, obteve %d", depth)
 		}
-		if spy.commandBuf == nil {
-			t.Error("Command buffer deveria ser inicializado")
+	})
+
+	t.Run("Item em backoff segura os seguintes", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if strings.HasPrefix(r.URL.Path, "/batch/") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		spy := NewSpy(&config.Client{Interval: 30, ServerURL: server.URL, User: "test"})
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 10.5))
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 5))
+
+		items, _ := spy.spool.Next(SPOOL_KIND_MATCH, 10)
+		spy.spool.Retry(items[0].ID, "timeout", 60)
+
+		spy.consumeBuffers()
+		waitDrain(spy)
+
+		if requests.Load() != 0 {
+			t.Errorf("Nenhum match deveria ser enviado antes do mais antigo, obteve %d requisições", requests.Load())
+		}
+
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_MATCH); depth != 2 {
+			t.Errorf("Spool deveria manter 2 matches, obteve %d", depth)
+		}
+	})
+
+	t.Run("Item em backoff segura o lote", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"matches":[],"commands":[]}`))
+		}))
+		defer server.Close()
+
+		spy := NewSpy(&config.Client{Interval: 30, ServerURL: server.URL, User: "test"})
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 10.5))
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 5))
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 7))
+
+		items, _ := spy.spool.Next(SPOOL_KIND_MATCH, 10)
+		spy.spool.Retry(items[0].ID, "timeout", 60)
+
+		if !spy.drainBatches() {
+			t.Error("drainBatches() deveria concluir sem voltar ao envio item a item")
+		}
+
+		if requests.Load() != 0 {
+			t.Errorf("Nenhum lote deveria ser enviado antes do match mais antigo, obteve %d requisições", requests.Load())
+		}
+
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_MATCH); depth != 3 {
+			t.Errorf("Spool deveria manter 3 matches, obteve %d", depth)
+		}
+	})
+
+	t.Run("Item recusado vai para dead letter", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if strings.HasPrefix(r.URL.Path, "/batch/") || strings.Contains(string(body), "bad.exe") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		spy := NewSpy(&config.Client{Interval: 30, ServerURL: server.URL, User: "test"})
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "bad.exe", 10.5))
+		spy.enqueueMatch(domain.NewMatch("test", "app", ".*app.*", "app.exe", 5))
+
+		spy.consumeBuffers()
+		waitDrain(spy)
+
+		// O lote recusado é enviado item a item: o recusado sai da fila e o seguinte é entregue
+		if depth, _ := spy.spool.Depth(SPOOL_KIND_MATCH); depth != 0 {
+			t.Errorf("Spool de matches deveria estar vazio, obteve %d", depth)
+		}
+
+		stats, _ := spy.spool.Stats()
+		if stats[SPOOL_KIND_MATCH] == nil || stats[SPOOL_KIND_MATCH].Dead != 1 {
+			t.Errorf("Stats = %+v, esperado 1 dead letter", stats[SPOOL_KIND_MATCH])
 		}
 	})
 }
 
+// TestSpoolBackoff testa o backoff exponencial do spool
+func TestSpoolBackoff(t *testing.T) {
+	tests := []struct {
+		retries  int
+		interval int
+		expected int
+	}{
+		{0, 30, 30},
+		{1, 30, 60},
+		{3, 30, 240},
+		{20, 30, SPOOL_MAX_BACKOFF},
+		{0, 0, 1},
+	}
+
+	for _, tt := range tests {
+		result := spoolBackoff(tt.retries, tt.interval)
+		if result != tt.expected {
+			t.Errorf("spoolBackoff(%d, %d) = %d, esperado %d", tt.retries, tt.interval, result, tt.expected)
+		}
+	}
+}
+
+func waitDrain(spy *Spy) {
+	for i := 0; i < 100 && spy.draining.Load(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 // TestSpy_kill testa terminação de processos
 func TestSpy_kill(t *testing.T) {
 	cfg := &config.Client{
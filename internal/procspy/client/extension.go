@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestExtension forwards a request for more time to the server, where it
+// waits for a parent. Requests are not spooled: the user must know when one
+// could not be sent. An approval arrives later as a grant on the targets.
+func (s *Spy) requestExtension(ctx *gin.Context) {
+	if len(s.children) > 0 {
+		if child := s.child(ctx); child != nil {
+			child.requestExtension(ctx)
+		}
+		return
+	}
+
+	extension := &domain.Extension{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, extension)
+	}
+
+	if err != nil || extension.Target == "" {
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json, target is required",
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if !s.hasTarget(extension.Target) {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     fmt.Sprintf("unknown target '%s'", extension.Target),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	extensionUrl := fmt.Sprintf("%s/extensions/%s", s.config.ServerURL, s.config.User)
+
+	data, status, err := s.httpPost(extensionUrl, extension.ToLog())
+
+	if err != nil {
+		log.Printf("[requestExtension] Error sending extension request for '%s' to %s: %s", extension.Target, extensionUrl, err)
+		ctx.IndentedJSON(http.StatusServiceUnavailable, gin.H{
+			"error":     "server unavailable, try again later",
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[requestExtension] Extension request for '%s' sent to the server (HTTP %d)", extension.Target, status)
+
+	ctx.Data(status, "application/json", []byte(data))
+}
+
+func (s *Spy) hasTarget(name string) bool {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	for _, target := range s.targets.Targets {
+		if target.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
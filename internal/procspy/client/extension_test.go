@@ -0,0 +1,71 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSpy_requestExtension testa o encaminhamento do pedido de tempo ao servidor
+func TestSpy_requestExtension(t *testing.T) {
+	received := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/extensions/test" || r.Header.Get("Authorization") != "Bearer device-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"extension":{"id":1,"status":"pending"}}`))
+	}))
+	defer server.Close()
+
+	spy := NewSpy(&config.Client{Interval: 30, ServerURL: server.URL, User: "test", Token: "device-token"})
+	spy.targets.Targets = []*domain.Target{{Name: "games", Pattern: "steam"}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/request-extension", spy.requestExtension)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"Pedido encaminhado", `{"target":"games","reason":"terminar a partida","duration":"15m"}`, 201},
+		{"Sem target", `{"reason":"mais tempo"}`, 400},
+		{"JSON inválido", `{`, 400},
+		{"Target desconhecido", `{"target":"videos"}`, 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/request-extension", strings.NewReader(tt.body)))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	extension, err := domain.ExtensionFromJson(received)
+	if err != nil || extension.Target != "games" || extension.Reason != "terminar a partida" || extension.Duration != 900 {
+		t.Errorf("Pedido recebido pelo servidor = %s, %v", received, err)
+	}
+
+	// Servidor fora do ar: o pedido não é guardado para depois
+	server.Close()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/request-extension", strings.NewReader(`{"target":"games"}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Status = %d, esperado 503 com o servidor fora do ar", w.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Accounting modes of the client. With "running", the default, a target is
+// credited while any of its processes runs; with "active", only while one of
+// them owns the focused window.
+const ACCOUNTING_RUNNING = "running"
+const ACCOUNTING_ACTIVE = "active"
+
+// FocusProvider tells which process owns the focused window, for the active
+// accounting mode.
+type FocusProvider interface {
+	// ActivePid returns the pid of the process owning the focused window, or
+	// 0 when no window is focused.
+	ActivePid() (int, error)
+}
+
+// focusedPid returns the pid owning the focused window, or nil when time is
+// credited to every running process, either because the accounting mode is
+// not active or because the focus cannot be read.
+func (s *Spy) focusedPid() *int {
+	if s.focus == nil {
+		return nil
+	}
+
+	pid, err := s.focus.ActivePid()
+
+	if err != nil {
+		log.Printf("[run] Error reading the focused window, crediting running processes: %s", err)
+		return nil
+	}
+
+	return &pid
+}
+
+// parseXprop returns the value of a property printed by xprop, such as
+// "_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3e00004" or
+// "_NET_WM_PID(CARDINAL) = 4242".
+func parseXprop(output string) (string, error) {
+	output = strings.TrimSpace(output)
+
+	if i := strings.LastIndexAny(output, "#="); i >= 0 {
+		if value := strings.TrimSpace(output[i+1:]); value != "" {
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("unexpected xprop output '%s'", output)
+}
+
+// parseXpropPid returns the pid of a _NET_WM_PID property printed by xprop.
+func parseXpropPid(output string) (int, error) {
+	value, err := parseXprop(output)
+
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("unexpected xprop pid '%s'", value)
+	}
+
+	return pid, nil
+}
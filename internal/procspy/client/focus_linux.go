@@ -0,0 +1,45 @@
+//go:build linux
+
+package client
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// xpropFocus reads the focused window from the _NET_ACTIVE_WINDOW property
+// of the root window with xprop. It needs DISPLAY (and XAUTHORITY, when the
+// client runs as another user) and, on Wayland, only sees XWayland windows.
+type xpropFocus struct{}
+
+func newFocusProvider() FocusProvider {
+	return &xpropFocus{}
+}
+
+func (f *xpropFocus) ActivePid() (int, error) {
+	out, err := exec.Command("xprop", "-root", "_NET_ACTIVE_WINDOW").Output()
+
+	if err != nil {
+		return 0, fmt.Errorf("reading _NET_ACTIVE_WINDOW: %v", err)
+	}
+
+	window, err := parseXprop(string(out))
+
+	if err != nil {
+		return 0, err
+	}
+
+	// The desktop or nothing has the focus.
+	if strings.TrimLeft(strings.TrimPrefix(window, "0x"), "0") == "" {
+		return 0, nil
+	}
+
+	out, err = exec.Command("xprop", "-id", window, "_NET_WM_PID").Output()
+
+	if err != nil {
+		return 0, fmt.Errorf("reading _NET_WM_PID of window %s: %v", window, err)
+	}
+
+	return parseXpropPid(string(out))
+}
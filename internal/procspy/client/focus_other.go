@@ -0,0 +1,15 @@
+//go:build !linux
+
+package client
+
+import (
+	"log"
+	"runtime"
+)
+
+// newFocusProvider returns no provider, as the focused window is only read
+// on Linux, so every running process is credited.
+func newFocusProvider() FocusProvider {
+	log.Printf("[NewSpy] Active accounting is not supported on %s, crediting running processes", runtime.GOOS)
+	return nil
+}
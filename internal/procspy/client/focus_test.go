@@ -0,0 +1,119 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"procspy/internal/procspy/config"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// fakeFocus é um FocusProvider com o pid da janela em foco fixo
+type fakeFocus struct {
+	pid int
+	err error
+}
+
+func (f *fakeFocus) ActivePid() (int, error) {
+	return f.pid, f.err
+}
+
+// TestParseXpropPid testa a leitura das propriedades impressas pelo xprop
+func TestParseXpropPid(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected int
+		wantErr  bool
+	}{
+		{"Pid da janela", "_NET_WM_PID(CARDINAL) = 4242\n", 4242, false},
+		{"Propriedade ausente", "_NET_WM_PID:  not found.\n", 0, true},
+		{"Valor inválido", "_NET_WM_PID(CARDINAL) = abc\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pid, err := parseXpropPid(tt.output)
+			if (err != nil) != tt.wantErr || pid != tt.expected {
+				t.Errorf("parseXpropPid() = %d, %v, esperado %d, erro %v", pid, err, tt.expected, tt.wantErr)
+			}
+		})
+	}
+
+	window, err := parseXprop("_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3e00004\n")
+	if err != nil || window != "0x3e00004" {
+		t.Errorf("parseXprop() = %q, %v, esperado 0x3e00004", window, err)
+	}
+}
+
+// ownPattern retorna um pattern que casa só com o executável do próprio
+// processo do teste
+func ownPattern(t *testing.T) string {
+	processes, err := listProcesses(false)
+	if err != nil {
+		t.Fatalf("listProcesses() erro = %v", err)
+	}
+
+	for _, process := range processes {
+		if process.Pid == os.Getpid() {
+			return "^" + regexp.QuoteMeta(process.Executable) + "$"
+		}
+	}
+
+	t.Skip("Processo do teste não encontrado")
+	return ""
+}
+
+// newScanServer sobe um servidor com um target que casa com o próprio
+// processo do teste, com os campos extras informados
+func newScanServer(t *testing.T, extra string) *httptest.Server {
+	pattern := ownPattern(t)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprintf(w, `{"targets":[{"name":"tests","pattern":%q,"limit":3600%s}]}`, pattern, extra)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+}
+
+// TestSpy_run_Active testa o crédito de tempo só para o processo em foco
+func TestSpy_run_Active(t *testing.T) {
+	server := newScanServer(t, "")
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		focus    FocusProvider
+		credited bool
+	}{
+		{"Todos os processos", nil, true},
+		{"Processo em foco", &fakeFocus{pid: os.Getpid()}, true},
+		{"Processo em segundo plano", &fakeFocus{pid: 0}, false},
+		{"Foco indisponível", &fakeFocus{err: fmt.Errorf("no display")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spy := NewSpy(&config.Client{Interval: 30, ServerURL: server.URL, User: "test", Accounting: ACCOUNTING_ACTIVE})
+			spy.focus = tt.focus
+
+			if err := spy.run(time.Now().Add(-30 * time.Second)); err != nil {
+				t.Fatalf("run() erro = %v", err)
+			}
+
+			if len(spy.targets.Targets) != 1 {
+				t.Fatalf("Targets = %d, esperado 1", len(spy.targets.Targets))
+			}
+
+			if credited := spy.targets.Targets[0].Elapsed > 0; credited != tt.credited {
+				t.Errorf("Tempo creditado = %.2f, esperado crédito %v", spy.targets.Targets[0].Elapsed, tt.credited)
+			}
+		})
+	}
+}
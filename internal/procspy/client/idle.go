@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdleProvider tells for how long the user has had no keyboard or mouse
+// input, for the targets that pause when the user is away.
+type IdleProvider interface {
+	IdleTime() (time.Duration, error)
+}
+
+// idleSeconds returns for how long the user has been idle, or 0 when no
+// target pauses or the idle time cannot be read, so time is credited.
+func (s *Spy) idleSeconds() float64 {
+	if s.idle == nil || !s.targets.NeedsIdle() {
+		return 0
+	}
+
+	idle, err := s.idle.IdleTime()
+
+	if err != nil {
+		log.Printf("[run] Error reading the idle time, not pausing: %s", err)
+		return 0
+	}
+
+	return idle.Seconds()
+}
+
+// parseXprintidle returns the idle time printed by xprintidle, in
+// milliseconds.
+func parseXprintidle(output string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("unexpected xprintidle output '%s'", strings.TrimSpace(output))
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// parseSessions returns the ids of the logind sessions listed by loginctl
+// list-sessions --no-legend whose uid or user is one of the accounts.
+func parseSessions(output string, accounts map[string]bool) []string {
+	ret := make([]string, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) >= 3 && (accounts[fields[1]] || accounts[fields[2]]) {
+			ret = append(ret, fields[0])
+		}
+	}
+
+	return ret
+}
+
+// parseIdleHint returns the idle time of a logind session from the
+// IdleHint and IdleSinceHint properties printed by loginctl, the latter in
+// microseconds since the epoch.
+func parseIdleHint(output string, now time.Time) (time.Duration, error) {
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	for scanner.Scan() {
+		if key, value, found := strings.Cut(scanner.Text(), "="); found {
+			properties[key] = strings.TrimSpace(value)
+		}
+	}
+
+	if properties["IdleHint"] != "yes" {
+		return 0, nil
+	}
+
+	since, err := strconv.ParseInt(properties["IdleSinceHint"], 10, 64)
+
+	if err != nil || since <= 0 {
+		return 0, fmt.Errorf("unexpected IdleSinceHint '%s'", properties["IdleSinceHint"])
+	}
+
+	return max(0, now.Sub(time.UnixMicro(since))), nil
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// sessionIdle reads the idle time from the X11 screensaver extension with
+// xprintidle and, when that fails (no X display, Wayland), from the IdleHint
+// of the logind session of the client.
+type sessionIdle struct{}
+
+func newIdleProvider() IdleProvider {
+	return &sessionIdle{}
+}
+
+func (i *sessionIdle) IdleTime() (time.Duration, error) {
+	out, err := exec.Command("xprintidle").Output()
+
+	if err == nil {
+		return parseXprintidle(string(out))
+	}
+
+	out, hintErr := exec.Command("loginctl", "show-session", "auto", "-p", "IdleHint", "-p", "IdleSinceHint").Output()
+
+	if hintErr != nil {
+		return 0, fmt.Errorf("xprintidle: %v, loginctl: %v", err, hintErr)
+	}
+
+	return parseIdleHint(string(out), time.Now())
+}
+
+// accountIdle reads the idle time of the OS accounts of a child on a shared
+// computer from the IdleHint of their logind sessions. xprintidle is not
+// used, as it reads the display of the client and not of the child.
+type accountIdle struct {
+	accounts map[string]bool
+}
+
+func newAccountIdleProvider(accounts map[string]bool) IdleProvider {
+	return &accountIdle{accounts: accounts}
+}
+
+// IdleTime returns the idle time of the least idle session of the accounts,
+// so the child is only away when none of their sessions has input.
+func (i *accountIdle) IdleTime() (time.Duration, error) {
+	out, err := exec.Command("loginctl", "list-sessions", "--no-legend").Output()
+
+	if err != nil {
+		return 0, fmt.Errorf("loginctl: %v", err)
+	}
+
+	sessions := parseSessions(string(out), i.accounts)
+
+	if len(sessions) == 0 {
+		return 0, errors.New("no logind session of the accounts")
+	}
+
+	ret := time.Duration(-1)
+
+	for _, session := range sessions {
+		out, err := exec.Command("loginctl", "show-session", session, "-p", "IdleHint", "-p", "IdleSinceHint").Output()
+
+		if err != nil {
+			return 0, fmt.Errorf("loginctl: %v", err)
+		}
+
+		idle, err := parseIdleHint(string(out), time.Now())
+
+		if err != nil {
+			return 0, err
+		}
+
+		if ret < 0 || idle < ret {
+			ret = idle
+		}
+	}
+
+	return ret, nil
+}
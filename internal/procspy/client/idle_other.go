@@ -0,0 +1,15 @@
+//go:build !linux
+
+package client
+
+// newIdleProvider returns no provider, as the idle time is only read on
+// Linux, so targets never pause.
+func newIdleProvider() IdleProvider {
+	return nil
+}
+
+// newAccountIdleProvider returns no provider, as the idle time is only read
+// on Linux, so targets never pause.
+func newAccountIdleProvider(accounts map[string]bool) IdleProvider {
+	return nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"procspy/internal/procspy/config"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeIdle é um IdleProvider com o tempo ocioso fixo
+type fakeIdle struct {
+	idle time.Duration
+	err  error
+}
+
+func (f *fakeIdle) IdleTime() (time.Duration, error) {
+	return f.idle, f.err
+}
+
+// TestParseIdle testa a leitura do xprintidle e do IdleHint do logind
+func TestParseIdle(t *testing.T) {
+	idle, err := parseXprintidle("125000\n")
+	if err != nil || idle != 125*time.Second {
+		t.Errorf("parseXprintidle() = %s, %v, esperado 2m5s", idle, err)
+	}
+
+	if _, err := parseXprintidle("couldn't open display\n"); err == nil {
+		t.Error("parseXprintidle() deveria recusar uma saída inválida")
+	}
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-10 * time.Minute).UnixMicro()
+
+	tests := []struct {
+		name     string
+		output   string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"Sessão ociosa", fmt.Sprintf("IdleHint=yes\nIdleSinceHint=%d\n", since), 10 * time.Minute, false},
+		{"Sessão em uso", fmt.Sprintf("IdleHint=no\nIdleSinceHint=%d\n", since), 0, false},
+		{"Sem IdleSinceHint", "IdleHint=yes\nIdleSinceHint=0\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idle, err := parseIdleHint(tt.output, now)
+			if (err != nil) != tt.wantErr || idle != tt.expected {
+				t.Errorf("parseIdleHint() = %s, %v, esperado %s, erro %v", idle, err, tt.expected, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParseSessions testa a escolha das sessões do logind das contas de um filho
+func TestParseSessions(t *testing.T) {
+	output := `     2 1000 alice seat0 tty2
+     5 1001 bob   -     pts/0
+    c1 1002 mae   seat0 tty1
+`
+
+	tests := []struct {
+		name     string
+		accounts map[string]bool
+		expected []string
+	}{
+		{"Por login", map[string]bool{"alice": true}, []string{"2"}},
+		{"Por uid", map[string]bool{"1001": true}, []string{"5"}},
+		{"Várias contas", map[string]bool{"alice": true, "1001": true}, []string{"2", "5"}},
+		{"Sem sessão", map[string]bool{"carol": true}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sessions := parseSessions(output, tt.accounts); !slices.Equal(sessions, tt.expected) {
+				t.Errorf("parseSessions() = %v, esperado %v", sessions, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSpy_run_Idle testa a pausa do tempo quando o usuário está ausente
+func TestSpy_run_Idle(t *testing.T) {
+	server := newScanServer(t, `,"idle_after":"5m"`)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		idle     IdleProvider
+		expected float64
+	}{
+		{"Sem leitura de ociosidade", nil, 30},
+		{"Em uso", &fakeIdle{idle: time.Minute}, 30},
+		{"Ocioso durante parte do scan", &fakeIdle{idle: 5*time.Minute + 10*time.Second}, 20},
+		{"Ausente", &fakeIdle{idle: time.Hour}, 0},
+		{"Ociosidade indisponível", &fakeIdle{err: fmt.Errorf("no session")}, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spy := NewSpy(&config.Client{Interval: 30, ServerURL: server.URL, User: "test"})
+			spy.idle = tt.idle
+
+			if err := spy.run(time.Now().Add(-30 * time.Second)); err != nil {
+				t.Fatalf("run() erro = %v", err)
+			}
+
+			if len(spy.targets.Targets) != 1 {
+				t.Fatalf("Targets = %d, esperado 1", len(spy.targets.Targets))
+			}
+
+			if elapsed := spy.targets.Targets[0].Elapsed; math.Abs(elapsed-tt.expected) > 0.5 {
+				t.Errorf("Tempo creditado = %.2f, esperado %.2f", elapsed, tt.expected)
+			}
+		})
+	}
+}
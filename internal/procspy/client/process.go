@@ -0,0 +1,42 @@
+package client
+
+import (
+	"procspy/internal/procspy/domain"
+
+	"github.com/mitchellh/go-ps"
+)
+
+// listProcesses returns the running processes with the executable name of
+// their parent. The path, command line and user are only read when details
+// is set, as they cost a read of /proc per process.
+func listProcesses(details bool) ([]*domain.Process, error) {
+	processes, err := ps.Processes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string, len(processes))
+
+	for _, proc := range processes {
+		names[proc.Pid()] = proc.Executable()
+	}
+
+	ret := make([]*domain.Process, 0, len(processes))
+
+	for _, proc := range processes {
+		process := &domain.Process{
+			Pid:        proc.Pid(),
+			Executable: proc.Executable(),
+			Parent:     names[proc.PPid()],
+		}
+
+		if details {
+			readDetails(process)
+		}
+
+		ret = append(ret, process)
+	}
+
+	return ret, nil
+}
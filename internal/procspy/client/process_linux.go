@@ -0,0 +1,61 @@
+//go:build linux
+
+package client
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"procspy/internal/procspy/domain"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// userNames caches the names of the uids that own processes.
+var userNames sync.Map
+
+// readDetails fills the path, command line and owning user of a process
+// from /proc. Fields that cannot be read, such as those of processes of
+// other users without privileges, are left empty.
+func readDetails(process *domain.Process) {
+	dir := filepath.Join("/proc", strconv.Itoa(process.Pid))
+
+	if path, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		process.Path = strings.TrimSuffix(path, " (deleted)")
+	}
+
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		process.Cmdline = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+
+	info, err := os.Stat(dir)
+
+	if err != nil {
+		return
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		process.UID = strconv.FormatUint(uint64(stat.Uid), 10)
+		process.User = userName(stat.Uid)
+	}
+}
+
+// userName returns the name of a uid, or the uid itself when it has none.
+func userName(uid uint32) string {
+	if name, found := userNames.Load(uid); found {
+		return name.(string)
+	}
+
+	id := strconv.FormatUint(uint64(uid), 10)
+	name := id
+
+	if u, err := user.LookupId(id); err == nil {
+		name = u.Username
+	}
+
+	userNames.Store(uid, name)
+
+	return name
+}
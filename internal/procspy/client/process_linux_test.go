@@ -0,0 +1,30 @@
+//go:build linux
+
+package client
+
+import (
+	"os"
+	"testing"
+)
+
+// TestListProcesses testa a leitura dos detalhes do próprio processo em /proc
+func TestListProcesses(t *testing.T) {
+	processes, err := listProcesses(true)
+	if err != nil {
+		t.Fatalf("listProcesses() erro = %v", err)
+	}
+
+	for _, process := range processes {
+		if process.Pid != os.Getpid() {
+			continue
+		}
+
+		if process.Path == "" || process.Cmdline == "" || process.User == "" {
+			t.Errorf("Detalhes incompletos: %+v", process)
+		}
+
+		return
+	}
+
+	t.Error("Processo do teste não encontrado")
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package client
+
+import "procspy/internal/procspy/domain"
+
+// readDetails leaves the path, command line, user and uid of a process empty:
+// they are only read from /proc on Linux.
+func readDetails(process *domain.Process) {}
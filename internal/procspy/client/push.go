@@ -0,0 +1,215 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"strings"
+	"time"
+)
+
+// PUSH_MAX_BACKOFF caps, in seconds, the wait between reconnections to the
+// event stream. Targets are still polled on every scan meanwhile.
+const PUSH_MAX_BACKOFF = 60
+
+// PUSH_MAX_EVENT_SIZE is the largest event line accepted from the server.
+const PUSH_MAX_EVENT_SIZE = 1024 * 1024
+
+// listenEvents keeps the event stream of the server open until ctx is
+// cancelled, reconnecting with exponential backoff when it drops.
+func (s *Spy) listenEvents(ctx context.Context) {
+	retries := 0
+
+	for ctx.Err() == nil {
+		connected, err := s.streamEvents(ctx)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			retries = 0
+		}
+
+		wait := pushBackoff(retries)
+		retries++
+
+		log.Printf("[listenEvents] Event stream unavailable: %v, reconnecting in %s (targets are polled every %ds meanwhile)", err, wait, s.config.Interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// streamEvents reads the event stream until it ends. It reports whether the
+// server accepted the connection.
+func (s *Spy) streamEvents(ctx context.Context) (bool, error) {
+	eventsUrl := fmt.Sprintf("%s/events/%s", s.config.ServerURL, s.config.User)
+
+	req, err := s.newRequest(http.MethodGet, eventsUrl, nil)
+	if err != nil {
+		return false, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	log.Printf("[listenEvents] Connected to event stream %s", eventsUrl)
+
+	err = readEvents(res.Body, s.handleEvent)
+
+	if err == nil {
+		err = io.EOF
+	}
+
+	return true, err
+}
+
+// readEvents parses a Server-Sent Events stream and calls handle for each
+// event. Comments, used as heartbeats, are ignored.
+func readEvents(r io.Reader, handle func(name string, data string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), PUSH_MAX_EVENT_SIZE)
+
+	name := ""
+	data := make([]string, 0)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				handle(name, strings.Join(data, "\n"))
+			}
+			name = ""
+			data = data[:0]
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	return scanner.Err()
+}
+
+// handleEvent applies a pushed event. It waits for a scan in progress, so
+// the targets never change in the middle of one.
+func (s *Spy) handleEvent(name string, data string) {
+	event, err := domain.EventFromJson(data)
+
+	if err != nil {
+		log.Printf("[handleEvent] Discarding invalid '%s' event: %s", name, err)
+		return
+	}
+
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	switch {
+	case event.HasTargets():
+		s.applyPushedTargets(event)
+	case event.Type == domain.EVENT_ACTION && event.Action == domain.ACTION_REFRESH:
+		log.Printf("[handleEvent] Refresh requested by the server")
+		s.updateTargets()
+	case event.Type == domain.EVENT_ACTION && event.Action == domain.ACTION_KILL:
+		s.killNow(event.Target)
+	default:
+		log.Printf("[handleEvent] Ignoring unknown event '%s'", event.Type)
+	}
+}
+
+func (s *Spy) applyPushedTargets(event *domain.Event) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	targets := domain.NewTargetList()
+
+	if event.Targets != nil {
+		targets.Targets = event.Targets
+	}
+
+	targets.Groups = event.Groups
+	targets.Override = event.Override
+	targets.Allowlist = event.Allowlist
+	targets.WeekStart = event.WeekStart
+	targets.SetTimezone(event.Timezone)
+	targets.SetCalendar(event.Calendar)
+
+	if s.config.Debug {
+		log.Printf("[handleEvent] '%s' event with %d targets", event.Type, len(targets.Targets))
+	}
+
+	now := time.Now()
+	locked := s.targets.Mode(now) != domain.OVERRIDE_LOCKED && targets.Mode(now) == domain.OVERRIDE_LOCKED
+
+	s.setTargets(targets, targets.ToLog(), event.ETag)
+	s.online = true
+	s.reconcileTargets()
+
+	// A lock is enforced right away instead of on the next scan.
+	if locked {
+		log.Printf("[handleEvent] User is %s", targets.Override.Describe())
+		s.killNow("")
+	}
+}
+
+// killNow kills the processes of a target, or of every target when name is
+// empty, whatever their limits are.
+func (s *Spy) killNow(name string) {
+	processes, err := s.processes()
+	if err != nil {
+		log.Printf("[killNow] Error getting processes: %s", err)
+		return
+	}
+
+	for _, target := range s.targets.Targets {
+		if name != "" && target.Name != name {
+			continue
+		}
+
+		pids := make([]int, 0)
+		names := make([]string, 0)
+
+		for _, proc := range processes {
+			if _, ok := target.MatchProcess(proc); ok {
+				pids = append(pids, proc.Pid)
+				names = append(names, proc.Executable)
+			}
+		}
+
+		log.Printf("[killNow] [%s] Kill requested by the server, terminating %d processes: %v", target.Name, len(pids), pids)
+		s.kill(target.Name, strings.Join(names, " / "), pids)
+	}
+}
+
+// pushBackoff doubles the wait for each failed connection, from one second
+// up to PUSH_MAX_BACKOFF.
+func pushBackoff(retries int) time.Duration {
+	backoff := 1
+	for i := 0; i < retries && backoff < PUSH_MAX_BACKOFF; i++ {
+		backoff *= 2
+	}
+
+	return time.Duration(min(backoff, PUSH_MAX_BACKOFF)) * time.Second
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReadEvents testa a leitura de um stream Server-Sent Events
+func TestReadEvents(t *testing.T) {
+	stream := ": heartbeat\n\nevent: targets\ndata: {\"type\":\"targets\"}\n\nevent: action\ndata: linha1\ndata: linha2\n\n"
+
+	names := make([]string, 0)
+	data := make([]string, 0)
+
+	err := readEvents(strings.NewReader(stream), func(name string, value string) {
+		names = append(names, name)
+		data = append(data, value)
+	})
+
+	if err != nil {
+		t.Fatalf("readEvents() erro = %v", err)
+	}
+
+	if len(names) != 2 || names[0] != "targets" || names[1] != "action" {
+		t.Fatalf("Eventos = %v, esperado [targets action]", names)
+	}
+
+	if data[1] != "linha1\nlinha2" {
+		t.Errorf("Data = %q, esperado linhas unidas", data[1])
+	}
+}
+
+// TestSpy_streamEvents testa a aplicação de targets enviados pelo servidor
+func TestSpy_streamEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events/test" || r.Header.Get("Authorization") != "Bearer device-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`event: targets` + "\n" + `data: {"type":"targets","user":"test","targets":[{"name":"games","pattern":"steam","elapsed":120}],"etag":"\"v1\""}` + "\n\n"))
+	}))
+	defer server.Close()
+
+	cfg := &config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		User:      "test",
+		Token:     "device-token",
+	}
+
+	spy := NewSpy(cfg)
+	spy.ledger.AddElapsed("test", ledgerDay(time.Now()), "games", 30)
+
+	connected, _ := spy.streamEvents(context.Background())
+
+	if !connected {
+		t.Fatal("streamEvents() deveria conectar")
+	}
+
+	if len(spy.targets.Targets) != 1 || spy.targetsETag != `"v1"` {
+		t.Fatalf("Targets = %d, ETag = %s, esperado 1 target e ETag \"v1\"", len(spy.targets.Targets), spy.targetsETag)
+	}
+
+	// Pendente local somado ao total enviado pelo servidor
+	if spy.targets.Targets[0].Elapsed != 150 {
+		t.Errorf("Elapsed = %.2f, esperado 150.00", spy.targets.Targets[0].Elapsed)
+	}
+
+	spy.config.Token = "outro"
+
+	if connected, err := spy.streamEvents(context.Background()); connected || err == nil {
+		t.Error("streamEvents() deveria falhar sem autorização")
+	}
+}
+
+// TestSpy_handleEvent_Refresh testa o pedido de atualização imediata
+func TestSpy_handleEvent_Refresh(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"targets":[{"name":"games","pattern":"steam"}]}`))
+	}))
+	defer server.Close()
+
+	spy := NewSpy(&config.Client{Interval: 30, ServerURL: server.URL, User: "test"})
+	spy.handleEvent(domain.EVENT_ACTION, domain.NewAction("test", domain.ACTION_REFRESH, "").ToLog())
+
+	if requests != 1 || len(spy.targets.Targets) != 1 {
+		t.Errorf("Requisições = %d, targets = %d, esperado 1 e 1", requests, len(spy.targets.Targets))
+	}
+
+	// Evento inválido é descartado
+	spy.handleEvent(domain.EVENT_ACTION, "{invalid")
+
+	if requests != 1 {
+		t.Errorf("Requisições = %d, esperado 1 após evento inválido", requests)
+	}
+}
+
+// TestSpy_handleEvent_Override testa o modo do usuário recebido por push
+func TestSpy_handleEvent_Override(t *testing.T) {
+	spy := NewSpy(&config.Client{Interval: 30, User: "test"})
+
+	event := domain.NewEvent(domain.EVENT_TARGETS, "test")
+	event.Targets = []*domain.Target{{Name: "games", Pattern: "^procspy-test-nothing$"}}
+	event.Override = &domain.Override{Mode: domain.OVERRIDE_LOCKED, Until: time.Now().Add(time.Hour)}
+
+	spy.handleEvent(domain.EVENT_TARGETS, event.ToLog())
+
+	if mode := spy.targets.Mode(time.Now()); mode != domain.OVERRIDE_LOCKED {
+		t.Errorf("Mode() = %s, esperado %s", mode, domain.OVERRIDE_LOCKED)
+	}
+
+	// Sem override no evento o usuário volta ao normal
+	event.Override = nil
+	spy.handleEvent(domain.EVENT_TARGETS, event.ToLog())
+
+	if mode := spy.targets.Mode(time.Now()); mode != domain.OVERRIDE_NORMAL {
+		t.Errorf("Mode() = %s, esperado %s", mode, domain.OVERRIDE_NORMAL)
+	}
+}
+
+// TestPushBackoff testa o intervalo de reconexão
+func TestPushBackoff(t *testing.T) {
+	tests := []struct {
+		retries  int
+		expected time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{5, 32 * time.Second},
+		{6, PUSH_MAX_BACKOFF * time.Second},
+		{20, PUSH_MAX_BACKOFF * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := pushBackoff(tt.retries); got != tt.expected {
+			t.Errorf("pushBackoff(%d) = %s, esperado %s", tt.retries, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"log"
+	"net/http"
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newChildren builds a spy for each procspy user of a shared computer, with
+// the OS accounts mapped to it. Each child fetches its own targets, keeps its
+// own ledger and spool, posts with its own token and reads the idle time of
+// its own sessions.
+func newChildren(cfg *config.Client) map[string]*Spy {
+	if runtime.GOOS != "linux" {
+		log.Printf("[NewSpy] The owners of processes are only read on Linux, no process will be monitored on %s", runtime.GOOS)
+	}
+
+	ret := make(map[string]*Spy)
+
+	for account, user := range cfg.OSUsers {
+		child, found := ret[user]
+
+		if !found {
+			child = NewSpy(cfg.ForUser(user))
+			child.accounts = make(map[string]bool)
+			child.idle = newAccountIdleProvider(child.accounts)
+			ret[user] = child
+		}
+
+		child.accounts[account] = true
+	}
+
+	return ret
+}
+
+// owned returns the processes of the OS accounts mapped to the spy, by
+// login or uid.
+func (s *Spy) owned(processes []*domain.Process) []*domain.Process {
+	ret := make([]*domain.Process, 0)
+
+	for _, proc := range processes {
+		if s.accounts[proc.User] || (proc.UID != "" && s.accounts[proc.UID]) {
+			ret = append(ret, proc)
+		}
+	}
+
+	return ret
+}
+
+// processes returns the running processes the spy monitors: every process,
+// or only those of its OS accounts on a shared computer.
+func (s *Spy) processes() ([]*domain.Process, error) {
+	if s.accounts == nil {
+		return listProcesses(s.targets.NeedsDetails())
+	}
+
+	processes, err := listProcesses(true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.owned(processes), nil
+}
+
+// runShared lists the processes of a shared computer once and scans those
+// of each procspy user with its spy. Users without processes are not
+// logged in and are not scanned, targets included, but their spools still
+// drain what was left from their last session; processes of unmapped
+// accounts, such as the parents', are never monitored.
+func (s *Spy) runShared(last time.Time) error {
+	processes, err := listProcesses(true)
+
+	if err != nil {
+		log.Printf("[run] Error getting processes: %s", err)
+		return err
+	}
+
+	users := make([]string, 0, len(s.children))
+
+	for user := range s.children {
+		users = append(users, user)
+	}
+
+	sort.Strings(users)
+
+	for _, user := range users {
+		child := s.children[user]
+		owned := child.owned(processes)
+
+		if len(owned) == 0 {
+			if s.config.Debug {
+				log.Printf("[run] No processes of user '%s', skipping", user)
+			}
+
+			child.consumeBuffers()
+			continue
+		}
+
+		log.Printf("[run] Scanning %d processes of user '%s'", len(owned), user)
+
+		if err := child.scan(last, func() ([]*domain.Process, error) { return owned, nil }); err != nil {
+			log.Printf("[run] Error scanning processes of user '%s': %s", user, err)
+		}
+	}
+
+	return nil
+}
+
+// child returns the spy of the procspy user in the user query parameter of
+// a local API request on a shared computer, answering 404 when there is none.
+func (s *Spy) child(ctx *gin.Context) *Spy {
+	child, found := s.children[ctx.Query("user")]
+
+	if !found {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "unknown user, expected one of os_users",
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return nil
+	}
+
+	return child
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/user"
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestNewSpy_Shared testa a criação de um spy por usuário do procspy
+func TestNewSpy_Shared(t *testing.T) {
+	cfg := &config.Client{
+		Interval:   30,
+		ServerURL:  "http://localhost:8080",
+		Token:      "shared-token",
+		OSUsers:    map[string]string{"alice": "crianca1", "1001": "crianca1", "bob": "crianca2"},
+		UserTokens: map[string]string{"crianca1": "token1"},
+	}
+
+	spy := NewSpy(cfg)
+
+	if len(spy.children) != 2 {
+		t.Fatalf("Filhos = %d, esperado 2", len(spy.children))
+	}
+
+	child := spy.children["crianca1"]
+	if child.config.User != "crianca1" || child.config.Token != "token1" {
+		t.Errorf("Config do filho = %s/%s, esperado crianca1/token1", child.config.User, child.config.Token)
+	}
+
+	if !child.accounts["alice"] || !child.accounts["1001"] || child.accounts["bob"] {
+		t.Errorf("Contas do filho = %v, esperado alice e 1001", child.accounts)
+	}
+
+	if len(child.children) != 0 {
+		t.Error("Filho não deveria ter filhos")
+	}
+
+	if spy.ledger != nil || spy.spool != nil || child.ledger == nil || child.spool == nil {
+		t.Error("Ledger e spool deveriam ficar só com os filhos")
+	}
+
+	spy.Stop()
+}
+
+// TestSpy_owned testa a atribuição dos processos pelo login ou uid do dono
+func TestSpy_owned(t *testing.T) {
+	spy := &Spy{accounts: map[string]bool{"alice": true, "1001": true}}
+
+	processes := []*domain.Process{
+		{Pid: 1, Executable: "steam", User: "alice", UID: "1000"},
+		{Pid: 2, Executable: "roblox", User: "bob", UID: "1001"},
+		{Pid: 3, Executable: "firefox", User: "mae", UID: "1002"},
+		{Pid: 4, Executable: "chrome"},
+	}
+
+	owned := spy.owned(processes)
+
+	if len(owned) != 2 || owned[0].Pid != 1 || owned[1].Pid != 2 {
+		t.Errorf("owned() = %v, esperado os processos 1 e 2", owned)
+	}
+}
+
+// TestSpy_run_Shared testa o scan só dos usuários com processos, ignorando contas sem mapa
+func TestSpy_run_Shared(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Dono dos processos só é lido no Linux")
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("Usuário atual indisponível: %v", err)
+	}
+
+	pattern := ownPattern(t)
+
+	var mu sync.Mutex
+	fetched := make(map[string]bool)
+	posted := make(map[string]bool)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		parts := strings.Split(r.URL.Path, "/")
+		owner := parts[len(parts)-1]
+
+		if r.Method == http.MethodGet {
+			fetched[owner] = true
+			fmt.Fprintf(w, `{"targets":[{"name":"tests","pattern":%q,"limit":3600}]}`, pattern)
+			return
+		}
+
+		posted[owner] = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	spy := NewSpy(&config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		OSUsers:   map[string]string{current.Uid: "crianca1", "procspy-nobody": "crianca2"},
+	})
+
+	if err := spy.run(time.Now().Add(-30 * time.Second)); err != nil {
+		t.Fatalf("run() erro = %v", err)
+	}
+
+	// Os matches são enviados em segundo plano
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := posted["crianca1"]
+		mu.Unlock()
+
+		if done {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !fetched["crianca1"] || !posted["crianca1"] {
+		t.Errorf("Targets e matches de crianca1 deveriam ir ao servidor: fetched %v, posted %v", fetched, posted)
+	}
+
+	if fetched["crianca2"] || posted["crianca2"] {
+		t.Error("crianca2 não tem processos e não deveria ser consultada")
+	}
+
+	if elapsed := spy.children["crianca1"].targets.Targets[0].Elapsed; elapsed <= 0 {
+		t.Errorf("Tempo de crianca1 = %.2f, esperado maior que zero", elapsed)
+	}
+}
+
+// TestSpy_run_Shared_Drain testa o envio do spool de um usuário sem processos
+func TestSpy_run_Shared_Drain(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Dono dos processos só é lido no Linux")
+	}
+
+	var mu sync.Mutex
+	posted := make(map[string]bool)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/batch/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		parts := strings.Split(r.URL.Path, "/")
+		posted[parts[len(parts)-1]] = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	spy := NewSpy(&config.Client{
+		Interval:  30,
+		ServerURL: server.URL,
+		OSUsers:   map[string]string{"procspy-nobody": "crianca2"},
+	})
+
+	child := spy.children["crianca2"]
+	child.enqueueMatch(domain.NewMatch("crianca2", "games", "steam", "steam", 30))
+
+	if err := spy.run(time.Now().Add(-30 * time.Second)); err != nil {
+		t.Fatalf("run() erro = %v", err)
+	}
+
+	waitDrain(child)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !posted["crianca2"] {
+		t.Error("O spool de crianca2 deveria ser enviado mesmo sem processos")
+	}
+
+	if depth, _ := child.spool.Depth(SPOOL_KIND_MATCH); depth != 0 {
+		t.Errorf("Spool de crianca2 = %d, esperado vazio", depth)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"path/filepath"
 )
 
 type Client struct {
@@ -14,6 +15,18 @@ type Client struct {
 	Debug     bool   `json:"debug,omitempty"`
 	APIPort   int    `json:"api_port,omitempty"`
 	APIHost   string `json:"api_host,omitempty"`
+	DBPath    string `json:"db_path,omitempty"`
+	SpoolSize int    `json:"spool_size,omitempty"`
+	Token     string `json:"token,omitempty"`
+	// Allowlist holds process name patterns that no target counts or
+	// kills, added to the allowlist served with the targets.
+	Allowlist   []string `json:"allowlist,omitempty"`
+	LogExcluded bool     `json:"log_excluded,omitempty"`
+	Accounting  string   `json:"accounting,omitempty"`
+	// OSUsers maps the OS logins or uids of a shared computer to procspy
+	// users, each posting with its token from UserTokens.
+	OSUsers    map[string]string `json:"os_users,omitempty"`
+	UserTokens map[string]string `json:"user_tokens,omitempty"`
 }
 
 func NewConfig() *Client {
@@ -42,6 +55,10 @@ func (c *Client) SetDefaults() {
 	if c.APIHost == "" {
 		c.APIHost = "localhost"
 	}
+
+	if c.DBPath == "" {
+		c.DBPath = c.LogPath
+	}
 }
 
 func (c *Client) ToJson() string {
@@ -53,6 +70,44 @@ func (c *Client) ToJson() string {
 	return string(ret)
 }
 
+// Redacted returns a copy safe to be logged, without secrets.
+func (c *Client) Redacted() *Client {
+	ret := *c
+
+	if ret.Token != "" {
+		ret.Token = REDACTED
+	}
+
+	if len(ret.UserTokens) > 0 {
+		ret.UserTokens = make(map[string]string, len(c.UserTokens))
+
+		for user := range c.UserTokens {
+			ret.UserTokens[user] = REDACTED
+		}
+	}
+
+	return &ret
+}
+
+// ForUser returns the config of one procspy user of a shared computer, with
+// its token and, when stored on disk, its own local database.
+func (c *Client) ForUser(user string) *Client {
+	ret := *c
+	ret.User = user
+	ret.OSUsers = nil
+	ret.UserTokens = nil
+
+	if token, found := c.UserTokens[user]; found {
+		ret.Token = token
+	}
+
+	if ret.DBPath != "" {
+		ret.DBPath = filepath.Join(ret.DBPath, user)
+	}
+
+	return &ret
+}
+
 func ClientConfigFromJson(jsonString string) (*Client, error) {
 	ret := &Client{}
 	err := json.Unmarshal([]byte(jsonString), ret)
@@ -63,7 +118,7 @@ func ClientConfigFromJson(jsonString string) (*Client, error) {
 
 	ret.SetDefaults()
 
-	log.Printf("[config.ClientConfigFromJson] Client configuration loaded successfully: %s", ret.ToJson())
+	log.Printf("[config.ClientConfigFromJson] Client configuration loaded successfully: %s", ret.Redacted().ToJson())
 
 	return ret, nil
 }
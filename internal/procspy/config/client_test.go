@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -543,3 +544,53 @@ func TestClient_SetDefaults_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestClient_Redacted testa que o token não aparece na config logada
+func TestClient_Redacted(t *testing.T) {
+	config := &Client{User: "user1", Token: "secret-token", UserTokens: map[string]string{"user2": "child-token"}}
+
+	redacted := config.Redacted()
+
+	if strings.Contains(redacted.ToJson(), "secret-token") || strings.Contains(redacted.ToJson(), "child-token") {
+		t.Error("Redacted() não deveria conter os tokens")
+	}
+
+	if config.Token != "secret-token" || config.UserTokens["user2"] != "child-token" {
+		t.Error("Redacted() não deveria alterar a config original")
+	}
+}
+
+// TestClient_ForUser testa a config de cada usuário de um computador compartilhado
+func TestClient_ForUser(t *testing.T) {
+	config := &Client{
+		User:       "",
+		Token:      "shared-token",
+		DBPath:     "data",
+		OSUsers:    map[string]string{"alice": "crianca1", "1001": "crianca2"},
+		UserTokens: map[string]string{"crianca1": "token1"},
+	}
+
+	tests := []struct {
+		name   string
+		user   string
+		token  string
+		dbPath string
+	}{
+		{"Token próprio", "crianca1", "token1", filepath.Join("data", "crianca1")},
+		{"Token compartilhado", "crianca2", "shared-token", filepath.Join("data", "crianca2")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child := config.ForUser(tt.user)
+
+			if child.User != tt.user || child.Token != tt.token || child.DBPath != tt.dbPath {
+				t.Errorf("ForUser() = %s/%s/%s, esperado %s/%s/%s", child.User, child.Token, child.DBPath, tt.user, tt.token, tt.dbPath)
+			}
+
+			if child.OSUsers != nil || child.UserTokens != nil {
+				t.Error("ForUser() não deveria manter o mapa de usuários")
+			}
+		})
+	}
+}
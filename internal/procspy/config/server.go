@@ -6,13 +6,20 @@ import (
 	"os"
 )
 
+const REDACTED = "***"
+
 type Server struct {
-	DBPath     string            `json:"db_path"`
-	LogPath    string            `json:"log_path"`
-	APIPort    int               `json:"api_port"`
-	APIHost    string            `json:"api_host"`
-	UserTarges map[string]string `json:"user_targets"`
-	Debug      bool              `json:"debug"`
+	DBPath        string            `json:"db_path"`
+	LogPath       string            `json:"log_path"`
+	APIPort       int               `json:"api_port"`
+	APIHost       string            `json:"api_host"`
+	UserTarges    map[string]string `json:"user_targets"`
+	Debug         bool              `json:"debug"`
+	AdminToken    string            `json:"admin_token,omitempty"`
+	TargetsTTL    int               `json:"targets_ttl,omitempty"`
+	WeekStart     int               `json:"week_start,omitempty"`
+	UserTimezones map[string]string `json:"user_timezones,omitempty"`
+	Allowlist     []string          `json:"allowlist,omitempty"`
 }
 
 func NewServer() *Server {
@@ -28,6 +35,17 @@ func (s *Server) ToJson() string {
 	return string(ret)
 }
 
+// Redacted returns a copy safe to be logged, without secrets.
+func (s *Server) Redacted() *Server {
+	ret := *s
+
+	if ret.AdminToken != "" {
+		ret.AdminToken = REDACTED
+	}
+
+	return &ret
+}
+
 func ServerConfigFromJson(jsonString string) (*Server, error) {
 	ret := &Server{}
 	err := json.Unmarshal([]byte(jsonString), ret)
@@ -36,7 +54,7 @@ func ServerConfigFromJson(jsonString string) (*Server, error) {
 		return nil, err
 	}
 
-	log.Printf("[config.ServerConfigFromJson] Server configuration loaded successfully: %s", ret.ToJson())
+	log.Printf("[config.ServerConfigFromJson] Server configuration loaded successfully: %s", ret.Redacted().ToJson())
 
 	return ret, nil
 }
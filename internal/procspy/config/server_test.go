@@ -371,3 +371,16 @@ func TestServer_ToJson_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestServer_Redacted testa que o token do admin não aparece na config logada
+func TestServer_Redacted(t *testing.T) {
+	config := &Server{AdminToken: "secret-token"}
+
+	if strings.Contains(config.Redacted().ToJson(), "secret-token") {
+		t.Error("Redacted() não deveria conter o token do admin")
+	}
+
+	if config.AdminToken != "secret-token" {
+		t.Error("Redacted() não deveria alterar a config original")
+	}
+}
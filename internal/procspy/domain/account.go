@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+)
+
+const ROLE_ADMIN = "admin"
+const ROLE_PARENT = "parent"
+const ROLE_VIEWER = "viewer"
+
+// Account is a person using the admin API. Admins see and manage every
+// user; parents manage only the children listed in Children; viewers can
+// only read the reports of their children.
+type Account struct {
+	ID        int64    `json:"id"`
+	Username  string   `json:"username"`
+	Role      string   `json:"role"`
+	Children  []string `json:"children"`
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+func NewAccount(username string, role string, children []string) *Account {
+	if children == nil {
+		children = []string{}
+	}
+
+	return &Account{
+		Username: username,
+		Role:     role,
+		Children: children,
+	}
+}
+
+func ValidRole(role string) bool {
+	return role == ROLE_ADMIN || role == ROLE_PARENT || role == ROLE_VIEWER
+}
+
+func (a *Account) IsAdmin() bool {
+	return a.Role == ROLE_ADMIN
+}
+
+// CanRead reports whether the account may see the data of a user.
+func (a *Account) CanRead(user string) bool {
+	return a.IsAdmin() || slices.Contains(a.Children, user)
+}
+
+// CanManage reports whether the account may change the setup of a user.
+func (a *Account) CanManage(user string) bool {
+	return a.IsAdmin() || (a.Role == ROLE_PARENT && slices.Contains(a.Children, user))
+}
+
+func (a *Account) ToLog() string {
+	ret, err := json.Marshal(a)
+	if err != nil {
+		log.Printf("[domain.Account.ToLog] Failed to marshal account to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
+
+func (a *Account) ToJson() string {
+	ret, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		log.Printf("[domain.Account.ToJson] Failed to marshal account to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
+
+func AccountFromJson(jsonString string) (*Account, error) {
+	ret := &Account{}
+	err := json.Unmarshal([]byte(jsonString), ret)
+	if err != nil {
+		log.Printf("[domain.AccountFromJson] Failed to unmarshal account from JSON: %v", err)
+		return nil, err
+	}
+	return ret, nil
+}
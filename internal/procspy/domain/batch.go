@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"encoding/json"
+	"log"
+)
+
+const BATCH_STATUS_INSERTED = "inserted"
+const BATCH_STATUS_DUPLICATE = "duplicate"
+const BATCH_STATUS_ERROR = "error"
+
+type Batch struct {
+	Matches  []*Match   `json:"matches"`
+	Commands []*Command `json:"commands"`
+}
+
+// BatchItemResult is the outcome of one item of a batch. Results are
+// returned in the same order as the items were sent.
+type BatchItemResult struct {
+	EventID string `json:"event_id,omitempty"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+type BatchResult struct {
+	Matches  []*BatchItemResult `json:"matches"`
+	Commands []*BatchItemResult `json:"commands"`
+}
+
+func NewBatch() *Batch {
+	return &Batch{
+		Matches:  []*Match{},
+		Commands: []*Command{},
+	}
+}
+
+func NewBatchResult() *BatchResult {
+	return &BatchResult{
+		Matches:  []*BatchItemResult{},
+		Commands: []*BatchItemResult{},
+	}
+}
+
+// Accepted reports whether the item is stored on the server, either now or
+// by a previous delivery.
+func (r *BatchItemResult) Accepted() bool {
+	return r.Status == BATCH_STATUS_INSERTED || r.Status == BATCH_STATUS_DUPLICATE
+}
+
+func (b *Batch) ToLog() string {
+	ret, err := json.Marshal(b)
+	if err != nil {
+		log.Printf("[domain.Batch.ToLog] Failed to marshal batch to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
+
+func BatchFromJson(jsonString string) (*Batch, error) {
+	ret := NewBatch()
+	err := json.Unmarshal([]byte(jsonString), ret)
+	if err != nil {
+		log.Printf("[domain.BatchFromJson] Failed to unmarshal batch from JSON: %v", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+func BatchResultFromJson(jsonString string) (*BatchResult, error) {
+	ret := NewBatchResult()
+	err := json.Unmarshal([]byte(jsonString), ret)
+	if err != nil {
+		log.Printf("[domain.BatchResultFromJson] Failed to unmarshal batch result from JSON: %v", err)
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,290 @@
+package domain
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PROFILE_WEEKEND and PROFILE_WEEKDAY are calendar profiles that follow the
+// rules of saturday and of monday. Any weekday name is a profile as well.
+const PROFILE_WEEKEND = "weekend"
+const PROFILE_WEEKDAY = "weekday"
+
+var profiles = map[string]time.Weekday{
+	PROFILE_WEEKEND: time.Saturday,
+	PROFILE_WEEKDAY: time.Monday,
+	"sunday":        time.Sunday,
+	"monday":        time.Monday,
+	"tuesday":       time.Tuesday,
+	"wednesday":     time.Wednesday,
+	"thursday":      time.Thursday,
+	"friday":        time.Friday,
+	"saturday":      time.Saturday,
+}
+
+// CalendarEntry is a named range of dates, such as school holidays, whose
+// days follow the rules of another weekday: its limits, schedule and
+// rollover days. From and To are inclusive dates (YYYY-MM-DD).
+type CalendarEntry struct {
+	Name    string `json:"name"`
+	From    string `json:"from"`
+	To      string `json:"to,omitempty"`
+	Profile string `json:"profile"`
+}
+
+// Validate checks the dates and the profile of an entry. An entry without
+// To lasts a single day.
+func (e *CalendarEntry) Validate() error {
+	if e.Name == "" {
+		return errors.New("calendar entry name is required")
+	}
+
+	from, err := time.Parse(time.DateOnly, e.From)
+
+	if err != nil {
+		return fmt.Errorf("invalid start '%s' of calendar entry '%s', expected YYYY-MM-DD", e.From, e.Name)
+	}
+
+	if e.To != "" {
+		to, err := time.Parse(time.DateOnly, e.To)
+
+		if err != nil {
+			return fmt.Errorf("invalid end '%s' of calendar entry '%s', expected YYYY-MM-DD", e.To, e.Name)
+		}
+
+		if to.Before(from) {
+			return fmt.Errorf("calendar entry '%s' ends before it starts", e.Name)
+		}
+	}
+
+	if _, found := profiles[e.Profile]; !found {
+		return fmt.Errorf("invalid profile '%s' of calendar entry '%s', expected %s, %s or a weekday name", e.Profile, e.Name, PROFILE_WEEKEND, PROFILE_WEEKDAY)
+	}
+
+	return nil
+}
+
+func (e *CalendarEntry) includes(date string) bool {
+	to := e.To
+	if to == "" {
+		to = e.From
+	}
+
+	return date >= e.From && date <= to
+}
+
+// Calendar holds the entries of a user. When entries overlap, the first one
+// wins.
+type Calendar []*CalendarEntry
+
+// Validate checks every entry of the calendar.
+func (c Calendar) Validate() error {
+	for _, entry := range c {
+		if err := entry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Weekday returns the weekday whose rules apply on a date: the profile of
+// the entry that includes it, or its own weekday.
+func (c Calendar) Weekday(date time.Time) time.Weekday {
+	day := date.Format(time.DateOnly)
+
+	for _, entry := range c {
+		if entry.includes(day) {
+			return profiles[entry.Profile]
+		}
+	}
+
+	return date.Weekday()
+}
+
+// Entry returns the entry that includes a date, or nil.
+func (c Calendar) Entry(date time.Time) *CalendarEntry {
+	day := date.Format(time.DateOnly)
+
+	for _, entry := range c {
+		if entry.includes(day) {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (c Calendar) String() string {
+	ret := make([]string, 0, len(c))
+
+	for _, entry := range c {
+		ret = append(ret, fmt.Sprintf("%s %s %s %s", entry.Name, entry.From, entry.To, entry.Profile))
+	}
+
+	return strings.Join(ret, ", ")
+}
+
+// SetCalendar makes the targets and groups of the list follow a calendar.
+func (t *TargetList) SetCalendar(calendar Calendar) {
+	t.Calendar = calendar
+
+	for _, target := range t.Targets {
+		target.calendar = calendar
+		target.updateRemaining()
+	}
+
+	for _, group := range t.Groups {
+		group.calendar = calendar
+		group.SetElapsed(group.Elapsed)
+	}
+}
+
+// ParseICS reads the events of an iCalendar file as calendar entries with a
+// profile. Only the dates are kept, in the zone of the user: DTSTART and
+// DTEND are converted from UTC or from their TZID first, and a DTEND at
+// midnight, which is exclusive, ends on the day before.
+func ParseICS(r io.Reader, profile string, loc *time.Location) (Calendar, error) {
+	lines, err := unfoldICS(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(Calendar, 0)
+	var entry *CalendarEntry
+	exclusive := false
+
+	for _, line := range lines {
+		name, value, found := strings.Cut(line, ":")
+
+		if !found {
+			continue
+		}
+
+		key, params, _ := strings.Cut(name, ";")
+
+		switch strings.ToUpper(key) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				entry = &CalendarEntry{Profile: profile}
+				exclusive = false
+			}
+		case "SUMMARY":
+			if entry != nil {
+				entry.Name = strings.TrimSpace(strings.ReplaceAll(value, `\,`, ","))
+			}
+		case "DTSTART":
+			if entry != nil {
+				var start time.Time
+				start, err = icsTime(params, value, loc)
+				entry.From = start.Format(time.DateOnly)
+			}
+		case "DTEND":
+			if entry != nil {
+				var end time.Time
+				end, err = icsTime(params, value, loc)
+				entry.To = end.Format(time.DateOnly)
+				exclusive = end.Hour() == 0 && end.Minute() == 0 && end.Second() == 0
+			}
+		case "END":
+			if entry == nil || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+
+			if exclusive && entry.To > entry.From {
+				end, _ := time.Parse(time.DateOnly, entry.To)
+				entry.To = end.AddDate(0, 0, -1).Format(time.DateOnly)
+			}
+
+			if entry.Name == "" {
+				entry.Name = entry.From
+			}
+
+			if err := entry.Validate(); err != nil {
+				return nil, err
+			}
+
+			ret = append(ret, entry)
+			entry = nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].From < ret[j].From })
+
+	return ret, nil
+}
+
+// unfoldICS reads the content lines of an iCalendar file, joining the lines
+// folded with a leading space or tab.
+func unfoldICS(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	lines := make([]string, 0)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// icsTime reads an iCalendar DATE or DATE-TIME value in the zone of the
+// user. A DATE-TIME in UTC, with the Z suffix, or with a TZID parameter is
+// converted from its zone. DATEs and floating DATE-TIMEs are already in the
+// zone of the user, and so are those with a TZID that cannot be loaded, such
+// as the Windows names some exports use.
+func icsTime(params string, value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	zone := loc
+	layout := "20060102T150405"
+
+	if len(value) == 8 {
+		layout = "20060102"
+	} else if strings.HasSuffix(value, "Z") {
+		zone = time.UTC
+		value = strings.TrimSuffix(value, "Z")
+	} else if tzid := icsParam(params, "TZID"); tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			zone = tz
+		} else {
+			log.Printf("[domain.ParseICS] Unknown TZID '%s', using the zone of the user", tzid)
+		}
+	}
+
+	moment, err := time.ParseInLocation(layout, value, zone)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid iCalendar date '%s'", value)
+	}
+
+	return moment.In(loc), nil
+}
+
+// icsParam returns a parameter of a content line, such as the TZID of
+// DTSTART;TZID=America/Sao_Paulo, without quotes.
+func icsParam(params string, name string) string {
+	for _, param := range strings.Split(params, ";") {
+		if key, value, found := strings.Cut(param, "="); found && strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`)
+		}
+	}
+
+	return ""
+}
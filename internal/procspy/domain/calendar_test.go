@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCalendarEntry_Validate testa as datas e os perfis aceitos
+func TestCalendarEntry_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   *CalendarEntry
+		wantErr bool
+	}{
+		{"Férias", &CalendarEntry{Name: "Férias", From: "2025-07-01", To: "2025-07-31", Profile: PROFILE_WEEKEND}, false},
+		{"Feriado de um dia", &CalendarEntry{Name: "Natal", From: "2025-12-25", Profile: "sunday"}, false},
+		{"Sem nome", &CalendarEntry{From: "2025-12-25", Profile: PROFILE_WEEKEND}, true},
+		{"Data inválida", &CalendarEntry{Name: "Natal", From: "25/12/2025", Profile: PROFILE_WEEKEND}, true},
+		{"Fim antes do início", &CalendarEntry{Name: "Férias", From: "2025-07-31", To: "2025-07-01", Profile: PROFILE_WEEKEND}, true},
+		{"Perfil inválido", &CalendarEntry{Name: "Natal", From: "2025-12-25", Profile: "holiday"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.entry.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, esperado erro %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCalendar_Weekday testa o dia da semana cujas regras valem em cada data
+func TestCalendar_Weekday(t *testing.T) {
+	calendar := Calendar{
+		{Name: "Carnaval", From: "2025-03-03", To: "2025-03-04", Profile: PROFILE_WEEKEND},
+		{Name: "Férias", From: "2025-03-01", To: "2025-03-31", Profile: "friday"},
+	}
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		expected time.Weekday
+	}{
+		{"Dia comum", time.Date(2025, 2, 26, 10, 0, 0, 0, time.Local), time.Wednesday},
+		{"Primeiro dia do feriado", time.Date(2025, 3, 3, 10, 0, 0, 0, time.Local), time.Saturday},
+		{"Último dia do feriado", time.Date(2025, 3, 4, 23, 59, 0, 0, time.Local), time.Saturday},
+		{"Férias após o feriado", time.Date(2025, 3, 5, 10, 0, 0, 0, time.Local), time.Friday},
+		{"Após as férias", time.Date(2025, 4, 1, 10, 0, 0, 0, time.Local), time.Tuesday},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calendar.Weekday(tt.date); got != tt.expected {
+				t.Errorf("Weekday() = %s, esperado %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestTargetList_SetCalendar testa o limite e o horário de um feriado
+func TestTargetList_SetCalendar(t *testing.T) {
+	now := time.Now()
+
+	if now.Weekday() == time.Saturday {
+		t.Skip("o teste depende de hoje não ser sábado")
+	}
+
+	saturday := Duration(4 * 3600)
+
+	list := NewTargetList()
+	list.Targets = append(list.Targets, &Target{
+		Name:          "games",
+		Limit:         3600,
+		WeekdayLimits: map[int]Duration{int(time.Saturday): saturday},
+		Schedule:      Schedule{int(time.Saturday): {"00:00-24:00"}, int(now.Weekday()): {}},
+	})
+
+	list.SetCalendar(Calendar{{Name: "Feriado", From: now.Format(time.DateOnly), Profile: PROFILE_WEEKEND}})
+	target := list.Targets[0]
+
+	if target.getLimit() != float64(saturday) {
+		t.Errorf("getLimit() = %f, esperado o limite de sábado %f", target.getLimit(), float64(saturday))
+	}
+
+	if !target.Allowed(now) {
+		t.Error("Allowed() deveria seguir o horário de sábado no feriado")
+	}
+
+	list.SetCalendar(nil)
+	target.Remaining = 0
+
+	if target.getLimit() != 3600 || target.Allowed(now) {
+		t.Errorf("Sem calendário deveria valer o dia de hoje, limite %f", target.getLimit())
+	}
+}
+
+// TestParseICS testa a leitura de eventos de um arquivo iCalendar
+func TestParseICS(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Férias de\r\n  julho\r\n" +
+		"DTSTART;VALUE=DATE:20250701\r\n" +
+		"DTEND;VALUE=DATE:20250801\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Tiradentes\r\n" +
+		"DTSTART:20250421T080000\r\n" +
+		"DTEND:20250421T180000\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Natal\r\n" +
+		"DTSTART:20251225T030000Z\r\n" +
+		"DTEND:20251226T030000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	loc := time.FixedZone("BRT", -3*60*60)
+
+	calendar, err := ParseICS(strings.NewReader(ics), PROFILE_WEEKEND, loc)
+	if err != nil {
+		t.Fatalf("ParseICS() erro = %v", err)
+	}
+
+	if len(calendar) != 3 {
+		t.Fatalf("Esperado 3 entradas, obteve %d", len(calendar))
+	}
+
+	holiday := calendar[0]
+	if holiday.Name != "Tiradentes" || holiday.From != "2025-04-21" || holiday.To != "2025-04-21" || holiday.Profile != PROFILE_WEEKEND {
+		t.Errorf("Feriado incorreto: %+v", holiday)
+	}
+
+	// DTEND de data inteira é exclusivo
+	vacation := calendar[1]
+	if vacation.Name != "Férias de julho" || vacation.From != "2025-07-01" || vacation.To != "2025-07-31" {
+		t.Errorf("Férias incorretas: %+v", vacation)
+	}
+
+	// Em UTC: meia-noite no fuso do usuário, fim exclusivo
+	christmas := calendar[2]
+	if christmas.Name != "Natal" || christmas.From != "2025-12-25" || christmas.To != "2025-12-25" {
+		t.Errorf("Natal incorreto: %+v", christmas)
+	}
+
+	if _, err := ParseICS(strings.NewReader(ics), "holiday", loc); err == nil {
+		t.Error("ParseICS() deveria rejeitar um perfil inválido")
+	}
+}
+
+// TestParseICS_TZID testa a conversão de eventos com TZID para o fuso do usuário
+func TestParseICS_TZID(t *testing.T) {
+	if _, err := time.LoadLocation("Asia/Tokyo"); err != nil {
+		t.Skipf("Fuso indisponível: %v", err)
+	}
+
+	ics := "BEGIN:VEVENT\r\n" +
+		"SUMMARY:Viagem\r\n" +
+		"DTSTART;TZID=\"Asia/Tokyo\":20250802T080000\r\n" +
+		"DTEND;TZID=Asia/Tokyo:20250803T180000\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Fuso desconhecido\r\n" +
+		"DTSTART;TZID=E. South America Standard Time:20250901T080000\r\n" +
+		"DTEND;TZID=E. South America Standard Time:20250901T180000\r\n" +
+		"END:VEVENT\r\n"
+
+	calendar, err := ParseICS(strings.NewReader(ics), PROFILE_WEEKEND, time.FixedZone("BRT", -3*60*60))
+	if err != nil || len(calendar) != 2 {
+		t.Fatalf("ParseICS() = %v, %v, esperado 2 entradas", calendar, err)
+	}
+
+	// 08:00 em Tóquio é 20:00 do dia anterior em Brasília
+	if trip := calendar[0]; trip.From != "2025-08-01" || trip.To != "2025-08-03" {
+		t.Errorf("Viagem incorreta: %+v", trip)
+	}
+
+	if unknown := calendar[1]; unknown.From != "2025-09-01" || unknown.To != "2025-09-01" {
+		t.Errorf("Evento com TZID desconhecido deveria seguir o fuso do usuário: %+v", unknown)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"log"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Command struct {
+	EventID     string    `json:"event_id,omitempty"`
 	User        string    `json:"user"`
 	Name        string    `json:"name"`
 	CommandLine string    `json:"command_line"`
@@ -18,6 +21,7 @@ type Command struct {
 
 func NewCommand(user string, name string, commandLine string, commandReturn string) *Command {
 	return &Command{
+		EventID:     uuid.NewString(),
 		User:        user,
 		Name:        name,
 		CommandLine: commandLine,
@@ -60,6 +60,11 @@ func TestNewCommand(t *testing.T) {
 	if diff > time.Second {
 		t.Errorf("CreatedAt está muito distante do tempo atual: %v", diff)
 	}
+
+	// Valida que o command recebe um event_id
+	if result.EventID == "" {
+		t.Error("EventID não foi inicializado")
+	}
 }
 
 // TestCommand_ToJson testa a serialização de Command para JSON
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// Device is a client installation allowed to talk to the server on behalf
+// of a user. Token is only filled when the device is issued; the server
+// keeps a hash of it.
+type Device struct {
+	ID         int64  `json:"id"`
+	User       string `json:"user"`
+	Name       string `json:"name"`
+	Token      string `json:"token,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	LastSeenAt string `json:"last_seen_at,omitempty"`
+	RevokedAt  string `json:"revoked_at,omitempty"`
+}
+
+func NewDevice(user string, name string) *Device {
+	return &Device{
+		User: user,
+		Name: name,
+	}
+}
+
+func (d *Device) IsRevoked() bool {
+	return d.RevokedAt != ""
+}
+
+func (d *Device) ToLog() string {
+	ret, err := json.Marshal(d)
+	if err != nil {
+		log.Printf("[domain.Device.ToLog] Failed to marshal device to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
+
+func (d *Device) ToJson() string {
+	ret, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		log.Printf("[domain.Device.ToJson] Failed to marshal device to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
+
+func DeviceFromJson(jsonString string) (*Device, error) {
+	ret := &Device{}
+	err := json.Unmarshal([]byte(jsonString), ret)
+	if err != nil {
+		log.Printf("[domain.DeviceFromJson] Failed to unmarshal device from JSON: %v", err)
+		return nil, err
+	}
+	return ret, nil
+}
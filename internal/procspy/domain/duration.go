@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is an amount of seconds. In JSON it is read from a number of
+// seconds or from a duration string such as "45m" or "1h30m", and always
+// written as a number of seconds.
+type Duration float64
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		*d = 0
+	case float64:
+		*d = Duration(v)
+	case string:
+		parsed, err := ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+
+	return nil
+}
+
+// ParseDuration reads a duration string ("45m", "1h30m") or a number of
+// seconds ("2700").
+func ParseDuration(value string) (Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return Duration(seconds), nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s', expected seconds or a value like 45m or 1h30m", value)
+	}
+
+	return Duration(parsed.Seconds()), nil
+}
+
+func (d Duration) Seconds() float64 {
+	return float64(d)
+}
+
+// warningThreshold computes when to warn for a limit from a warning
+// setting: a percentage of the limit ("90%") or how long before the limit
+// ("5m", "5m before", "300").
+func warningThreshold(value string, limit float64) (float64, error) {
+	value = strings.TrimSpace(value)
+
+	if number, found := strings.CutSuffix(value, "%"); found {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("invalid warning percentage '%s', expected 0%% to 100%%", value)
+		}
+
+		return limit * percent / 100, nil
+	}
+
+	before, err := ParseDuration(strings.TrimSuffix(value, "before"))
+
+	if err != nil || before < 0 {
+		return 0, fmt.Errorf("invalid warning '%s', expected a percentage like 90%% or a duration like 5m", value)
+	}
+
+	return max(limit-before.Seconds(), 0), nil
+}
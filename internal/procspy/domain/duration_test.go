@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestDuration_UnmarshalJSON testa a leitura de durações em segundos ou texto
+func TestDuration_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected Duration
+		wantErr  bool
+	}{
+		{"Segundos", `2700`, 2700, false},
+		{"Minutos", `"45m"`, 2700, false},
+		{"Horas e minutos", `"1h30m"`, 5400, false},
+		{"Segundos em texto", `"300"`, 300, false},
+		{"Nulo", `null`, 0, false},
+		{"Texto inválido", `"muito"`, 0, true},
+		{"Tipo inválido", `true`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value Duration
+			err := json.Unmarshal([]byte(tt.json), &value)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() erro = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if value != tt.expected {
+				t.Errorf("UnmarshalJSON() = %.0f, esperado %.0f", value, tt.expected)
+			}
+		})
+	}
+}
+
+// TestWarningThreshold testa o cálculo do momento do aviso
+func TestWarningThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		warning  string
+		expected float64
+		wantErr  bool
+	}{
+		{"Percentual", "90%", 3240, false},
+		{"Minutos antes", "5m", 3300, false},
+		{"Minutos antes por extenso", "5m before", 3300, false},
+		{"Segundos antes", "300", 3300, false},
+		{"Antes maior que o limite", "2h", 0, false},
+		{"Percentual inválido", "150%", 0, true},
+		{"Texto inválido", "soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := warningThreshold(tt.warning, 3600)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("warningThreshold() erro = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if result != tt.expected {
+				t.Errorf("warningThreshold() = %.0f, esperado %.0f", result, tt.expected)
+			}
+		})
+	}
+}
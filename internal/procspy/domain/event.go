@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Events pushed by the server to the clients of a user.
+const EVENT_TARGETS = "targets"
+const EVENT_REMAINING = "remaining"
+const EVENT_ACTION = "action"
+
+// Actions carried by an EVENT_ACTION event.
+const ACTION_KILL = "kill"
+const ACTION_REFRESH = "refresh"
+
+// Event is a message pushed to the clients of a user. Targets and remaining
+// events carry the same target list, groups and override, with usage, served
+// by GET /targets and its ETag; action events ask the client to do something
+// right away.
+type Event struct {
+	Type      string       `json:"type"`
+	User      string       `json:"user"`
+	Action    string       `json:"action,omitempty"`
+	Target    string       `json:"target,omitempty"`
+	Targets   []*Target    `json:"targets,omitempty"`
+	Groups    []*Group     `json:"groups,omitempty"`
+	Override  *Override    `json:"override,omitempty"`
+	Calendar  Calendar     `json:"calendar,omitempty"`
+	Timezone  string       `json:"timezone,omitempty"`
+	Allowlist []string     `json:"allowlist,omitempty"`
+	WeekStart time.Weekday `json:"week_start,omitempty"`
+	ETag      string       `json:"etag,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func NewEvent(eventType string, user string) *Event {
+	return &Event{
+		Type:      eventType,
+		User:      user,
+		CreatedAt: time.Now(),
+	}
+}
+
+// NewAction builds an action event. An empty target applies the action to
+// every target of the user.
+func NewAction(user string, action string, target string) *Event {
+	ret := NewEvent(EVENT_ACTION, user)
+	ret.Action = action
+	ret.Target = target
+
+	return ret
+}
+
+// ValidAction reports whether an action is known by the clients.
+func ValidAction(action string) bool {
+	return action == ACTION_KILL || action == ACTION_REFRESH
+}
+
+// HasTargets reports whether the event carries a target list.
+func (e *Event) HasTargets() bool {
+	return e.Type == EVENT_TARGETS || e.Type == EVENT_REMAINING
+}
+
+func (e *Event) ToLog() string {
+	ret, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("[domain.Event.ToLog] Failed to marshal event to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
+
+func EventFromJson(jsonString string) (*Event, error) {
+	ret := &Event{}
+	err := json.Unmarshal([]byte(jsonString), ret)
+	if err != nil {
+		log.Printf("[domain.EventFromJson] Failed to unmarshal event from JSON: %v", err)
+		return nil, err
+	}
+
+	for _, v := range ret.Targets {
+		v.ApplyDefaults()
+	}
+
+	for _, v := range ret.Groups {
+		v.ApplyDefaults()
+	}
+
+	return ret, nil
+}
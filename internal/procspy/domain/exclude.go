@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+)
+
+// patternList is a list of regular expressions compiled once. Invalid
+// expressions are logged and left out.
+type patternList struct {
+	sources []string
+	rgx     []*regexp.Regexp
+}
+
+func compilePatterns(sources []string) *patternList {
+	ret := &patternList{}
+
+	for _, source := range sources {
+		rgx, err := regexp.Compile(source)
+
+		if err != nil {
+			log.Printf("[domain.compilePatterns] Ignoring invalid pattern '%s': %v", source, err)
+			continue
+		}
+
+		ret.sources = append(ret.sources, source)
+		ret.rgx = append(ret.rgx, rgx)
+	}
+
+	return ret
+}
+
+// find returns the first expression that matches a value, or empty.
+func (p *patternList) find(value string) string {
+	if p == nil {
+		return ""
+	}
+
+	for i, rgx := range p.rgx {
+		if rgx.MatchString(value) {
+			return p.sources[i]
+		}
+	}
+
+	return ""
+}
+
+// validatePatterns checks a list of regular expressions before it is stored.
+func validatePatterns(kind string, sources []string) error {
+	for _, source := range sources {
+		if source == "" {
+			return fmt.Errorf("empty %s pattern", kind)
+		}
+
+		if _, err := regexp.Compile(source); err != nil {
+			return fmt.Errorf("invalid %s pattern '%s': %v", kind, source, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateAllowlist checks the expressions of a global allowlist.
+func ValidateAllowlist(allowlist []string) error {
+	return validatePatterns("allowlist", allowlist)
+}
+
+// MergeAllowlists joins allowlists, such as the one served with the targets
+// and the one of the client, without repeating entries.
+func MergeAllowlists(lists ...[]string) []string {
+	ret := make([]string, 0)
+
+	for _, list := range lists {
+		for _, source := range list {
+			if !slices.Contains(ret, source) {
+				ret = append(ret, source)
+			}
+		}
+	}
+
+	return ret
+}
+
+// SetAllowlist sets the processes that no target of the list counts or
+// kills, whatever their patterns are.
+func (t *TargetList) SetAllowlist(allowlist []string) {
+	t.Allowlist = allowlist
+	compiled := compilePatterns(allowlist)
+
+	for _, target := range t.Targets {
+		target.allowlist = compiled
+	}
+}
+
+// ExcludedBy returns the allowlist or exclude expression that keeps a
+// process name out of the target, or empty when none does.
+func (t *Target) ExcludedBy(name string) string {
+	if by := t.allowlist.find(name); by != "" {
+		return by
+	}
+
+	if t.excludeRgx == nil {
+		t.excludeRgx = compilePatterns(t.Exclude)
+	}
+
+	return t.excludeRgx.find(name)
+}
+
+// Excluded returns the expression that kept a process out of the target
+// when the process would match it otherwise, for tuning the patterns.
+func (t *Target) Excluded(p *Process) string {
+	by := t.ExcludedBy(p.Executable)
+
+	if by == "" {
+		return ""
+	}
+
+	if _, ok := t.matchProcess(p); !ok {
+		return ""
+	}
+
+	return by
+}
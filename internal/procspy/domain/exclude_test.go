@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestTarget_Exclude testa os padrões de exclusão e a allowlist antes do padrão do alvo
+func TestTarget_Exclude(t *testing.T) {
+	browsers := "chrome|firefox|edge"
+
+	tests := []struct {
+		name      string
+		exclude   []string
+		allowlist []string
+		process   string
+		expected  bool
+		by        string
+	}{
+		{"Sem exclusão", nil, nil, "msedgewebview2", true, ""},
+		{"Excluído pelo alvo", []string{"webview"}, nil, "msedgewebview2", false, "webview"},
+		{"Exclusão não se aplica", []string{"webview"}, nil, "msedge", true, ""},
+		{"Excluído pela allowlist", nil, []string{"^msedgewebview2$"}, "msedgewebview2", false, "^msedgewebview2$"},
+		{"Allowlist de outro processo", nil, []string{"^zoom$"}, "firefox", true, ""},
+		{"Exclusão fora do padrão", []string{"zoom"}, nil, "zoom", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &TargetList{Targets: []*Target{{Name: "browsers", Pattern: browsers, Exclude: tt.exclude}}}
+			list.SetAllowlist(tt.allowlist)
+			target := list.Targets[0]
+			process := &Process{Executable: tt.process}
+
+			if got := target.Match(tt.process); got != tt.expected {
+				t.Errorf("Match() = %v, esperado %v", got, tt.expected)
+			}
+
+			if _, ok := target.MatchProcess(process); ok != tt.expected {
+				t.Errorf("MatchProcess() = %v, esperado %v", ok, tt.expected)
+			}
+
+			if by := target.Excluded(process); by != tt.by {
+				t.Errorf("Excluded() = %q, esperado %q", by, tt.by)
+			}
+		})
+	}
+}
+
+// TestTarget_ValidateExclude testa a validação dos padrões de exclusão
+func TestTarget_ValidateExclude(t *testing.T) {
+	tests := []struct {
+		name    string
+		exclude []string
+		wantErr bool
+	}{
+		{"Sem exclusão", nil, false},
+		{"Padrões válidos", []string{"webview", "^teams$"}, false},
+		{"Padrão vazio", []string{""}, true},
+		{"Padrão inválido", []string{"(["}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &Target{User: "user1", Name: "browsers", Pattern: "chrome", Exclude: tt.exclude}
+			if err := target.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, esperado erro %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateAllowlist([]string{"(["}); err == nil {
+		t.Error("ValidateAllowlist() deveria recusar uma expressão inválida")
+	}
+}
+
+// TestMergeAllowlists testa a junção das allowlists do servidor e do cliente
+func TestMergeAllowlists(t *testing.T) {
+	merged := MergeAllowlists([]string{"^zoom$", "^teams$"}, nil, []string{"^teams$", "webview"})
+	expected := []string{"^zoom$", "^teams$", "webview"}
+
+	if !slices.Equal(merged, expected) {
+		t.Errorf("MergeAllowlists() = %v, esperado %v", merged, expected)
+	}
+}
+
+// TestTargetListFromJson_Allowlist testa a allowlist recebida com os alvos
+func TestTargetListFromJson_Allowlist(t *testing.T) {
+	list, err := TargetListFromJson(`{"targets":[{"name":"browsers","pattern":"chrome|edge"}],"allowlist":["webview"]}`)
+	if err != nil {
+		t.Fatalf("TargetListFromJson() erro = %v", err)
+	}
+
+	if list.Targets[0].Match("msedgewebview2") {
+		t.Error("Processo da allowlist não deveria ser contado")
+	}
+
+	if !list.Targets[0].Match("msedge") {
+		t.Error("Processo fora da allowlist deveria ser contado")
+	}
+}
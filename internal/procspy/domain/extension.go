@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// DEFAULT_EXTENSION_DURATION is the time, in seconds, granted by an approval
+// when neither the request nor the approval sets it.
+const DEFAULT_EXTENSION_DURATION = 15 * 60
+
+const EXTENSION_PENDING = "pending"
+const EXTENSION_APPROVED = "approved"
+const EXTENSION_DENIED = "denied"
+
+// Extension is a request for more time made by the user of a client. It
+// waits for a parent, and an approval becomes a Grant.
+type Extension struct {
+	ID        int64    `json:"id"`
+	User      string   `json:"user"`
+	Target    string   `json:"target"`
+	Duration  Duration `json:"duration,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Status    string   `json:"status"`
+	DecidedBy string   `json:"decided_by,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	GrantID   int64    `json:"grant_id,omitempty"`
+	CreatedAt string   `json:"created_at,omitempty"`
+	DecidedAt string   `json:"decided_at,omitempty"`
+}
+
+// Validate checks a new request before it is queued.
+func (e *Extension) Validate() error {
+	if e.Target == "" {
+		return errors.New("extension target is required")
+	}
+
+	if e.Duration < 0 {
+		return fmt.Errorf("invalid extension duration %.0f, expected a positive duration", e.Duration.Seconds())
+	}
+
+	return nil
+}
+
+func (e *Extension) IsPending() bool {
+	return e.Status == EXTENSION_PENDING
+}
+
+// NewGrant builds the grant of an approved request. The duration of the
+// approval wins over the requested one.
+func (e *Extension) NewGrant(duration Duration) *Grant {
+	if duration <= 0 {
+		duration = e.Duration
+	}
+
+	if duration <= 0 {
+		duration = DEFAULT_EXTENSION_DURATION
+	}
+
+	return &Grant{
+		User:     e.User,
+		Target:   e.Target,
+		Duration: duration,
+		Reason:   fmt.Sprintf("extension request %d: %s", e.ID, e.Reason),
+	}
+}
+
+func (e *Extension) ToLog() string {
+	ret, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("[domain.Extension.ToLog] Failed to marshal extension to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
+
+func ExtensionFromJson(jsonString string) (*Extension, error) {
+	ret := &Extension{}
+	err := json.Unmarshal([]byte(jsonString), ret)
+	if err != nil {
+		log.Printf("[domain.ExtensionFromJson] Failed to unmarshal extension from JSON: %v", err)
+		return nil, err
+	}
+	return ret, nil
+}
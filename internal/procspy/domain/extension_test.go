@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+// TestExtension_NewGrant testa a duração da concessão criada por uma aprovação
+func TestExtension_NewGrant(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested Duration
+		approved  Duration
+		expected  Duration
+	}{
+		{"Duração da aprovação", 900, 1800, 1800},
+		{"Duração pedida", 900, 0, 900},
+		{"Duração padrão", 0, 0, DEFAULT_EXTENSION_DURATION},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extension := &Extension{ID: 3, User: "user1", Target: "games", Duration: tt.requested, Reason: "terminar a partida"}
+			grant := extension.NewGrant(tt.approved)
+
+			if grant.Duration != tt.expected || grant.Target != "games" || grant.User != "user1" {
+				t.Errorf("NewGrant() = %+v, esperado duração %.0f", grant, tt.expected.Seconds())
+			}
+		})
+	}
+}
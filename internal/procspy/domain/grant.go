@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Grant is extra time given to a target of a user, such as 30 more minutes
+// of games today. While active it raises the daily limit of the target, and
+// its quotas, by its duration. A grant that spans several days adds its time
+// to each of them.
+type Grant struct {
+	ID        int64     `json:"id"`
+	User      string    `json:"user"`
+	Target    string    `json:"target"`
+	Duration  Duration  `json:"duration"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt string    `json:"created_at,omitempty"`
+	RevokedAt string    `json:"revoked_at,omitempty"`
+}
+
+// EndOfDay returns the last moment of the day of a moment, the default
+// expiry of a grant.
+func EndOfDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+}
+
+// ApplyDefaults makes a grant without expiry last until the end of today.
+func (g *Grant) ApplyDefaults(now time.Time) {
+	if g.ExpiresAt.IsZero() {
+		g.ExpiresAt = EndOfDay(now)
+	}
+}
+
+// Validate checks the rules of a grant before it is stored.
+func (g *Grant) Validate(now time.Time) error {
+	if g.Target == "" {
+		return errors.New("grant target is required")
+	}
+
+	if g.Duration <= 0 {
+		return fmt.Errorf("invalid grant duration %.0f, expected a positive duration", g.Duration.Seconds())
+	}
+
+	if !g.ExpiresAt.After(now) {
+		return fmt.Errorf("grant expires at %s, expected a moment in the future", g.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (g *Grant) IsRevoked() bool {
+	return g.RevokedAt != ""
+}
+
+// IsActive reports whether the grant still adds time at a moment.
+func (g *Grant) IsActive(now time.Time) bool {
+	return !g.IsRevoked() && now.Before(g.ExpiresAt)
+}
+
+// grantedBetween returns the time the grant added to the days from one
+// midnight to another, the latter excluded: its duration on each day it was
+// created by, that it did not expire before and was not revoked on.
+func (g *Grant) grantedBetween(from time.Time, to time.Time) float64 {
+	created, createdErr := time.ParseInLocation(time.DateTime, g.CreatedAt, time.Local)
+	revoked, revokedErr := time.ParseInLocation(time.DateTime, g.RevokedAt, time.Local)
+	ret := 0.0
+
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+
+		if !g.ExpiresAt.After(day) || (createdErr == nil && !created.Before(next)) || (g.IsRevoked() && (revokedErr != nil || revoked.Before(next))) {
+			continue
+		}
+
+		ret += g.Duration.Seconds()
+	}
+
+	return ret
+}
+
+// ApplyGrants adds the active grants to the limits of their targets and of
+// the groups of those targets, so a grant is not swallowed by a group budget
+// that is already used up. The quotas are raised by the grants of every day
+// of the week and month so far, as the time granted on past days is still
+// part of their usage. Grants of targets that no longer exist are ignored.
+func (t *TargetList) ApplyGrants(now time.Time, grants []*Grant) {
+	now = zoned(now, t.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekStart := WeekStart(now, t.WeekStart)
+	monthStart := MonthStart(now)
+
+	for _, group := range t.Groups {
+		group.Granted = 0
+	}
+
+	for _, target := range t.Targets {
+		target.Granted = 0
+		target.WeeklyGranted = 0
+		target.MonthlyGranted = 0
+
+		for _, grant := range grants {
+			if grant.Target != target.Name {
+				continue
+			}
+
+			if grant.IsActive(now) {
+				target.Granted += grant.Duration.Seconds()
+			}
+
+			target.WeeklyGranted += grant.grantedBetween(weekStart, today)
+			target.MonthlyGranted += grant.grantedBetween(monthStart, today)
+		}
+
+		target.WeeklyGranted += target.Granted
+		target.MonthlyGranted += target.Granted
+		target.updateRemaining()
+
+		for _, name := range target.Groups {
+			if group := t.Group(name); group != nil {
+				group.Granted += target.Granted
+			}
+		}
+	}
+
+	for _, group := range t.Groups {
+		group.SetElapsed(group.Elapsed)
+	}
+}
+
+func (g *Grant) ToLog() string {
+	ret, err := json.Marshal(g)
+	if err != nil {
+		log.Printf("[domain.Grant.ToLog] Failed to marshal grant to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGrant_Validate testa as regras de uma concessão de tempo
+func TestGrant_Validate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		grant   *Grant
+		wantErr bool
+	}{
+		{"Concessão válida", &Grant{Target: "games", Duration: 1800}, false},
+		{"Sem target", &Grant{Duration: 1800}, true},
+		{"Sem duração", &Grant{Target: "games"}, true},
+		{"Já expirada", &Grant{Target: "games", Duration: 1800, ExpiresAt: now.Add(-time.Minute)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.grant.ApplyDefaults(now)
+			if err := tt.grant.Validate(now); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, esperado erro %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Sem expiração a concessão vale até o fim do dia
+	grant := &Grant{Target: "games", Duration: 1800}
+	grant.ApplyDefaults(now)
+	if !grant.ExpiresAt.Equal(time.Date(2025, 3, 10, 23, 59, 59, 0, time.Local)) {
+		t.Errorf("ExpiresAt = %v, esperado fim do dia", grant.ExpiresAt)
+	}
+}
+
+// TestTargetList_ApplyGrants testa o tempo concedido no limite e nas cotas
+func TestTargetList_ApplyGrants(t *testing.T) {
+	now := time.Now()
+	games := &Target{Name: "games", Pattern: "steam", Limit: 3600, WeeklyLimit: 7200}
+	videos := &Target{Name: "videos", Pattern: "vlc", Limit: 3600}
+
+	list := &TargetList{Targets: []*Target{games, videos}}
+	games.SetElapsed(3600)
+	games.SetQuotaElapsed(7200, 7200)
+
+	if !games.CheckLimit() {
+		t.Fatal("CheckLimit() = false, esperado limite atingido antes da concessão")
+	}
+
+	list.ApplyGrants(now, []*Grant{
+		{ID: 1, Target: "games", Duration: 1800, ExpiresAt: now.Add(time.Hour)},
+		{ID: 2, Target: "games", Duration: 600, ExpiresAt: now.Add(-time.Minute)},
+		{ID: 3, Target: "games", Duration: 600, ExpiresAt: now.Add(time.Hour), RevokedAt: "2025-03-10 10:00:00"},
+		{ID: 4, Target: "unknown", Duration: 600, ExpiresAt: now.Add(time.Hour)},
+	})
+
+	// Só a concessão ativa conta, inclusive para a cota semanal
+	if games.Granted != 1800 || games.DailyLimit != 5400 || games.Remaining != 1800 || games.WeeklyRemaining != 1800 {
+		t.Errorf("games = granted %.0f, limite %.0f, restante %.0f, semana %.0f, esperado 1800, 5400, 1800 e 1800", games.Granted, games.DailyLimit, games.Remaining, games.WeeklyRemaining)
+	}
+
+	if games.CheckLimit() {
+		t.Error("CheckLimit() = true, esperado tempo liberado pela concessão")
+	}
+
+	if videos.Granted != 0 || videos.DailyLimit != 3600 {
+		t.Errorf("videos = granted %.0f, limite %.0f, esperado sem concessão", videos.Granted, videos.DailyLimit)
+	}
+}
+
+// TestTargetList_ApplyGrants_Quota testa o tempo concedido em dias anteriores nas cotas
+func TestTargetList_ApplyGrants_Quota(t *testing.T) {
+	// Quarta-feira
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local)
+
+	grants := []*Grant{
+		// Só na segunda
+		{ID: 1, Target: "games", Duration: 1800, CreatedAt: "2025-03-10 09:00:00", ExpiresAt: time.Date(2025, 3, 10, 23, 59, 59, 0, time.Local)},
+		// De terça a quinta: ontem e hoje
+		{ID: 2, Target: "games", Duration: 600, CreatedAt: "2025-03-11 10:00:00", ExpiresAt: time.Date(2025, 3, 13, 23, 59, 59, 0, time.Local)},
+		// Revogada na terça: só a segunda conta
+		{ID: 3, Target: "games", Duration: 900, CreatedAt: "2025-03-10 08:00:00", ExpiresAt: time.Date(2025, 3, 12, 23, 59, 59, 0, time.Local), RevokedAt: "2025-03-11 12:00:00"},
+	}
+
+	tests := []struct {
+		name      string
+		weekStart time.Weekday
+		weekly    float64
+	}{
+		{"Semana desde domingo", time.Sunday, 3900},
+		{"Semana começando hoje", time.Wednesday, 600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games := &Target{Name: "games", Pattern: "steam", Limit: 3600, WeeklyLimit: 3600, MonthlyLimit: 36000}
+			list := &TargetList{Targets: []*Target{games}, WeekStart: tt.weekStart}
+			games.SetQuotaElapsed(7000, 7000)
+
+			list.ApplyGrants(now, grants)
+
+			if games.Granted != 600 || games.WeeklyGranted != tt.weekly || games.MonthlyGranted != 3900 {
+				t.Errorf("games = granted %.0f, semana %.0f, mês %.0f, esperado 600, %.0f e 3900", games.Granted, games.WeeklyGranted, games.MonthlyGranted, tt.weekly)
+			}
+
+			// O tempo concedido no começo da semana continua liberando a cota
+			if expected := max(3600+tt.weekly-7000, 0); games.WeeklyRemaining != expected {
+				t.Errorf("WeeklyRemaining = %.0f, esperado %.0f", games.WeeklyRemaining, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Group is a time budget shared by the targets that list it in their groups,
+// such as 90 minutes a day of "entertainment". Its usage is the union of the
+// usage of its members: time in which several members ran counts once.
+type Group struct {
+	Name          string           `json:"name"`
+	Limit         Duration         `json:"limit,omitempty"`
+	WeekdayLimits map[int]Duration `json:"weekday_limits,omitempty"`
+	DailyLimit    float64          `json:"daily_limit"`
+	Elapsed       float64          `json:"elapsed,omitempty"`
+	Remaining     float64          `json:"remaining"`
+	Granted       float64          `json:"granted,omitempty"`
+	calendar      Calendar
+	location      *time.Location
+}
+
+// Validate checks the rules of a group before it is stored.
+func (g *Group) Validate() error {
+	if g.Name == "" {
+		return errors.New("group name is required")
+	}
+
+	if g.Limit < 0 {
+		return fmt.Errorf("invalid limit %.0f for group '%s', expected a positive duration", g.Limit.Seconds(), g.Name)
+	}
+
+	for day, limit := range g.WeekdayLimits {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid weekday %d for group '%s', expected 0 (sunday) to 6 (saturday)", day, g.Name)
+		}
+
+		if limit < 0 {
+			return fmt.Errorf("invalid limit %.0f for weekday %d of group '%s'", limit.Seconds(), day, g.Name)
+		}
+	}
+
+	if g.Limit == 0 && len(g.WeekdayLimits) == 0 {
+		return fmt.Errorf("group '%s' needs a limit", g.Name)
+	}
+
+	return nil
+}
+
+// ApplyDefaults computes today's limit.
+func (g *Group) ApplyDefaults() {
+	g.getLimit()
+}
+
+// getLimit computes today's limit, in seconds, from the limit of the weekday,
+// or of the calendar profile of today, or the group limit, raised by the time
+// granted to its members. A group without a limit today stays unlimited.
+func (g *Group) getLimit() float64 {
+	if limit, found := g.WeekdayLimits[int(g.calendar.Weekday(zoned(time.Now(), g.location)))]; found {
+		g.DailyLimit = limit.Seconds()
+	} else {
+		g.DailyLimit = g.Limit.Seconds()
+	}
+
+	if g.DailyLimit > 0 {
+		g.DailyLimit += g.Granted
+	}
+
+	return g.DailyLimit
+}
+
+func (g *Group) AddElapsed(elapsed float64) {
+	g.SetElapsed(g.Elapsed + elapsed)
+}
+
+func (g *Group) SetElapsed(elapsed float64) {
+	g.Elapsed = elapsed
+	g.Remaining = g.getLimit() - elapsed
+}
+
+// CheckLimit reports whether the group has no time left today. A group
+// without a limit today never blocks.
+func (g *Group) CheckLimit() bool {
+	limit := g.getLimit()
+	if limit == 0 {
+		return false
+	}
+
+	return g.Elapsed >= limit
+}
+
+// ValidateGroups checks a set of groups and that every group used by the
+// targets is one of them.
+func ValidateGroups(groups []*Group, targets []*Target) error {
+	names := make(map[string]bool, len(groups))
+
+	for _, group := range groups {
+		if err := group.Validate(); err != nil {
+			return err
+		}
+
+		if names[group.Name] {
+			return fmt.Errorf("duplicated group '%s'", group.Name)
+		}
+
+		names[group.Name] = true
+	}
+
+	for _, target := range targets {
+		for _, name := range target.Groups {
+			if !names[name] {
+				return fmt.Errorf("target '%s' uses unknown group '%s'", target.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGroup_Validate testa as regras de um grupo
+func TestGroup_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   *Group
+		wantErr bool
+	}{
+		{"Grupo válido", &Group{Name: "entertainment", Limit: 5400}, false},
+		{"Apenas limites por dia", &Group{Name: "entertainment", WeekdayLimits: map[int]Duration{6: 7200}}, false},
+		{"Sem nome", &Group{Limit: 5400}, true},
+		{"Sem limite", &Group{Name: "entertainment"}, true},
+		{"Limite negativo", &Group{Name: "entertainment", Limit: -1}, true},
+		{"Dia da semana inválido", &Group{Name: "entertainment", WeekdayLimits: map[int]Duration{7: 60}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.group.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateGroups testa nomes repetidos e grupos usados pelos targets
+func TestValidateGroups(t *testing.T) {
+	groups := []*Group{{Name: "entertainment", Limit: 5400}}
+
+	if err := ValidateGroups(groups, []*Target{{Name: "games", Groups: []string{"entertainment"}}}); err != nil {
+		t.Errorf("ValidateGroups() erro = %v", err)
+	}
+
+	if err := ValidateGroups(groups, []*Target{{Name: "games", Groups: []string{"unknown"}}}); err == nil {
+		t.Error("ValidateGroups() deveria rejeitar grupo inexistente")
+	}
+
+	if err := ValidateGroups(append(groups, &Group{Name: "entertainment", Limit: 60}), nil); err == nil {
+		t.Error("ValidateGroups() deveria rejeitar grupo duplicado")
+	}
+}
+
+// TestGroup_CheckLimit testa o limite do grupo e o limite do dia
+func TestGroup_CheckLimit(t *testing.T) {
+	today := int(time.Now().Weekday())
+
+	group := &Group{Name: "entertainment", Limit: 5400}
+	group.SetElapsed(5000)
+
+	if group.CheckLimit() || group.Remaining != 400 {
+		t.Errorf("Grupo não deveria estar esgotado: %+v", group)
+	}
+
+	group.AddElapsed(400)
+
+	if !group.CheckLimit() {
+		t.Errorf("Grupo deveria estar esgotado: %+v", group)
+	}
+
+	// Limite do dia tem precedência; zero não bloqueia
+	group.WeekdayLimits = map[int]Duration{today: 0}
+
+	if group.CheckLimit() || group.DailyLimit != 0 {
+		t.Errorf("Grupo sem limite hoje não deveria bloquear: %+v", group)
+	}
+}
+
+// TestTargetList_Members testa os targets de um grupo
+func TestTargetList_Members(t *testing.T) {
+	list, err := TargetListFromJson(`{
+		"targets": [
+			{"name": "games", "pattern": "steam", "groups": ["entertainment"]},
+			{"name": "videos", "pattern": "vlc", "groups": ["school", "entertainment"]},
+			{"name": "school", "pattern": "libreoffice"}
+		],
+		"groups": [{"name": "entertainment", "limit": "90m"}]
+	}`)
+
+	if err != nil {
+		t.Fatalf("TargetListFromJson() erro = %v", err)
+	}
+
+	members := list.Members("entertainment")
+	if len(members) != 2 || members[0] != "games" || members[1] != "videos" {
+		t.Errorf("Members() = %v, esperado [games videos]", members)
+	}
+
+	if group := list.Group("entertainment"); group == nil || group.DailyLimit != 5400 {
+		t.Errorf("Group() = %+v, esperado limite de 5400s", group)
+	}
+
+	if list.Group("unknown") != nil {
+		t.Error("Group() deveria retornar nil para grupo inexistente")
+	}
+}
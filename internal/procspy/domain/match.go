@@ -4,14 +4,19 @@ import (
 	"encoding/json"
 	"log"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Match struct {
+	EventID    string    `json:"event_id,omitempty"`
 	User       string    `json:"user"`
 	Name       string    `json:"name"`
 	Pattern    string    `json:"pattern"`
 	Match      string    `json:"match"`
 	Elapsed    float64   `json:"elapsed"`
+	Field      string    `json:"field,omitempty"`
+	Idle       float64   `json:"idle,omitempty"`
 	CreatedAt  time.Time `json:"created_at,omitempty"`
 	FirstMatch string    `json:"first_match,omitempty"`
 	LastMatch  string    `json:"last_match,omitempty"`
@@ -31,6 +36,7 @@ type MatchInfo struct {
 
 func NewMatch(user string, name string, pattern string, match string, elapsed float64) *Match {
 	ret := &Match{
+		EventID:   uuid.NewString(),
 		User:      user,
 		Name:      name,
 		Pattern:   pattern,
@@ -55,6 +55,15 @@ func TestNewMatch(t *testing.T) {
 	if diff > time.Second {
 		t.Errorf("CreatedAt está muito distante do tempo atual: %v", diff)
 	}
+
+	// Valida que cada match recebe um event_id único
+	if result.EventID == "" {
+		t.Error("EventID não foi inicializado")
+	}
+
+	if other := NewMatch(user, name, pattern, match, elapsed); other.EventID == result.EventID {
+		t.Error("EventID deveria ser único por match")
+	}
 }
 
 // TestMatch_ToJson testa a serialização de Match para JSON
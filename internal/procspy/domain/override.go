@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Override modes of a user. Locked blocks every target, unlimited lifts
+// every limit and normal follows the rules of the targets.
+const OVERRIDE_NORMAL = "normal"
+const OVERRIDE_LOCKED = "locked"
+const OVERRIDE_UNLIMITED = "unlimited"
+
+// Override replaces the rules of all targets of a user until a moment, such
+// as locking everything at dinner time or lifting the limits on a sick day.
+// Without Until it lasts until the mode is set back to normal.
+type Override struct {
+	User      string    `json:"user,omitempty"`
+	Mode      string    `json:"mode"`
+	Until     time.Time `json:"until,omitzero"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt string    `json:"created_at,omitempty"`
+}
+
+// Validate checks an override before it is stored.
+func (o *Override) Validate(now time.Time) error {
+	switch o.Mode {
+	case OVERRIDE_NORMAL:
+		return nil
+	case OVERRIDE_LOCKED, OVERRIDE_UNLIMITED:
+	default:
+		return fmt.Errorf("invalid mode '%s', expected %s, %s or %s", o.Mode, OVERRIDE_NORMAL, OVERRIDE_LOCKED, OVERRIDE_UNLIMITED)
+	}
+
+	if !o.Until.IsZero() && !o.Until.After(now) {
+		return fmt.Errorf("override until %s, expected a moment in the future", o.Until.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// ModeAt returns the mode in effect at a moment: an expired override, or no
+// override at all, is normal.
+func (o *Override) ModeAt(now time.Time) string {
+	if o == nil || o.Mode == "" || (!o.Until.IsZero() && !now.Before(o.Until)) {
+		return OVERRIDE_NORMAL
+	}
+
+	return o.Mode
+}
+
+// Describe renders the mode and its end for logs and reports.
+func (o *Override) Describe() string {
+	if o == nil || o.Mode == "" {
+		return OVERRIDE_NORMAL
+	}
+
+	if o.Mode == OVERRIDE_NORMAL || o.Until.IsZero() {
+		return o.Mode
+	}
+
+	return fmt.Sprintf("%s until %s", o.Mode, o.Until.Format(time.RFC3339))
+}
+
+// Mode returns the override mode of the list in effect at a moment.
+func (t *TargetList) Mode(now time.Time) string {
+	return t.Override.ModeAt(now)
+}
+
+func (o *Override) ToLog() string {
+	ret, err := json.Marshal(o)
+	if err != nil {
+		log.Printf("[domain.Override.ToLog] Failed to marshal override to JSON: %v", err)
+		return ""
+	}
+	return string(ret)
+}
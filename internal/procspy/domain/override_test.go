@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOverride_Validate testa os modos aceitos e o fim no futuro
+func TestOverride_Validate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 19, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		override *Override
+		wantErr  bool
+	}{
+		{"Bloqueado até as 20h", &Override{Mode: OVERRIDE_LOCKED, Until: now.Add(time.Hour)}, false},
+		{"Liberado sem fim", &Override{Mode: OVERRIDE_UNLIMITED}, false},
+		{"Normal", &Override{Mode: OVERRIDE_NORMAL}, false},
+		{"Modo inválido", &Override{Mode: "paused"}, true},
+		{"Sem modo", &Override{}, true},
+		{"Fim no passado", &Override{Mode: OVERRIDE_LOCKED, Until: now.Add(-time.Minute)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.override.Validate(now); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, esperado erro %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestOverride_ModeAt testa o modo em vigor antes e depois do fim
+func TestOverride_ModeAt(t *testing.T) {
+	now := time.Date(2025, 3, 10, 19, 0, 0, 0, time.Local)
+	locked := &Override{Mode: OVERRIDE_LOCKED, Until: now.Add(time.Hour)}
+
+	tests := []struct {
+		name     string
+		override *Override
+		at       time.Time
+		expected string
+	}{
+		{"Sem override", nil, now, OVERRIDE_NORMAL},
+		{"Antes do fim", locked, now, OVERRIDE_LOCKED},
+		{"No fim", locked, now.Add(time.Hour), OVERRIDE_NORMAL},
+		{"Sem fim", &Override{Mode: OVERRIDE_UNLIMITED}, now.AddDate(1, 0, 0), OVERRIDE_UNLIMITED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := &TargetList{Override: tt.override}
+			if mode := list.Mode(tt.at); mode != tt.expected {
+				t.Errorf("Mode() = %s, esperado %s", mode, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Process fields a target can match. The executable name is matched by the
+// pattern of the target; the others by its match fields.
+const FIELD_EXECUTABLE = "executable"
+const FIELD_PATH = "path"
+const FIELD_CMDLINE = "cmdline"
+const FIELD_USER = "user"
+const FIELD_PARENT = "parent"
+
+// Combinations of the pattern and the match fields of a target.
+const FIELDS_AND = "and"
+const FIELDS_OR = "or"
+
+// Process is a running process as seen by the client. Path, Cmdline, User
+// and UID are only read where the system exposes them (/proc on Linux) and
+// are empty elsewhere.
+type Process struct {
+	Pid        int
+	Executable string
+	Path       string
+	Cmdline    string
+	User       string
+	UID        string
+	Parent     string
+}
+
+// MatchFields narrows or widens the pattern of a target with regular
+// expressions on other fields of a process, such as telling Minecraft from
+// a school IDE by the command line of "java". With Mode "and", the default,
+// the pattern and every field set must match; with "or", any of them.
+type MatchFields struct {
+	Path    string `json:"path,omitempty"`
+	Cmdline string `json:"cmdline,omitempty"`
+	User    string `json:"user,omitempty"`
+	Parent  string `json:"parent,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+// Validate checks the mode and the expressions. Nil fields are valid.
+func (f *MatchFields) Validate() error {
+	if f == nil {
+		return nil
+	}
+
+	if f.Mode != "" && f.Mode != FIELDS_AND && f.Mode != FIELDS_OR {
+		return fmt.Errorf("invalid match mode '%s', expected %s or %s", f.Mode, FIELDS_AND, FIELDS_OR)
+	}
+
+	for _, field := range f.patterns() {
+		if _, err := regexp.Compile(field[1]); err != nil {
+			return fmt.Errorf("invalid %s pattern '%s': %v", field[0], field[1], err)
+		}
+	}
+
+	return nil
+}
+
+// patterns returns the field names and expressions that are set, in a fixed
+// order.
+func (f *MatchFields) patterns() [][2]string {
+	ret := make([][2]string, 0, 4)
+
+	if f == nil {
+		return ret
+	}
+
+	for _, field := range [][2]string{{FIELD_PATH, f.Path}, {FIELD_CMDLINE, f.Cmdline}, {FIELD_USER, f.User}, {FIELD_PARENT, f.Parent}} {
+		if field[1] != "" {
+			ret = append(ret, field)
+		}
+	}
+
+	return ret
+}
+
+// NeedsDetails reports whether the fields read the path, command line or
+// user of a process, which cost a read of /proc per process.
+func (f *MatchFields) NeedsDetails() bool {
+	return f != nil && (f.Path != "" || f.Cmdline != "" || f.User != "")
+}
+
+func (f *MatchFields) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s %s %s %s", f.Path, f.Cmdline, f.User, f.Parent, f.Mode)
+}
+
+// value returns the field of a process by name.
+func (p *Process) value(field string) string {
+	switch field {
+	case FIELD_PATH:
+		return p.Path
+	case FIELD_CMDLINE:
+		return p.Cmdline
+	case FIELD_USER:
+		return p.User
+	case FIELD_PARENT:
+		return p.Parent
+	}
+
+	return p.Executable
+}
+
+// MatchProcess reports whether a process is one of the target, and which
+// fields matched, comma separated, for the posted match. Processes of the
+// allowlist or of the exclude patterns never are.
+func (t *Target) MatchProcess(p *Process) (string, bool) {
+	if t.ExcludedBy(p.Executable) != "" {
+		return "", false
+	}
+
+	return t.matchProcess(p)
+}
+
+func (t *Target) matchProcess(p *Process) (string, bool) {
+	if t.Fields == nil {
+		if t.matchPattern(p.Executable) {
+			return FIELD_EXECUTABLE, true
+		}
+
+		return "", false
+	}
+
+	if t.fieldRgx == nil {
+		t.fieldRgx = make(map[string]*regexp.Regexp)
+
+		for _, field := range t.Fields.patterns() {
+			t.fieldRgx[field[0]] = regexp.MustCompile(field[1])
+		}
+	}
+
+	matched := make([]string, 0, len(t.fieldRgx)+1)
+
+	if t.matchPattern(p.Executable) {
+		matched = append(matched, FIELD_EXECUTABLE)
+	}
+
+	for _, field := range t.Fields.patterns() {
+		if t.fieldRgx[field[0]].MatchString(p.value(field[0])) {
+			matched = append(matched, field[0])
+		}
+	}
+
+	if t.Fields.Mode == FIELDS_OR {
+		return strings.Join(matched, ","), len(matched) > 0
+	}
+
+	if len(matched) == len(t.fieldRgx)+1 {
+		return strings.Join(matched, ","), true
+	}
+
+	return "", false
+}
+
+// NeedsDetails reports whether any target of the list matches on the path,
+// command line or user of the processes.
+func (t *TargetList) NeedsDetails() bool {
+	for _, target := range t.Targets {
+		if target.Fields.NeedsDetails() {
+			return true
+		}
+	}
+
+	return false
+}
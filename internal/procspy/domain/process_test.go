@@ -0,0 +1,67 @@
+package domain
+
+import "testing"
+
+// TestTarget_MatchProcess testa a combinação do padrão com os campos do processo
+func TestTarget_MatchProcess(t *testing.T) {
+	minecraft := &Process{Executable: "java", Path: "/usr/bin/java", Cmdline: "java -jar minecraft-launcher.jar", User: "fino", Parent: "bash"}
+	ide := &Process{Executable: "java", Path: "/usr/bin/java", Cmdline: "java -jar eclipse.jar", User: "fino", Parent: "gnome-shell"}
+	game := &Process{Executable: "wine64-preloader", Cmdline: "C:\\Games\\game.exe", Parent: "wine"}
+
+	tests := []struct {
+		name     string
+		target   *Target
+		process  *Process
+		expected bool
+		field    string
+	}{
+		{"Só o padrão", &Target{Pattern: "java"}, ide, true, FIELD_EXECUTABLE},
+		{"Padrão e linha de comando", &Target{Pattern: "^java$", Fields: &MatchFields{Cmdline: "minecraft"}}, minecraft, true, "executable,cmdline"},
+		{"Linha de comando diferente", &Target{Pattern: "^java$", Fields: &MatchFields{Cmdline: "minecraft"}}, ide, false, ""},
+		{"Usuário e caminho", &Target{Pattern: "java", Fields: &MatchFields{Path: "^/usr/bin/", User: "^fino$"}}, ide, true, "executable,path,user"},
+		{"Usuário diferente", &Target{Pattern: "java", Fields: &MatchFields{User: "^root$"}}, ide, false, ""},
+		{"Processo pai com ou", &Target{Pattern: "^steam$", Fields: &MatchFields{Parent: "^wine", Mode: FIELDS_OR}}, game, true, FIELD_PARENT},
+		{"Nenhum campo com ou", &Target{Pattern: "^steam$", Fields: &MatchFields{Parent: "^steam", Mode: FIELDS_OR}}, game, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, ok := tt.target.MatchProcess(tt.process)
+			if ok != tt.expected || field != tt.field {
+				t.Errorf("MatchProcess() = %q, %v, esperado %q, %v", field, ok, tt.field, tt.expected)
+			}
+		})
+	}
+}
+
+// TestMatchFields_Validate testa o modo e as expressões dos campos
+func TestMatchFields_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  *MatchFields
+		wantErr bool
+	}{
+		{"Sem campos", nil, false},
+		{"Campos válidos", &MatchFields{Cmdline: "minecraft", Mode: FIELDS_AND}, false},
+		{"Modo inválido", &MatchFields{Cmdline: "minecraft", Mode: "xor"}, true},
+		{"Expressão inválida", &MatchFields{Path: "(["}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fields.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, esperado erro %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	list := &TargetList{Targets: []*Target{{Pattern: "java", Fields: &MatchFields{Parent: "bash"}}}}
+	if list.NeedsDetails() {
+		t.Error("NeedsDetails() não deveria ler /proc só para o processo pai")
+	}
+
+	list.Targets[0].Fields.Cmdline = "minecraft"
+	if !list.NeedsDetails() {
+		t.Error("NeedsDetails() deveria ler /proc para a linha de comando")
+	}
+}
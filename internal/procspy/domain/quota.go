@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+// WeekStart returns midnight of the first day of the week of a moment, for
+// weeks starting on the given weekday.
+func WeekStart(now time.Time, first time.Weekday) time.Time {
+	days := (int(now.Weekday()) - int(first) + 7) % 7
+	return time.Date(now.Year(), now.Month(), now.Day()-days, 0, 0, 0, 0, now.Location())
+}
+
+// MonthStart returns midnight of the first day of the month of a moment.
+func MonthStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+// HasQuota reports whether the target has a weekly or monthly quota.
+func (t *Target) HasQuota() bool {
+	return t.WeeklyLimit > 0 || t.MonthlyLimit > 0
+}
+
+// SetQuotaElapsed sets the usage of the current week and month, today
+// included, and updates the time left.
+func (t *Target) SetQuotaElapsed(weekly float64, monthly float64) {
+	t.WeeklyElapsed = weekly
+	t.MonthlyElapsed = monthly
+	t.updateRemaining()
+}
+
+// CheckQuota reports whether the weekly or monthly quota is used up.
+func (t *Target) CheckQuota() bool {
+	if t.WeeklyLimit > 0 && t.WeeklyElapsed >= t.quotaLimit(t.WeeklyLimit, t.WeeklyGranted) {
+		return true
+	}
+
+	return t.MonthlyLimit > 0 && t.MonthlyElapsed >= t.quotaLimit(t.MonthlyLimit, t.MonthlyGranted)
+}
+
+// quotaLimit is a quota raised by the time granted in its period, so a grant
+// is not swallowed by a quota that is already used up. The time granted in
+// the period includes today's, which is used alone when the period was not
+// computed, e.g. in lists cached before it was served.
+func (t *Target) quotaLimit(limit Duration, granted float64) float64 {
+	return limit.Seconds() + max(granted, t.Granted)
+}
+
+// updateRemaining computes the time left today, which is the smallest of
+// what is left of the daily limit and of the quotas.
+func (t *Target) updateRemaining() {
+	t.Remaining = t.getLimit() - t.Elapsed
+	t.WeeklyRemaining = 0
+	t.MonthlyRemaining = 0
+
+	if t.WeeklyLimit > 0 {
+		t.WeeklyRemaining = max(t.quotaLimit(t.WeeklyLimit, t.WeeklyGranted)-t.WeeklyElapsed, 0)
+		t.Remaining = min(t.Remaining, t.WeeklyRemaining)
+	}
+
+	if t.MonthlyLimit > 0 {
+		t.MonthlyRemaining = max(t.quotaLimit(t.MonthlyLimit, t.MonthlyGranted)-t.MonthlyElapsed, 0)
+		t.Remaining = min(t.Remaining, t.MonthlyRemaining)
+	}
+}
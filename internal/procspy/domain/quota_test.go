@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWeekStart testa o início da semana conforme o primeiro dia configurado
+func TestWeekStart(t *testing.T) {
+	// 7 de janeiro de 2026 é quarta-feira
+	now := time.Date(2026, 1, 7, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		first    time.Weekday
+		expected int
+	}{
+		{"Semana começando no domingo", time.Sunday, 4},
+		{"Semana começando na segunda", time.Monday, 5},
+		{"Semana começando no próprio dia", time.Wednesday, 7},
+		{"Semana começando na quinta", time.Thursday, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := WeekStart(now, tt.first)
+			expected := time.Date(2026, 1, tt.expected, 0, 0, 0, 0, time.Local)
+
+			if !start.Equal(expected) {
+				t.Errorf("WeekStart() = %v, esperado %v", start, expected)
+			}
+		})
+	}
+
+	if start := MonthStart(now); !start.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("MonthStart() = %v, esperado 1 de janeiro", start)
+	}
+}
+
+// TestTarget_Quota testa as cotas semanal e mensal junto do limite diário
+func TestTarget_Quota(t *testing.T) {
+	target := &Target{Name: "games", Limit: 3 * 3600, WeeklyLimit: 10 * 3600, MonthlyLimit: 30 * 3600}
+
+	// Servidor informa 1h hoje e 9h30 na semana
+	target.SetElapsed(3600)
+	target.SetQuotaElapsed(9.5*3600, 20*3600)
+
+	if target.Remaining != 1800 || target.WeeklyRemaining != 1800 || target.MonthlyRemaining != 10*3600 {
+		t.Errorf("Restante = %.0f / %.0f / %.0f, esperado 1800 / 1800 / 36000", target.Remaining, target.WeeklyRemaining, target.MonthlyRemaining)
+	}
+
+	if target.CheckLimit() {
+		t.Error("CheckLimit() não deveria bloquear com cota restante")
+	}
+
+	// Uso local soma no dia, na semana e no mês
+	target.AddElapsed(1800)
+
+	if target.WeeklyElapsed != 10*3600 || target.MonthlyElapsed != 20.5*3600 {
+		t.Errorf("Uso = %.0f / %.0f, esperado 36000 / 73800", target.WeeklyElapsed, target.MonthlyElapsed)
+	}
+
+	if !target.CheckQuota() || !target.CheckLimit() {
+		t.Error("Cota semanal esgotada deveria bloquear mesmo abaixo do limite diário")
+	}
+
+	// Reconciliação ajusta a semana pela diferença do dia
+	target.SetElapsed(3600)
+
+	if target.WeeklyElapsed != 9.5*3600 || target.CheckLimit() {
+		t.Errorf("WeeklyElapsed = %.0f, esperado 34200 após reconciliação", target.WeeklyElapsed)
+	}
+}
+
+// TestTarget_Quota_None testa target sem cotas
+func TestTarget_Quota_None(t *testing.T) {
+	target := &Target{Name: "games", Limit: 3600}
+	target.SetElapsed(600)
+
+	if target.HasQuota() || target.CheckQuota() {
+		t.Error("Target sem cota não deveria ter cota")
+	}
+
+	if target.Remaining != 3000 || target.WeeklyRemaining != 0 {
+		t.Errorf("Restante = %.0f / %.0f, esperado 3000 / 0", target.Remaining, target.WeeklyRemaining)
+	}
+}
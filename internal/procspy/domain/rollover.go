@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DEFAULT_ROLLOVER_DAYS is how many previous days are looked at when a
+// rollover policy does not set it.
+const DEFAULT_ROLLOVER_DAYS = 7
+
+// Rollover carries the unused time of previous days over to today, such as
+// unused weekday minutes to the weekend. Unused time of the days in From is
+// saved, up to Cap, and spent on the days in To when they go over their own
+// limit. Empty From or To mean every day.
+type Rollover struct {
+	Days int      `json:"days,omitempty"`
+	Cap  Duration `json:"cap"`
+	From []int    `json:"from,omitempty"`
+	To   []int    `json:"to,omitempty"`
+}
+
+// Validate checks the rollover policy. A nil policy is valid.
+func (r *Rollover) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if r.Days < 0 {
+		return fmt.Errorf("invalid rollover days %d", r.Days)
+	}
+
+	if r.Cap <= 0 {
+		return fmt.Errorf("invalid rollover cap %.0f, expected a positive duration", r.Cap.Seconds())
+	}
+
+	for _, day := range append(append([]int{}, r.From...), r.To...) {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid rollover weekday %d, expected 0 (sunday) to 6 (saturday)", day)
+		}
+	}
+
+	return nil
+}
+
+func (r *Rollover) String() string {
+	if r == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d %f %v %v", r.Days, r.Cap.Seconds(), r.From, r.To)
+}
+
+func (r *Rollover) days() int {
+	if r.Days > 0 {
+		return r.Days
+	}
+
+	return DEFAULT_ROLLOVER_DAYS
+}
+
+func includesDay(days []int, day time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+
+	for _, v := range days {
+		if v == int(day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RolloverStart returns the first day whose usage a rollover policy needs.
+func (t *Target) RolloverStart(now time.Time) time.Time {
+	if t.Rollover == nil {
+		return now
+	}
+
+	return time.Date(now.Year(), now.Month(), now.Day()-t.Rollover.days(), 0, 0, 0, 0, now.Location())
+}
+
+// ApplyRollover computes the time carried over to today from the usage of
+// the previous days, keyed by date (YYYY-MM-DD), and adds it to today's
+// limit. Nothing is carried over to a day out of To. Calendar days count as
+// the weekday of their profile.
+func (t *Target) ApplyRollover(now time.Time, usage map[string]float64) {
+	t.Carryover = 0
+
+	if t.Rollover == nil {
+		t.updateRemaining()
+		return
+	}
+
+	balance := 0.0
+	limit := t.Rollover.Cap.Seconds()
+
+	for i := t.Rollover.days(); i > 0; i-- {
+		day := now.AddDate(0, 0, -i)
+		weekday := t.calendar.Weekday(day)
+		allowed := t.LimitOn(weekday)
+		used := usage[day.Format(time.DateOnly)]
+
+		if includesDay(t.Rollover.To, weekday) {
+			balance -= min(max(used-allowed, 0), balance)
+		}
+
+		if includesDay(t.Rollover.From, weekday) {
+			balance = min(balance+max(allowed-used, 0), limit)
+		}
+	}
+
+	if includesDay(t.Rollover.To, t.calendar.Weekday(now)) {
+		t.Carryover = balance
+	}
+
+	t.updateRemaining()
+}
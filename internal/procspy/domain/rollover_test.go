@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTarget_ApplyRollover testa o tempo não usado levado para o fim de semana
+func TestTarget_ApplyRollover(t *testing.T) {
+	// 10 de janeiro de 2026 é sábado; dias de semana com 1h e fim de semana com 2h
+	saturday := time.Date(2026, 1, 10, 15, 0, 0, 0, time.Local)
+	sunday := saturday.AddDate(0, 0, 1)
+	monday := saturday.AddDate(0, 0, 2)
+
+	newTarget := func() *Target {
+		return &Target{
+			Name:          "games",
+			Limit:         3600,
+			WeekdayLimits: map[int]Duration{0: 7200, 6: 7200},
+			Rollover:      &Rollover{Days: 6, Cap: 3600, From: []int{1, 2, 3, 4, 5}, To: []int{0, 6}},
+		}
+	}
+
+	// Segunda a sexta: 30m, 1h, 50m, 1h10m e nada
+	usage := map[string]float64{
+		"2026-01-05": 1800,
+		"2026-01-06": 3600,
+		"2026-01-07": 3000,
+		"2026-01-08": 4200,
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		usage    map[string]float64
+		expected float64
+	}{
+		{"Sábado recebe o não usado até o teto", saturday, usage, 3600},
+		{"Domingo recebe o que sobrou do sábado", sunday, merge(usage, map[string]float64{"2026-01-10": 7200 + 2400}), 1200},
+		{"Sábado sem uso na semana", saturday, nil, 3600},
+		{"Dia fora de To não recebe", monday, usage, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newTarget()
+			target.ApplyRollover(tt.now, tt.usage)
+
+			if target.Carryover != tt.expected {
+				t.Errorf("Carryover = %.0f, esperado %.0f", target.Carryover, tt.expected)
+			}
+		})
+	}
+}
+
+// TestTarget_ApplyRollover_EveryDay testa a política sem dias de origem e destino
+func TestTarget_ApplyRollover_EveryDay(t *testing.T) {
+	now := time.Date(2026, 1, 10, 15, 0, 0, 0, time.Local)
+	target := &Target{Name: "games", Limit: 3600, Rollover: &Rollover{Days: 3, Cap: 7200}}
+
+	// Sobra 30m, usa 20m a mais e sobra 10m
+	target.ApplyRollover(now, map[string]float64{
+		"2026-01-07": 1800,
+		"2026-01-08": 4800,
+		"2026-01-09": 3000,
+	})
+
+	if target.Carryover != 1200 {
+		t.Errorf("Carryover = %.0f, esperado 1200", target.Carryover)
+	}
+
+	if limit := target.getLimit(); limit != 3600+1200 {
+		t.Errorf("getLimit() = %.0f, esperado 4800 com o tempo acumulado", limit)
+	}
+}
+
+// TestRollover_Validate testa as regras da política de acúmulo
+func TestRollover_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollover *Rollover
+		wantErr  bool
+	}{
+		{"Sem política", nil, false},
+		{"Política válida", &Rollover{Cap: 3600, To: []int{0, 6}}, false},
+		{"Sem teto", &Rollover{To: []int{0, 6}}, true},
+		{"Dias negativos", &Rollover{Days: -1, Cap: 3600}, true},
+		{"Dia da semana inválido", &Rollover{Cap: 3600, From: []int{7}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rollover.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func merge(values ...map[string]float64) map[string]float64 {
+	ret := make(map[string]float64)
+	for _, v := range values {
+		for key, value := range v {
+			ret[key] = value
+		}
+	}
+	return ret
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schedule holds the allowed time windows of a target per weekday (0 is
+// sunday), as "HH:MM-HH:MM" ranges. A window whose end is not after its
+// start crosses midnight and ends on the next day. Weekdays missing from the
+// schedule are not restricted; a weekday with no windows is fully blocked.
+type Schedule map[int][]string
+
+type window struct {
+	start int
+	end   int
+}
+
+func (w window) crossesMidnight() bool {
+	return w.end <= w.start
+}
+
+// parseWindow reads a "HH:MM-HH:MM" range into minutes since midnight.
+func parseWindow(value string) (window, error) {
+	from, to, found := strings.Cut(value, "-")
+
+	if !found {
+		return window{}, fmt.Errorf("invalid window '%s', expected HH:MM-HH:MM", value)
+	}
+
+	start, err := parseClock(from)
+
+	if err != nil || start == 24*60 {
+		return window{}, fmt.Errorf("invalid window start in '%s'", value)
+	}
+
+	end, err := parseClock(to)
+
+	if err != nil {
+		return window{}, fmt.Errorf("invalid window end in '%s'", value)
+	}
+
+	if start == end {
+		return window{}, fmt.Errorf("empty window '%s'", value)
+	}
+
+	return window{start: start, end: end}, nil
+}
+
+// parseClock reads "HH:MM" into minutes since midnight. "24:00" is accepted
+// as the end of the day.
+func parseClock(value string) (int, error) {
+	var hour, minute int
+
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hour, &minute); err != nil {
+		return 0, err
+	}
+
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute > 0) {
+		return 0, fmt.Errorf("invalid time '%s'", value)
+	}
+
+	return hour*60 + minute, nil
+}
+
+// Validate checks the weekdays and windows of the schedule.
+func (s Schedule) Validate() error {
+	for day, windows := range s {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid schedule weekday %d, expected 0 (sunday) to 6 (saturday)", day)
+		}
+
+		for _, value := range windows {
+			if _, err := parseWindow(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Allows reports whether a moment is inside an allowed window. Windows of
+// the previous day that cross midnight also count.
+func (s Schedule) Allows(now time.Time) bool {
+	return s.allows(now, now.Weekday(), now.AddDate(0, 0, -1).Weekday())
+}
+
+// allows checks a moment against the windows of the weekdays whose rules
+// apply today and yesterday, which differ from the real ones on calendar
+// days.
+func (s Schedule) allows(now time.Time, today time.Weekday, yesterday time.Weekday) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	day := int(today)
+	windows, restricted := s[day]
+
+	if !restricted {
+		return true
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+
+	for _, value := range windows {
+		w, err := parseWindow(value)
+
+		if err != nil {
+			continue
+		}
+
+		if w.crossesMidnight() && minute >= w.start {
+			return true
+		}
+
+		if !w.crossesMidnight() && minute >= w.start && minute < w.end {
+			return true
+		}
+	}
+
+	for _, value := range s[int(yesterday)] {
+		w, err := parseWindow(value)
+
+		if err == nil && w.crossesMidnight() && minute < w.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Describe renders the windows of a weekday for reports.
+func (s Schedule) Describe(day int) string {
+	windows, restricted := s[day]
+
+	if !restricted {
+		return ""
+	}
+
+	if len(windows) == 0 {
+		return "blocked"
+	}
+
+	sorted := append([]string{}, windows...)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ", ")
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// at retorna um horário no dia da semana informado (4 de janeiro de 2026 é domingo)
+func at(weekday int, clock string) time.Time {
+	ret, _ := time.ParseInLocation("2006-01-02 15:04", "2026-01-04 "+clock, time.Local)
+	return ret.AddDate(0, 0, weekday)
+}
+
+// TestSchedule_Allows testa as janelas permitidas por dia da semana
+func TestSchedule_Allows(t *testing.T) {
+	schedule := Schedule{
+		1: {"16:00-20:00", "07:00-08:00"},
+		5: {"22:00-02:00"},
+		6: {"10:00-12:00"},
+		0: {},
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected bool
+	}{
+		{"Dentro da janela", at(1, "17:30"), true},
+		{"Segunda janela do dia", at(1, "07:15"), true},
+		{"Fim da janela é exclusivo", at(1, "20:00"), false},
+		{"Fora da janela", at(1, "21:00"), false},
+		{"Dia sem restrição", at(2, "23:00"), true},
+		{"Dia bloqueado", at(0, "12:00"), false},
+		{"Janela que cruza a meia-noite, antes", at(5, "23:00"), true},
+		{"Janela que cruza a meia-noite, depois", at(6, "01:30"), true},
+		{"Após o fim da janela do dia anterior", at(6, "02:00"), false},
+		{"Janela do próprio dia após meia-noite", at(6, "11:00"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.Allows(tt.now); got != tt.expected {
+				t.Errorf("Allows(%s) = %t, esperado %t", tt.now.Format("Mon 15:04"), got, tt.expected)
+			}
+		})
+	}
+
+	if !Schedule(nil).Allows(at(0, "03:00")) {
+		t.Error("Schedule vazio deveria permitir qualquer horário")
+	}
+}
+
+// TestSchedule_Validate testa a validação das janelas
+func TestSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		valid    bool
+	}{
+		{"Janelas válidas", Schedule{1: {"16:00-20:00", "22:00-24:00"}, 5: {"23:00-01:00"}}, true},
+		{"Dia bloqueado", Schedule{0: {}}, true},
+		{"Dia inválido", Schedule{7: {"10:00-11:00"}}, false},
+		{"Formato inválido", Schedule{1: {"16h-20h"}}, false},
+		{"Hora inválida", Schedule{1: {"16:00-25:00"}}, false},
+		{"Minuto inválido", Schedule{1: {"16:60-20:00"}}, false},
+		{"Janela vazia", Schedule{1: {"16:00-16:00"}}, false},
+		{"Início às 24:00", Schedule{1: {"24:00-02:00"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate() erro = %v, esperado válido = %t", err, tt.valid)
+			}
+		})
+	}
+}
+
+// TestSchedule_Describe testa a descrição das janelas para o relatório
+func TestSchedule_Describe(t *testing.T) {
+	schedule := Schedule{1: {"16:00-20:00", "07:00-08:00"}, 0: {}}
+
+	if got := schedule.Describe(1); got != "07:00-08:00, 16:00-20:00" {
+		t.Errorf("Describe(1) = %q", got)
+	}
+
+	if got := schedule.Describe(0); got != "blocked" {
+		t.Errorf("Describe(0) = %q, esperado blocked", got)
+	}
+
+	if got := schedule.Describe(2); got != "" {
+		t.Errorf("Describe(2) = %q, esperado vazio", got)
+	}
+}
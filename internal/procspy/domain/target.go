@@ -1,10 +1,13 @@
 package domain
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
+	"slices"
 	"time"
 )
 
@@ -14,26 +17,57 @@ const DEFAULT_BASE_LIMIT = 60 * 60
 const DEFAULT_WARNING_ON = 0.95
 
 type Target struct {
-	User           string          `json:"user"`
-	Name           string          `json:"name"`
-	Pattern        string          `json:"pattern"`
-	Source         string          `json:"source,omitempty"`
-	Limit          float64         `json:"limit"`
-	Elapsed        float64         `json:"elapsed,omitempty"`
-	Remaining      float64         `json:"remaining"`
-	Ocurrences     int             `json:"ocurrences,omitempty"`
-	FirstMatch     string          `json:"first_match,omitempty"`
-	LastMatch      string          `json:"last_match,omitempty"`
-	Kill           bool            `json:"kill"`
-	LimitCommand   string          `json:"limit_command,omitempty"`
-	CheckCommand   string          `json:"check_command,omitempty"`
-	WarningCommand string          `json:"warning_command,omitempty"`
-	WarningOn      float64         `json:"warning_on,omitempty"`
-	Weekdays       map[int]float64 `json:"weekdays,omitempty"`
-	rgx            *regexp.Regexp
+	ID               int64            `json:"id,omitempty"`
+	User             string           `json:"user"`
+	Name             string           `json:"name"`
+	Pattern          string           `json:"pattern"`
+	Source           string           `json:"source,omitempty"`
+	Limit            Duration         `json:"limit,omitempty"`
+	DailyLimit       float64          `json:"daily_limit"`
+	Elapsed          float64          `json:"elapsed,omitempty"`
+	Remaining        float64          `json:"remaining"`
+	Ocurrences       int              `json:"ocurrences,omitempty"`
+	FirstMatch       string           `json:"first_match,omitempty"`
+	LastMatch        string           `json:"last_match,omitempty"`
+	Kill             bool             `json:"kill"`
+	LimitCommand     string           `json:"limit_command,omitempty"`
+	CheckCommand     string           `json:"check_command,omitempty"`
+	WarningCommand   string           `json:"warning_command,omitempty"`
+	Warning          string           `json:"warning,omitempty"`
+	WarningOn        float64          `json:"warning_on,omitempty"`
+	Weekdays         map[int]float64  `json:"weekdays,omitempty"`
+	WeekdayLimits    map[int]Duration `json:"weekday_limits,omitempty"`
+	Schedule         Schedule         `json:"schedule,omitempty"`
+	Groups           []string         `json:"groups,omitempty"`
+	WeeklyLimit      Duration         `json:"weekly_limit,omitempty"`
+	MonthlyLimit     Duration         `json:"monthly_limit,omitempty"`
+	WeeklyElapsed    float64          `json:"weekly_elapsed,omitempty"`
+	WeeklyRemaining  float64          `json:"weekly_remaining,omitempty"`
+	MonthlyElapsed   float64          `json:"monthly_elapsed,omitempty"`
+	MonthlyRemaining float64          `json:"monthly_remaining,omitempty"`
+	Rollover         *Rollover        `json:"rollover,omitempty"`
+	Carryover        float64          `json:"carryover,omitempty"`
+	Granted          float64          `json:"granted,omitempty"`
+	WeeklyGranted    float64          `json:"weekly_granted,omitempty"`
+	MonthlyGranted   float64          `json:"monthly_granted,omitempty"`
+	Fields           *MatchFields     `json:"fields,omitempty"`
+	Exclude          []string         `json:"exclude,omitempty"`
+	IdleAfter        Duration         `json:"idle_after,omitempty"`
+	rgx              *regexp.Regexp
+	fieldRgx         map[string]*regexp.Regexp
+	excludeRgx       *patternList
+	allowlist        *patternList
+	calendar         Calendar
+	location         *time.Location
 }
 
+// setWeekdays fills the weekday factors that were not set with the
+// defaults. Targets with an explicit limit keep it on those days instead.
 func (t *Target) setWeekdays() {
+	if t.Limit > 0 {
+		return
+	}
+
 	if t.Weekdays == nil {
 		t.Weekdays = map[int]float64{}
 	}
@@ -67,7 +101,14 @@ func (t *Target) ToJson() string {
 }
 
 type TargetList struct {
-	Targets []*Target `json:"targets"`
+	Targets   []*Target    `json:"targets"`
+	Groups    []*Group     `json:"groups,omitempty"`
+	Override  *Override    `json:"override,omitempty"`
+	Calendar  Calendar     `json:"calendar,omitempty"`
+	Timezone  string       `json:"timezone,omitempty"`
+	Allowlist []string     `json:"allowlist,omitempty"`
+	WeekStart time.Weekday `json:"week_start,omitempty"`
+	location  *time.Location
 }
 
 func NewTargetList() *TargetList {
@@ -85,13 +126,121 @@ func TargetListFromJson(jsonString string) (*TargetList, error) {
 	}
 
 	for _, v := range ret.Targets {
-		v.setWeekdays()
-		v.getLimit()
+		v.ApplyDefaults()
+	}
+
+	for _, v := range ret.Groups {
+		v.ApplyDefaults()
 	}
 
+	ret.SetTimezone(ret.Timezone)
+	ret.SetAllowlist(ret.Allowlist)
+	ret.SetCalendar(ret.Calendar)
+
 	return ret, nil
 }
 
+// Group returns a group of the list by name, or nil when it does not exist.
+func (t *TargetList) Group(name string) *Group {
+	for _, v := range t.Groups {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Members returns the names of the targets that belong to a group.
+func (t *TargetList) Members(group string) []string {
+	ret := make([]string, 0)
+
+	for _, v := range t.Targets {
+		for _, name := range v.Groups {
+			if name == group {
+				ret = append(ret, v.Name)
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// ApplyDefaults fills the weekday factors that were not set and computes
+// today's limit.
+func (t *Target) ApplyDefaults() {
+	t.setWeekdays()
+	t.getLimit()
+}
+
+// Validate checks the rules of a target before it is stored.
+func (t *Target) Validate() error {
+	if t.Name == "" {
+		return errors.New("target name is required")
+	}
+
+	if t.Pattern == "" {
+		return errors.New("target pattern is required")
+	}
+
+	if _, err := regexp.Compile(t.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern '%s': %v", t.Pattern, err)
+	}
+
+	for day, factor := range t.Weekdays {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid weekday %d, expected 0 (sunday) to 6 (saturday)", day)
+		}
+
+		if factor < 0 {
+			return fmt.Errorf("invalid factor %f for weekday %d", factor, day)
+		}
+	}
+
+	if t.Limit < 0 {
+		return fmt.Errorf("invalid limit %.0f, expected a positive duration", t.Limit.Seconds())
+	}
+
+	for day, limit := range t.WeekdayLimits {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid weekday %d, expected 0 (sunday) to 6 (saturday)", day)
+		}
+
+		if limit < 0 {
+			return fmt.Errorf("invalid limit %.0f for weekday %d", limit.Seconds(), day)
+		}
+	}
+
+	if t.WeeklyLimit < 0 || t.MonthlyLimit < 0 {
+		return errors.New("invalid quota, expected a positive duration")
+	}
+
+	if t.IdleAfter < 0 {
+		return errors.New("invalid idle_after, expected a positive duration")
+	}
+
+	if err := t.Rollover.Validate(); err != nil {
+		return err
+	}
+
+	if err := t.Fields.Validate(); err != nil {
+		return err
+	}
+
+	if err := validatePatterns("exclude", t.Exclude); err != nil {
+		return err
+	}
+
+	if t.Warning != "" {
+		if _, err := warningThreshold(t.Warning, 0); err != nil {
+			return err
+		}
+	}
+
+	return t.Schedule.Validate()
+}
+
 func (t *TargetList) ToLog() string {
 	ret, err := json.MarshalIndent(t, "", "\t")
 	if err != nil {
@@ -104,19 +253,76 @@ func (t *TargetList) ToLog() string {
 func (t *TargetList) Hash() string {
 	ret := ""
 	for _, v := range t.Targets {
-		ret += fmt.Sprintf("%s %s %s %f %f %t %s %s %s %s", v.User, v.Name, v.Pattern, v.getLimit(), v.getWarningOn(), v.Kill, v.Source, v.CheckCommand, v.WarningCommand, v.LimitCommand)
+		ret += fmt.Sprintf("%s %s %s %f %f %t %s %s %s %s %v %v %v %f %f %v %v %v %f", v.User, v.Name, v.Pattern, v.getLimit(), v.getWarningOn(), v.Kill, v.Source, v.CheckCommand, v.WarningCommand, v.LimitCommand, v.Schedule, v.WeekdayLimits, v.Groups, v.WeeklyLimit, v.MonthlyLimit, v.Rollover, v.Fields, v.Exclude, v.IdleAfter)
+	}
+	for _, v := range t.Groups {
+		ret += fmt.Sprintf(" group %s %f %v", v.Name, v.getLimit(), v.WeekdayLimits)
+	}
+	if t.Override != nil {
+		ret += fmt.Sprintf(" override %s %s", t.Override.Mode, t.Override.Until.Format(time.RFC3339))
+	}
+	if len(t.Calendar) > 0 {
+		ret += " calendar " + t.Calendar.String()
+	}
+	if t.Timezone != "" {
+		ret += " timezone " + t.Timezone
+	}
+	if len(t.Allowlist) > 0 {
+		ret += fmt.Sprintf(" allowlist %v", t.Allowlist)
 	}
 	return ret
 }
 
+// ETag identifies the rules and the elapsed time of the list. Both are what a
+// client uses, so an unchanged ETag means there is nothing to reload.
+func (t *TargetList) ETag() string {
+	data := t.Hash()
+	for _, v := range t.Targets {
+		data += fmt.Sprintf(" %s %f %f %f %f %f", v.Name, v.Elapsed, v.WeeklyElapsed, v.MonthlyElapsed, v.WeeklyGranted, v.MonthlyGranted)
+	}
+	for _, v := range t.Groups {
+		data += fmt.Sprintf(" group %s %f", v.Name, v.Elapsed)
+	}
+	return fmt.Sprintf("\"%x\"", sha256.Sum256([]byte(data)))
+}
+
+// ReusePatterns keeps the compiled patterns of a previous list for targets
+// whose name, pattern and match fields did not change.
+func (t *TargetList) ReusePatterns(previous *TargetList) {
+	if previous == nil {
+		return
+	}
+
+	compiled := make(map[string]*Target, len(previous.Targets))
+
+	for _, v := range previous.Targets {
+		compiled[v.Name] = v
+	}
+
+	for _, v := range t.Targets {
+		if old, ok := compiled[v.Name]; ok && old.Pattern == v.Pattern && old.Fields.String() == v.Fields.String() {
+			v.rgx = old.rgx
+			v.fieldRgx = old.fieldRgx
+		}
+
+		if old, ok := compiled[v.Name]; ok && slices.Equal(old.Exclude, v.Exclude) {
+			v.excludeRgx = old.excludeRgx
+		}
+	}
+}
+
+// Match reports whether a process name matches the pattern of the target
+// and is not excluded from it.
 func (t *Target) Match(value string) bool {
+	return t.ExcludedBy(value) == "" && t.matchPattern(value)
+}
+
+func (t *Target) matchPattern(value string) bool {
 	if t.rgx == nil {
 		t.rgx = regexp.MustCompile(t.Pattern)
 	}
 
-	ret := t.rgx.MatchString(value)
-
-	return ret
+	return t.rgx.MatchString(value)
 }
 
 func (t *Target) AddMatchInfo(info *MatchInfo) {
@@ -124,25 +330,58 @@ func (t *Target) AddMatchInfo(info *MatchInfo) {
 	t.FirstMatch = info.FirstMatch
 	t.LastMatch = info.LastMatch
 	t.Ocurrences = info.Ocurrences
-	t.Remaining = t.getLimit() - t.Elapsed
+	t.updateRemaining()
 }
 
+// AddElapsed adds usage to today, and so to the current week and month.
 func (t *Target) AddElapsed(elapsed float64) {
-	t.Elapsed += elapsed
-	t.Remaining = t.getLimit() - t.Elapsed
+	t.SetElapsed(t.Elapsed + elapsed)
 }
 
+// IdlePause returns how many of the elapsed seconds of a scan are not
+// credited because the user has had no input for idle seconds, longer than
+// the IdleAfter of the target. Targets without IdleAfter never pause.
+func (t *Target) IdlePause(idle float64, elapsed float64) float64 {
+	if t.IdleAfter <= 0 {
+		return 0
+	}
+
+	return max(0, min(elapsed, idle-t.IdleAfter.Seconds()))
+}
+
+// NeedsIdle reports whether any target of the list pauses when the user is
+// idle, so the client only reads the idle time when it is used.
+func (t *TargetList) NeedsIdle() bool {
+	for _, target := range t.Targets {
+		if target.IdleAfter > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetElapsed sets today's usage. The weekly and monthly usage, which include
+// today, follow the difference until they are set again with
+// SetQuotaElapsed, as the client does from its ledger on each reconcile.
 func (t *Target) SetElapsed(elapsed float64) {
+	t.WeeklyElapsed += elapsed - t.Elapsed
+	t.MonthlyElapsed += elapsed - t.Elapsed
 	t.Elapsed = elapsed
-	t.Remaining = t.getLimit() - elapsed
+	t.updateRemaining()
 }
 
 func (t *Target) ResetElapsed() {
 	t.Elapsed = 0
-	t.Remaining = t.getLimit()
+	t.updateRemaining()
 }
 
+// CheckLimit reports whether the daily limit or a quota is used up.
 func (t *Target) CheckLimit() bool {
+	if t.CheckQuota() {
+		return true
+	}
+
 	limit := t.getLimit()
 	if limit == 0 {
 		return false
@@ -151,6 +390,13 @@ func (t *Target) CheckLimit() bool {
 	return t.Elapsed >= limit
 }
 
+// Allowed reports whether the target may run at a given moment according to
+// its schedule in the zone of its list, whatever budget is left.
+func (t *Target) Allowed(now time.Time) bool {
+	now = zoned(now, t.location)
+	return t.Schedule.allows(now, t.calendar.Weekday(now), t.calendar.Weekday(now.AddDate(0, 0, -1)))
+}
+
 func (t *Target) CheckWarning() bool {
 	warn := t.getWarningOn()
 	if warn == 0 {
@@ -160,24 +406,47 @@ func (t *Target) CheckWarning() bool {
 	return t.Elapsed > warn
 }
 
+// getLimit computes today's limit, in seconds, from the limit of the
+// weekday, or of the calendar profile of today, plus the time carried over
+// from previous days and granted.
 func (t *Target) getLimit() float64 {
-	today := int(time.Now().Weekday())
+	t.DailyLimit = t.LimitOn(t.calendar.Weekday(zoned(time.Now(), t.location))) + t.Carryover + t.Granted
 
-	factor, found := t.Weekdays[today]
-
-	if !found {
-		factor = DEFAULT_WEEKDAY_LIMIT
+	if t.Remaining <= 0 {
+		t.Remaining = t.DailyLimit
 	}
 
-	t.Limit = DEFAULT_BASE_LIMIT * factor
-	if t.Remaining <= 0 {
-		t.Remaining = t.Limit
+	return t.DailyLimit
+}
+
+// LimitOn computes the limit of a weekday, in seconds, from the first of:
+// the absolute limit of the weekday, the weekday factor of
+// DEFAULT_BASE_LIMIT, the target limit and the default factor.
+func (t *Target) LimitOn(weekday time.Weekday) float64 {
+	day := int(weekday)
+
+	if limit, found := t.WeekdayLimits[day]; found {
+		return limit.Seconds()
+	} else if factor, found := t.Weekdays[day]; found {
+		return DEFAULT_BASE_LIMIT * factor
+	} else if t.Limit > 0 {
+		return t.Limit.Seconds()
 	}
 
-	return t.Limit
+	return DEFAULT_BASE_LIMIT * DEFAULT_WEEKDAY_LIMIT
 }
 
+// getWarningOn computes when to warn from the warning of the target, or at
+// DEFAULT_WARNING_ON of the limit when it has none.
 func (t *Target) getWarningOn() float64 {
-	t.WarningOn = t.getLimit() * DEFAULT_WARNING_ON
+	limit := t.getLimit()
+	t.WarningOn = limit * DEFAULT_WARNING_ON
+
+	if t.Warning != "" {
+		if threshold, err := warningThreshold(t.Warning, limit); err == nil {
+			t.WarningOn = threshold
+		}
+	}
+
 	return t.WarningOn
 }
@@ -0,0 +1,34 @@
+package domain
+
+const TARGET_SOURCE_UPDATED = "updated"
+const TARGET_SOURCE_NOT_MODIFIED = "not_modified"
+const TARGET_SOURCE_STALE = "stale"
+
+// TargetSource is the cache state of a remote target list. The targets
+// themselves are kept in the database, which is the last known good copy
+// served while the source is down. URL and validators are not exposed as
+// shared links usually carry access keys. Hash is the checksum of the last
+// stored list, so sources without validators are not replaced when unchanged.
+type TargetSource struct {
+	User         string `json:"user"`
+	URL          string `json:"-"`
+	ETag         string `json:"-"`
+	LastModified string `json:"-"`
+	Hash         string `json:"-"`
+	Status       string `json:"status"`
+	LastError    string `json:"-"`
+	CheckedAt    string `json:"checked_at,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+}
+
+func NewTargetSource(user string, url string) *TargetSource {
+	return &TargetSource{
+		User: user,
+		URL:  url,
+	}
+}
+
+// IsStale reports whether the last revalidation failed.
+func (s *TargetSource) IsStale() bool {
+	return s.Status == TARGET_SOURCE_STALE
+}
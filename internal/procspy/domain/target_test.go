@@ -703,6 +703,70 @@ func TestTarget_getLimit_MissingWeekday(t *testing.T) {
 	}
 }
 
+// TestTarget_getLimit_Absolute testa a precedência dos limites absolutos
+// Valida: limite do dia, fator do dia, limite do target e padrão
+func TestTarget_getLimit_Absolute(t *testing.T) {
+	today := int(time.Now().Weekday())
+	factor := DEFAULT_WEEKDAY_LIMIT
+	if today == 0 || today == 6 {
+		factor = DEFAULT_WEEKEND_LIMIT
+	}
+
+	tests := []struct {
+		name     string
+		target   *Target
+		expected float64
+	}{
+		{"Limite do target", &Target{Limit: 2700}, 2700},
+		{"Limite do dia tem precedência", &Target{Limit: 2700, WeekdayLimits: map[int]Duration{today: 600}}, 600},
+		{"Fator do dia tem precedência sobre o limite", &Target{Limit: 2700, Weekdays: map[int]float64{today: 1.0}}, DEFAULT_BASE_LIMIT},
+		{"Limite de outro dia é ignorado", &Target{Limit: 2700, WeekdayLimits: map[int]Duration{(today + 1) % 7: 600}}, 2700},
+		{"Sem limite usa o padrão", &Target{}, DEFAULT_BASE_LIMIT * factor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.target.setWeekdays()
+
+			if limit := tt.target.getLimit(); limit != tt.expected {
+				t.Errorf("getLimit() = %.0f, esperado %.0f", limit, tt.expected)
+			}
+
+			if tt.target.DailyLimit != tt.expected {
+				t.Errorf("DailyLimit = %.0f, esperado %.0f", tt.target.DailyLimit, tt.expected)
+			}
+		})
+	}
+}
+
+// TestTarget_CheckWarning_Threshold testa o aviso configurado no target
+// Valida percentual, tempo antes do limite e o padrão
+func TestTarget_CheckWarning_Threshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		warning  string
+		elapsed  float64
+		expected bool
+	}{
+		{"Percentual atingido", "50%", 1900, true},
+		{"Percentual não atingido", "50%", 1700, false},
+		{"Tempo antes do limite atingido", "5m before", 3400, true},
+		{"Tempo antes do limite não atingido", "5m", 3200, false},
+		{"Padrão atingido", "", 3500, true},
+		{"Padrão não atingido", "", 3300, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &Target{Limit: 3600, Warning: tt.warning, Elapsed: tt.elapsed}
+
+			if result := target.CheckWarning(); result != tt.expected {
+				t.Errorf("CheckWarning() = %v, esperado %v (aviso em %.0f)", result, tt.expected, target.WarningOn)
+			}
+		})
+	}
+}
+
 // TestTargetList_Hash_EmptyList testa Hash com lista vazia
 // Valida que hash de lista vazia é string vazia
 func TestTargetList_Hash_EmptyList(t *testing.T) {
@@ -756,6 +820,45 @@ func TestTargetList_Hash_SameContent(t *testing.T) {
 	}
 }
 
+// TestTargetList_ETag testa a ETag da lista de targets
+// Valida que muda com as regras e com o tempo decorrido, e só com eles
+func TestTargetList_ETag(t *testing.T) {
+	newList := func() *TargetList {
+		return &TargetList{
+			Targets: []*Target{
+				{User: "user1", Name: "games", Pattern: "steam", Weekdays: map[int]float64{0: 1.0}},
+			},
+		}
+	}
+
+	etag := newList().ETag()
+
+	if !strings.HasPrefix(etag, "\"") || !strings.HasSuffix(etag, "\"") {
+		t.Errorf("ETag() = %s, esperado valor entre aspas", etag)
+	}
+
+	same := newList()
+	same.Targets[0].Ocurrences = 3
+
+	if same.ETag() != etag {
+		t.Error("ETag deveria ser igual para as mesmas regras e tempo decorrido")
+	}
+
+	changedRule := newList()
+	changedRule.Targets[0].Pattern = "steam|lutris"
+
+	if changedRule.ETag() == etag {
+		t.Error("ETag deveria mudar quando uma regra muda")
+	}
+
+	changedElapsed := newList()
+	changedElapsed.Targets[0].Elapsed = 60
+
+	if changedElapsed.ETag() == etag {
+		t.Error("ETag deveria mudar quando o tempo decorrido muda")
+	}
+}
+
 // TestTarget_AddElapsed_MultipleAdditions testa múltiplas adições de elapsed
 // Valida acumulação correta ao longo de várias chamadas
 func TestTarget_AddElapsed_MultipleAdditions(t *testing.T) {
@@ -927,3 +1030,62 @@ func TestTarget_CheckLimit_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestTarget_Validate testa as regras de validação de target
+func TestTarget_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  *Target
+		wantErr bool
+	}{
+		{"Target válido", &Target{Name: "games", Pattern: "steam|roblox", Weekdays: map[int]float64{0: 2.0}}, false},
+		{"Sem nome", &Target{Pattern: "steam"}, true},
+		{"Sem pattern", &Target{Name: "games"}, true},
+		{"Pattern inválido", &Target{Name: "games", Pattern: "steam("}, true},
+		{"Dia da semana inválido", &Target{Name: "games", Pattern: "steam", Weekdays: map[int]float64{7: 1.0}}, true},
+		{"Fator negativo", &Target{Name: "games", Pattern: "steam", Weekdays: map[int]float64{1: -1.0}}, true},
+		{"Limite absoluto", &Target{Name: "games", Pattern: "steam", Limit: 2700, WeekdayLimits: map[int]Duration{6: 7200}, Warning: "5m"}, false},
+		{"Limite negativo", &Target{Name: "games", Pattern: "steam", Limit: -1}, true},
+		{"Limite de dia inválido", &Target{Name: "games", Pattern: "steam", WeekdayLimits: map[int]Duration{9: 60}}, true},
+		{"Aviso inválido", &Target{Name: "games", Pattern: "steam", Warning: "soon"}, true},
+		{"Ociosidade negativa", &Target{Name: "games", Pattern: "steam", IdleAfter: -60}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.target.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() erro = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestTarget_IdlePause testa a pausa do tempo depois de N minutos sem uso
+func TestTarget_IdlePause(t *testing.T) {
+	tests := []struct {
+		name      string
+		idleAfter Duration
+		idle      float64
+		expected  float64
+	}{
+		{"Sem pausa configurada", 0, 3600, 0},
+		{"Antes do limite", 300, 200, 0},
+		{"Ocioso durante parte do scan", 300, 320, 20},
+		{"Ocioso durante todo o scan", 300, 900, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &Target{Name: "games", Pattern: "steam", IdleAfter: tt.idleAfter}
+			if got := target.IdlePause(tt.idle, 30); got != tt.expected {
+				t.Errorf("IdlePause() = %.0f, esperado %.0f", got, tt.expected)
+			}
+		})
+	}
+
+	list := &TargetList{Targets: []*Target{{Name: "games"}, {Name: "browsers", IdleAfter: 300}}}
+	if !list.NeedsIdle() {
+		t.Error("NeedsIdle() deveria ser verdadeiro com um target que pausa")
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// LoadTimezone returns the location of an IANA zone name, such as
+// "America/Sao_Paulo". An empty name is the local zone of the process.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %v", name, err)
+	}
+
+	return loc, nil
+}
+
+// zoned returns a moment in a zone, or unchanged when no zone is set.
+func zoned(moment time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		return moment
+	}
+
+	return moment.In(loc)
+}
+
+// SetTimezone makes the days of the targets and groups of the list start at
+// midnight of a zone. An unknown zone is logged and the local zone is used.
+func (t *TargetList) SetTimezone(name string) {
+	loc, err := LoadTimezone(name)
+
+	if err != nil {
+		log.Printf("[domain.TargetList.SetTimezone] Using local time: %v", err)
+		loc = time.Local
+	}
+
+	t.Timezone = name
+	t.location = loc
+
+	for _, target := range t.Targets {
+		target.location = loc
+		target.updateRemaining()
+	}
+
+	for _, group := range t.Groups {
+		group.location = loc
+		group.SetElapsed(group.Elapsed)
+	}
+}
+
+// Location returns the zone of the days of the list.
+func (t *TargetList) Location() *time.Location {
+	if t == nil || t.location == nil {
+		return time.Local
+	}
+
+	return t.location
+}
+
+// Now returns the current moment in the zone of the list.
+func (t *TargetList) Now() time.Time {
+	return time.Now().In(t.Location())
+}
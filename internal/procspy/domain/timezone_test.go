@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTargetList_SetTimezone testa o limite e o horário no dia do fuso do usuário
+func TestTargetList_SetTimezone(t *testing.T) {
+	// Kiritimati (+14) e Pago Pago (-11) estão sempre em dias diferentes
+	for _, name := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+		t.Run(name, func(t *testing.T) {
+			loc, err := LoadTimezone(name)
+			if err != nil {
+				t.Skipf("Fuso indisponível: %v", err)
+			}
+
+			weekday := time.Now().In(loc).Weekday()
+			target := &Target{
+				Name:          "games",
+				Limit:         3600,
+				WeekdayLimits: map[int]Duration{},
+				Schedule:      Schedule{},
+			}
+
+			for day := range 7 {
+				target.WeekdayLimits[day] = Duration((day + 1) * 600)
+				target.Schedule[day] = []string{}
+			}
+
+			target.Schedule[int(weekday)] = []string{"00:00-24:00"}
+
+			list := NewTargetList()
+			list.Targets = append(list.Targets, target)
+			list.SetTimezone(name)
+
+			if expected := float64((int(weekday) + 1) * 600); target.getLimit() != expected {
+				t.Errorf("getLimit() = %f, esperado %f do dia %s", target.getLimit(), expected, weekday)
+			}
+
+			if !target.Allowed(time.Now()) {
+				t.Errorf("Allowed() deveria seguir o horário de %s", weekday)
+			}
+
+			if list.Now().Location().String() != name {
+				t.Errorf("Now() = %s, esperado no fuso %s", list.Now(), name)
+			}
+		})
+	}
+}
+
+// TestTargetList_SetTimezone_Invalid testa o fuso local quando o fuso é inválido
+func TestTargetList_SetTimezone_Invalid(t *testing.T) {
+	list := NewTargetList()
+
+	if list.Location() != time.Local {
+		t.Errorf("Location() sem fuso = %s, esperado local", list.Location())
+	}
+
+	list.SetTimezone("Mars/Olympus_Mons")
+
+	if list.Location() != time.Local {
+		t.Errorf("Location() com fuso inválido = %s, esperado local", list.Location())
+	}
+
+	if _, err := LoadTimezone("Mars/Olympus_Mons"); err == nil {
+		t.Error("LoadTimezone() deveria rejeitar um fuso inexistente")
+	}
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Account struct {
+	service *service.Account
+	users   *service.Users
+}
+
+func NewAccount(accountService *service.Account, usersService *service.Users) *Account {
+	return &Account{
+		service: accountService,
+		users:   usersService,
+	}
+}
+
+// Login opens a session. The token is returned in the body, for API
+// clients, and in an HTTP only cookie, for browsers.
+func (a *Account) Login(ctx *gin.Context) {
+	start := time.Now()
+
+	req := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Account.Login] Invalid login request: %v", err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	token, account, err := a.service.Login(req.Username, req.Password)
+
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		abortUnauthorized(ctx, start)
+		return
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Account.Login] Failed to login account '%s': %v", req.Username, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteStrictMode)
+	ctx.SetCookie(SESSION_COOKIE, token, service.SESSION_TTL, "/", "", ctx.Request.TLS != nil, true)
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"token":     token,
+		"account":   account,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (a *Account) Logout(ctx *gin.Context) {
+	start := time.Now()
+
+	err := a.service.Logout(SessionToken(ctx))
+
+	if err != nil {
+		log.Printf("[handlers.Account.Logout] Failed to close session: %v", err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.SetCookie(SESSION_COOKIE, "", -1, "/", "", ctx.Request.TLS != nil, true)
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"message":   "logged out",
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (a *Account) GetMe(ctx *gin.Context) {
+	start := time.Now()
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"account":   CurrentAccount(ctx),
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (a *Account) CreateAccount(ctx *gin.Context) {
+	start := time.Now()
+
+	req := struct {
+		Username string   `json:"username"`
+		Password string   `json:"password"`
+		Role     string   `json:"role"`
+		Children []string `json:"children"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Account.CreateAccount] Invalid account request: %v", err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	for _, child := range req.Children {
+		if !a.users.Exists(child) {
+			log.Printf("[handlers.Account.CreateAccount] Unknown child '%s' for account '%s'", child, req.Username)
+			ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+				"error":     "user not found: " + child,
+				"elapsed":   time.Since(start).Milliseconds(),
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+			return
+		}
+	}
+
+	account, err := a.service.CreateAccount(req.Username, req.Password, req.Role, req.Children)
+
+	if errors.Is(err, service.ErrInvalidAccount) {
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "username, password (min 8 chars) and role (admin, parent or viewer) are required",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if errors.Is(err, service.ErrAccountExists) {
+		ctx.IndentedJSON(http.StatusConflict, gin.H{
+			"error":     "account already exists",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Account.CreateAccount] Failed to create account '%s': %v", req.Username, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Account.CreateAccount] Account %d '%s' created by '%s'", account.ID, account.Username, accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"account":   account,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (a *Account) GetAccounts(ctx *gin.Context) {
+	start := time.Now()
+
+	accounts, err := a.service.GetAccounts()
+
+	if err != nil {
+		log.Printf("[handlers.Account.GetAccounts] Failed to retrieve accounts: %v", err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"accounts":  accounts,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (a *Account) DeleteAccount(ctx *gin.Context) {
+	start := time.Now()
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		log.Printf("[handlers.Account.DeleteAccount] Invalid account id '%s': %v", ctx.Param("id"), err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid account id",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	found, err := a.service.DeleteAccount(id)
+
+	if err != nil {
+		log.Printf("[handlers.Account.DeleteAccount] Failed to delete account %d: %v", id, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if !found {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "account not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Account.DeleteAccount] Account %d deleted by '%s'", id, accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"message":   "account deleted",
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"testing"
+)
+
+// TestAccount_Login testa login, sessão e logout via HTTP
+func TestAccount_Login(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	accounts := service.NewAccount(conn)
+	accounts.CreateAccount("parent", "password1", "parent", []string{"user1"})
+	handler := NewAccount(accounts, service.NewUsers(newTestServerConfig(), nil))
+
+	router := setupTestRouter()
+	router.POST("/login", handler.Login)
+	api := router.Group("", AccountAuth(accounts, ""))
+	api.GET("/me", handler.GetMe)
+	api.POST("/logout", handler.Logout)
+
+	w := executeRequest(router, makeTestRequest("POST", "/login", `{"username":"parent","password":"wrong"}`))
+	if w.Code != 401 {
+		t.Errorf("Status = %d, esperado 401 para senha errada", w.Code)
+	}
+
+	w = executeRequest(router, makeTestRequest("POST", "/login", `{"username":"parent","password":"password1"}`))
+	if w.Code != 200 {
+		t.Fatalf("Status = %d, esperado 200", w.Code)
+	}
+
+	if len(w.Result().Cookies()) == 0 {
+		t.Error("Login deveria definir o cookie de sessão")
+	}
+
+	resp := struct {
+		Token string `json:"token"`
+	}{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	req := makeTestRequest("GET", "/me", "")
+	req.Header.Set("Authorization", "Bearer "+resp.Token)
+	if w = executeRequest(router, req); w.Code != 200 {
+		t.Errorf("Status = %d, esperado 200", w.Code)
+	}
+
+	req = makeTestRequest("POST", "/logout", "")
+	req.Header.Set("Authorization", "Bearer "+resp.Token)
+	executeRequest(router, req)
+
+	req = makeTestRequest("GET", "/me", "")
+	req.Header.Set("Authorization", "Bearer "+resp.Token)
+	if w = executeRequest(router, req); w.Code != 401 {
+		t.Errorf("Status = %d, esperado 401 após logout", w.Code)
+	}
+}
+
+// TestAccount_CreateAccount testa criação de contas via HTTP
+func TestAccount_CreateAccount(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	accounts := service.NewAccount(conn)
+	handler := NewAccount(accounts, service.NewUsers(newTestServerConfig(), nil))
+
+	router := setupTestRouter()
+	router.POST("/admin/accounts", handler.CreateAccount)
+	router.GET("/admin/accounts", handler.GetAccounts)
+	router.DELETE("/admin/accounts/:id", handler.DeleteAccount)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"Conta válida", `{"username":"parent","password":"password1","role":"parent","children":["user1"]}`, 201},
+		{"Conta duplicada", `{"username":"parent","password":"password1","role":"parent"}`, 409},
+		{"Papel inválido", `{"username":"other","password":"password1","role":"root"}`, 400},
+		{"Senha curta", `{"username":"other","password":"short","role":"viewer"}`, 400},
+		{"Filho inexistente", `{"username":"other","password":"password1","role":"viewer","children":["unknown"]}`, 400},
+		{"JSON inválido", `{invalid`, 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest("POST", "/admin/accounts", tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d", w.Code, tt.expected)
+			}
+		})
+	}
+
+	if w := executeRequest(router, makeTestRequest("GET", "/admin/accounts", "")); w.Code != 200 {
+		t.Errorf("Status = %d, esperado 200", w.Code)
+	}
+
+	if w := executeRequest(router, makeTestRequest("DELETE", "/admin/accounts/1", "")); w.Code != 200 {
+		t.Errorf("Status = %d, esperado 200", w.Code)
+	}
+
+	if w := executeRequest(router, makeTestRequest("DELETE", "/admin/accounts/1", "")); w.Code != 404 {
+		t.Errorf("Status = %d, esperado 404", w.Code)
+	}
+}
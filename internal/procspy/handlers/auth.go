@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const CONTEXT_DEVICE = "device"
+const CONTEXT_ACCOUNT = "account"
+const SESSION_COOKIE = "procspy_session"
+
+// ADMIN_TOKEN_ACCOUNT is the account name used in logs for requests made
+// with the admin token.
+const ADMIN_TOKEN_ACCOUNT = "admin_token"
+
+// DeviceAuth requires a valid, non revoked device token issued for the user
+// in the :user path parameter.
+func DeviceAuth(devices *service.Device) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		user := ctx.Param("user")
+
+		device, err := devices.Authenticate(BearerToken(ctx))
+
+		if err == nil && device.User != user {
+			log.Printf("[handlers.DeviceAuth] [%s] Device %d belongs to user '%s'", user, device.ID, device.User)
+			err = service.ErrInvalidToken
+		}
+
+		if err != nil {
+			log.Printf("[handlers.DeviceAuth] [%s] Device authentication failed for %s: %v", user, ctx.Request.URL.Path, err)
+			abortUnauthorized(ctx, start)
+			return
+		}
+
+		ctx.Set(CONTEXT_DEVICE, device)
+		ctx.Next()
+	}
+}
+
+// AccountAuth requires a logged in account, from a session token sent as a
+// Bearer token or in the session cookie. The admin token from the server
+// configuration, when set, is accepted as an admin account so the first
+// accounts can be created.
+func AccountAuth(accounts *service.Account, adminToken string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		token := SessionToken(ctx)
+
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			ctx.Set(CONTEXT_ACCOUNT, domain.NewAccount(ADMIN_TOKEN_ACCOUNT, domain.ROLE_ADMIN, nil))
+			ctx.Next()
+			return
+		}
+
+		account, err := accounts.Authenticate(token)
+
+		if err != nil {
+			log.Printf("[handlers.AccountAuth] Account authentication failed for %s: %v", ctx.Request.URL.Path, err)
+			abortUnauthorized(ctx, start)
+			return
+		}
+
+		ctx.Set(CONTEXT_ACCOUNT, account)
+		ctx.Next()
+	}
+}
+
+// RequireAdmin only lets admin accounts through. It must run after
+// AccountAuth.
+func RequireAdmin() gin.HandlerFunc {
+	return requireAccount(func(account *domain.Account, ctx *gin.Context) bool {
+		return account.IsAdmin()
+	})
+}
+
+// RequireRead only lets through accounts that can see the user in the :user
+// path parameter. It must run after AccountAuth.
+func RequireRead() gin.HandlerFunc {
+	return requireAccount(func(account *domain.Account, ctx *gin.Context) bool {
+		return account.CanRead(ctx.Param("user"))
+	})
+}
+
+// RequireManage only lets through accounts that can manage the user in the
+// :user path parameter. It must run after AccountAuth.
+func RequireManage() gin.HandlerFunc {
+	return requireAccount(func(account *domain.Account, ctx *gin.Context) bool {
+		return account.CanManage(ctx.Param("user"))
+	})
+}
+
+func requireAccount(allowed func(account *domain.Account, ctx *gin.Context) bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		account := CurrentAccount(ctx)
+
+		if account == nil || !allowed(account, ctx) {
+			log.Printf("[handlers.requireAccount] Access denied to %s for account '%s'", ctx.Request.URL.Path, accountName(account))
+			abortForbidden(ctx, start)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// CurrentAccount returns the account set by AccountAuth, or nil.
+func CurrentAccount(ctx *gin.Context) *domain.Account {
+	value, ok := ctx.Get(CONTEXT_ACCOUNT)
+
+	if !ok {
+		return nil
+	}
+
+	account, _ := value.(*domain.Account)
+
+	return account
+}
+
+// SessionToken returns the Bearer token or, when there is none, the session
+// cookie.
+func SessionToken(ctx *gin.Context) string {
+	if token := BearerToken(ctx); token != "" {
+		return token
+	}
+
+	token, _ := ctx.Cookie(SESSION_COOKIE)
+
+	return token
+}
+
+// BearerToken returns the token of an "Authorization: Bearer <token>" header.
+func BearerToken(ctx *gin.Context) string {
+	header := ctx.GetHeader("Authorization")
+
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+
+	return ""
+}
+
+func abortUnauthorized(ctx *gin.Context, start time.Time) {
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":     "unauthorized",
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func abortForbidden(ctx *gin.Context, start time.Time) {
+	ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":     "forbidden",
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func accountName(account *domain.Account) string {
+	if account == nil {
+		return ""
+	}
+
+	return account.Username
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newDeviceAuthRouter(devices *service.Device) *gin.Engine {
+	router := setupTestRouter()
+	router.GET("/targets/:user", DeviceAuth(devices), func(ctx *gin.Context) {
+		ctx.Status(200)
+	})
+	return router
+}
+
+// TestDeviceAuth testa o middleware de autenticação por dispositivo
+func TestDeviceAuth(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	devices := service.NewDevice(conn)
+	device, _ := devices.IssueDevice("user1", "notebook")
+	router := newDeviceAuthRouter(devices)
+
+	tests := []struct {
+		name     string
+		url      string
+		token    string
+		expected int
+	}{
+		{"Token válido", "/targets/user1", device.Token, 200},
+		{"Sem token", "/targets/user1", "", 401},
+		{"Token inválido", "/targets/user1", "invalid", 401},
+		{"Token de outro usuário", "/targets/user2", device.Token, 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := makeTestRequest("GET", tt.url, "")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := executeRequest(router, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d", w.Code, tt.expected)
+			}
+		})
+	}
+
+	// Token revogado deixa de ser aceito
+	devices.RevokeDevice(device.ID)
+	req := makeTestRequest("GET", "/targets/user1", "")
+	req.Header.Set("Authorization", "Bearer "+device.Token)
+	if w := executeRequest(router, req); w.Code != 401 {
+		t.Errorf("Status = %d, esperado 401 para token revogado", w.Code)
+	}
+}
+
+func newAccountAuthRouter(accounts *service.Account, adminToken string) *gin.Engine {
+	router := setupTestRouter()
+	api := router.Group("", AccountAuth(accounts, adminToken))
+	api.GET("/report/:user", RequireRead(), func(ctx *gin.Context) { ctx.Status(200) })
+	api.POST("/admin/users/:user/devices", RequireManage(), func(ctx *gin.Context) { ctx.Status(200) })
+	api.GET("/admin/accounts", RequireAdmin(), func(ctx *gin.Context) { ctx.Status(200) })
+	return router
+}
+
+// TestAccountAuth testa autenticação e permissões por papel
+func TestAccountAuth(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	accounts := service.NewAccount(conn)
+	accounts.CreateAccount("parent", "password1", domain.ROLE_PARENT, []string{"user1"})
+	accounts.CreateAccount("viewer", "password2", domain.ROLE_VIEWER, []string{"user1"})
+	parent, _, _ := accounts.Login("parent", "password1")
+	viewer, _, _ := accounts.Login("viewer", "password2")
+
+	router := newAccountAuthRouter(accounts, "secret")
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		token    string
+		expected int
+	}{
+		{"Sem token", "GET", "/report/user1", "", 401},
+		{"Sessão inválida", "GET", "/report/user1", "invalid", 401},
+		{"Token do admin", "GET", "/admin/accounts", "secret", 200},
+		{"Pai lê relatório do filho", "GET", "/report/user1", parent, 200},
+		{"Pai não lê relatório de outro usuário", "GET", "/report/user2", parent, 403},
+		{"Pai gerencia dispositivos do filho", "POST", "/admin/users/user1/devices", parent, 200},
+		{"Pai não gerencia contas", "GET", "/admin/accounts", parent, 403},
+		{"Viewer lê relatório do filho", "GET", "/report/user1", viewer, 200},
+		{"Viewer não gerencia dispositivos", "POST", "/admin/users/user1/devices", viewer, 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := makeTestRequest(tt.method, tt.url, "")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := executeRequest(router, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d", w.Code, tt.expected)
+			}
+		})
+	}
+
+	// Sessão também é aceita pelo cookie
+	req := makeTestRequest("GET", "/report/user1", "")
+	req.AddCookie(&http.Cookie{Name: SESSION_COOKIE, Value: parent})
+	if w := executeRequest(router, req); w.Code != 200 {
+		t.Errorf("Status = %d, esperado 200 com cookie de sessão", w.Code)
+	}
+
+	// Sem admin_token configurado, um token vazio não autentica
+	router = newAccountAuthRouter(accounts, "")
+	if w := executeRequest(router, makeTestRequest("GET", "/admin/accounts", "")); w.Code != 401 {
+		t.Errorf("Status = %d, esperado 401", w.Code)
+	}
+}
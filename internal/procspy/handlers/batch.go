@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Batch struct {
+	service *service.Batch
+	users   *service.Users
+}
+
+func NewBatch(batchService *service.Batch, usersService *service.Users) *Batch {
+	return &Batch{
+		service: batchService,
+		users:   usersService,
+	}
+}
+
+func (b *Batch) InsertBatch(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(b.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Batch.InsertBatch] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusUnauthorized, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	body, err := ctx.GetRawData()
+
+	if err != nil {
+		log.Printf("[handlers.Batch.InsertBatch] [%s] Failed to read request body: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	batch, err := domain.BatchFromJson(string(body))
+
+	if err == nil && !validBatch(batch) {
+		err = errNilBatchItem
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Batch.InsertBatch] [%s] Failed to parse batch JSON: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+
+		return
+	}
+
+	result, err := b.service.InsertBatch(user, batch)
+
+	if err != nil {
+		log.Printf("[handlers.Batch.InsertBatch] [%s] Failed to insert batch into database: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Batch.InsertBatch] [%s] Batch inserted: %d matches, %d commands", user, len(result.Matches), len(result.Commands))
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"message":   "batch inserted",
+		"matches":   result.Matches,
+		"commands":  result.Commands,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+var errNilBatchItem = errors.New("batch contains null items")
+
+func validBatch(batch *domain.Batch) bool {
+	for _, match := range batch.Matches {
+		if match == nil {
+			return false
+		}
+	}
+
+	for _, cmd := range batch.Commands {
+		if cmd == nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"testing"
+)
+
+func newTestBatchHandler(conn *storage.DbConnection) *Batch {
+	service.NewMatch(conn)
+	service.NewCommand(conn)
+	batchService := service.NewBatch(conn)
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	usersService := service.NewUsers(cfg, nil)
+
+	return NewBatch(batchService, usersService)
+}
+
+// TestBatch_InsertBatch testa inserção em lote via HTTP
+func TestBatch_InsertBatch(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	handler := newTestBatchHandler(conn)
+
+	gin := setupTestRouter()
+	gin.POST("/batch/:user", handler.InsertBatch)
+
+	body := `{"matches":[{"event_id":"m1","user":"user1","name":"games","pattern":"steam","match":"steam.exe","elapsed":10.5},{"event_id":"m1","user":"user1","name":"games","pattern":"steam","match":"steam.exe","elapsed":10.5}],"commands":[{"event_id":"c1","user":"user1","name":"games","command_line":"echo","source":"Limit"}]}`
+	req := makeTestRequest("POST", "/batch/user1", body)
+	w := executeRequest(gin, req)
+
+	if w.Code != 201 {
+		t.Fatalf("Status = %d, esperado 201", w.Code)
+	}
+
+	result, err := domain.BatchResultFromJson(w.Body.String())
+	if err != nil {
+		t.Fatalf("BatchResultFromJson() erro = %v", err)
+	}
+
+	if result.Matches[0].Status != domain.BATCH_STATUS_INSERTED || result.Matches[1].Status != domain.BATCH_STATUS_DUPLICATE {
+		t.Errorf("Status dos matches = %s, %s", result.Matches[0].Status, result.Matches[1].Status)
+	}
+
+	if result.Commands[0].Status != domain.BATCH_STATUS_INSERTED {
+		t.Errorf("Status do command = %s", result.Commands[0].Status)
+	}
+}
+
+// TestBatch_InsertBatch_InvalidUser testa inserção com usuário inválido
+func TestBatch_InsertBatch_InvalidUser(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	handler := newTestBatchHandler(conn)
+
+	gin := setupTestRouter()
+	gin.POST("/batch/:user", handler.InsertBatch)
+
+	req := makeTestRequest("POST", "/batch/invalid", `{"matches":[],"commands":[]}`)
+	w := executeRequest(gin, req)
+
+	if w.Code != 401 {
+		t.Errorf("Status = %d, esperado 401", w.Code)
+	}
+}
+
+// TestBatch_InsertBatch_InvalidJSON testa inserção com JSON inválido ou itens nulos
+func TestBatch_InsertBatch_InvalidJSON(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	handler := newTestBatchHandler(conn)
+
+	gin := setupTestRouter()
+	gin.POST("/batch/:user", handler.InsertBatch)
+
+	for _, body := range []string{`{invalid json}`, `{"matches":[null]}`} {
+		req := makeTestRequest("POST", "/batch/user1", body)
+		w := executeRequest(gin, req)
+
+		if w.Code != 400 {
+			t.Errorf("Status = %d, esperado 400 para %s", w.Code, body)
+		}
+	}
+}
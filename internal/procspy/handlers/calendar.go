@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCalendar returns the holiday and vacation entries of a user.
+func (t *Target) GetCalendar(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.GetCalendar] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	calendar, err := t.service.GetCalendar(user)
+
+	if err != nil {
+		log.Printf("[handlers.Target.GetCalendar] [%s] Failed to retrieve calendar from service: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"calendar":  calendar,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// ReplaceCalendar replaces all calendar entries of a user.
+func (t *Target) ReplaceCalendar(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ReplaceCalendar] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	req := struct {
+		Calendar domain.Calendar `json:"calendar"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Target.ReplaceCalendar] [%s] Invalid calendar request: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if req.Calendar == nil {
+		req.Calendar = domain.Calendar{}
+	}
+
+	if t.writeError(ctx, start, user, t.service.ReplaceCalendar(user, req.Calendar)) {
+		return
+	}
+
+	log.Printf("[handlers.Target.ReplaceCalendar] [%s] %d calendar entries stored by '%s'", user, len(req.Calendar), accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"calendar":  req.Calendar,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// ImportCalendar appends the events of an iCalendar file sent as the body
+// to the calendar of a user. The profile query parameter sets which weekday
+// rules the imported days follow, weekend by default.
+func (t *Target) ImportCalendar(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ImportCalendar] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	profile := ctx.DefaultQuery("profile", domain.PROFILE_WEEKEND)
+
+	imported, err := t.service.ImportCalendar(user, ctx.Request.Body, profile)
+
+	if t.writeError(ctx, start, user, err) {
+		return
+	}
+
+	log.Printf("[handlers.Target.ImportCalendar] [%s] %d calendar entries imported by '%s'", user, len(imported), accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"calendar":  imported,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -61,6 +61,7 @@ func (c *Command) InsertCommand(ctx *gin.Context) {
 		return
 	}
 
+	cmd.User = user
 	err = c.service.InsertCommand(cmd)
 
 	if err != nil {
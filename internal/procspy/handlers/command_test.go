@@ -14,7 +14,7 @@ func TestNewCommand(t *testing.T) {
 
 	commandService := service.NewCommand(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 
 	handler := NewCommand(commandService, usersService)
 	if handler == nil {
@@ -29,7 +29,7 @@ func TestCommand_InsertCommand(t *testing.T) {
 
 	commandService := service.NewCommand(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 	handler := NewCommand(commandService, usersService)
 
 	gin := setupTestRouter()
@@ -51,7 +51,7 @@ func TestCommand_InsertCommand_InvalidUser(t *testing.T) {
 
 	commandService := service.NewCommand(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 	handler := NewCommand(commandService, usersService)
 
 	gin := setupTestRouter()
@@ -73,7 +73,7 @@ func TestCommand_InsertCommand_InvalidJSON(t *testing.T) {
 
 	commandService := service.NewCommand(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 	handler := NewCommand(commandService, usersService)
 
 	gin := setupTestRouter()
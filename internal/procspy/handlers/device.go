@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Device struct {
+	service *service.Device
+	users   *service.Users
+}
+
+func NewDevice(deviceService *service.Device, usersService *service.Users) *Device {
+	return &Device{
+		service: deviceService,
+		users:   usersService,
+	}
+}
+
+func (d *Device) IssueDevice(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(d.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Device.IssueDevice] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	req := struct {
+		Name string `json:"name"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil || req.Name == "" {
+		log.Printf("[handlers.Device.IssueDevice] [%s] Invalid device request: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json, device name is required",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	device, err := d.service.IssueDevice(user, req.Name)
+
+	if err != nil {
+		log.Printf("[handlers.Device.IssueDevice] [%s] Failed to issue device: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Device.IssueDevice] [%s] Device %d '%s' issued", user, device.ID, device.Name)
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"device":    device,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (d *Device) GetDevices(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(d.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Device.GetDevices] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	devices, err := d.service.GetDevices(user)
+
+	if err != nil {
+		log.Printf("[handlers.Device.GetDevices] [%s] Failed to retrieve devices: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"devices":   devices,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (d *Device) RevokeDevice(ctx *gin.Context) {
+	start := time.Now()
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		log.Printf("[handlers.Device.RevokeDevice] Invalid device id '%s': %v", ctx.Param("id"), err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid device id",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	device, err := d.service.GetDevice(id)
+
+	if err != nil {
+		log.Printf("[handlers.Device.RevokeDevice] Failed to retrieve device %d: %v", id, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if device == nil || device.IsRevoked() {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "device not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if account := CurrentAccount(ctx); account == nil || !account.CanManage(device.User) {
+		log.Printf("[handlers.Device.RevokeDevice] [%s] Account '%s' cannot revoke device %d", device.User, accountName(account), id)
+		abortForbidden(ctx, start)
+		return
+	}
+
+	found, err := d.service.RevokeDevice(id)
+
+	if err != nil {
+		log.Printf("[handlers.Device.RevokeDevice] Failed to revoke device %d: %v", id, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if !found {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "device not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Device.RevokeDevice] Device %d revoked", id)
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"message":   "device revoked",
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDevice_IssueDevice testa emissão de dispositivo via HTTP
+func TestDevice_IssueDevice(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	devices := service.NewDevice(conn)
+	users := service.NewUsers(newTestServerConfig(), nil)
+	handler := NewDevice(devices, users)
+
+	router := setupTestRouter()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set(CONTEXT_ACCOUNT, domain.NewAccount("parent", domain.ROLE_PARENT, []string{"user1"}))
+	})
+	router.POST("/admin/users/:user/devices", handler.IssueDevice)
+	router.GET("/admin/users/:user/devices", handler.GetDevices)
+	router.DELETE("/admin/devices/:id", handler.RevokeDevice)
+
+	w := executeRequest(router, makeTestRequest("POST", "/admin/users/user1/devices", `{"name":"notebook"}`))
+	if w.Code != 201 {
+		t.Fatalf("Status = %d, esperado 201", w.Code)
+	}
+
+	w = executeRequest(router, makeTestRequest("POST", "/admin/users/user1/devices", `{}`))
+	if w.Code != 400 {
+		t.Errorf("Status = %d, esperado 400 sem nome", w.Code)
+	}
+
+	w = executeRequest(router, makeTestRequest("POST", "/admin/users/unknown/devices", `{"name":"pc"}`))
+	if w.Code != 404 {
+		t.Errorf("Status = %d, esperado 404 para usuário inexistente", w.Code)
+	}
+
+	w = executeRequest(router, makeTestRequest("GET", "/admin/users/user1/devices", ""))
+	if w.Code != 200 {
+		t.Errorf("Status = %d, esperado 200", w.Code)
+	}
+
+	// Dispositivo de um usuário que o pai não gerencia
+	other, _ := devices.IssueDevice("user2", "pc")
+	w = executeRequest(router, makeTestRequest("DELETE", fmt.Sprintf("/admin/devices/%d", other.ID), ""))
+	if w.Code != 403 {
+		t.Errorf("Status = %d, esperado 403 para dispositivo de outro usuário", w.Code)
+	}
+
+	w = executeRequest(router, makeTestRequest("DELETE", "/admin/devices/1", ""))
+	if w.Code != 200 {
+		t.Errorf("Status = %d, esperado 200", w.Code)
+	}
+
+	w = executeRequest(router, makeTestRequest("DELETE", "/admin/devices/1", ""))
+	if w.Code != 404 {
+		t.Errorf("Status = %d, esperado 404 para dispositivo já revogado", w.Code)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EVENTS_HEARTBEAT is how often an idle stream sends a comment, so proxies
+// keep the connection open and clients notice a dead server.
+var EVENTS_HEARTBEAT = 30 * time.Second
+
+type Events struct {
+	events  *service.Events
+	targets *service.Target
+	matches *service.Match
+	users   *service.Users
+}
+
+func NewEvents(events *service.Events, targetService *service.Target, matchService *service.Match, usersService *service.Users) *Events {
+	return &Events{
+		events:  events,
+		targets: targetService,
+		matches: matchService,
+		users:   usersService,
+	}
+}
+
+// Stream pushes the events of a user as Server-Sent Events until the client
+// disconnects. The current target list is sent first, so a client that
+// reconnects is up to date right away.
+func (e *Events) Stream(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(e.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Events.Stream] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusUnauthorized, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ch, unsubscribe := e.events.Subscribe(user)
+	defer unsubscribe()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	e.write(ctx, domain.NewEvent(domain.EVENT_TARGETS, user))
+
+	heartbeat := time.NewTicker(EVENTS_HEARTBEAT)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			log.Printf("[handlers.Events.Stream] [%s] Client disconnected after %s", user, time.Since(start).Round(time.Second))
+			return
+		case event := <-ch:
+			e.write(ctx, event)
+		case <-heartbeat.C:
+			ctx.Writer.WriteString(": heartbeat\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// write sends one event. Target list events are filled with the current
+// list of the user when sent, so a burst of usage updates never delivers an
+// outdated list.
+func (e *Events) write(ctx *gin.Context, event *domain.Event) {
+	if event.HasTargets() {
+		targets, err := targetsWithUsage(e.targets, e.matches, event.User)
+
+		if err != nil {
+			log.Printf("[handlers.Events.write] [%s] Failed to retrieve targets for '%s' event: %v", event.User, event.Type, err)
+			return
+		}
+
+		filled := *event
+		filled.Targets = targets.Targets
+		filled.Groups = targets.Groups
+		filled.Override = targets.Override
+		filled.Calendar = targets.Calendar
+		filled.Timezone = targets.Timezone
+		filled.Allowlist = targets.Allowlist
+		filled.WeekStart = targets.WeekStart
+		filled.ETag = targets.ETag()
+		event = &filled
+	}
+
+	ctx.SSEvent(event.Type, event)
+	ctx.Writer.Flush()
+}
+
+// PostAction pushes an action, such as killing the processes of a target
+// now, to the connected clients of a user.
+func (e *Events) PostAction(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(e.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Events.PostAction] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	req := struct {
+		Action string `json:"action"`
+		Target string `json:"target"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil || !domain.ValidAction(req.Action) {
+		log.Printf("[handlers.Events.PostAction] [%s] Invalid action request: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "action must be kill or refresh",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	delivered := e.events.Publish(domain.NewAction(user, req.Action, req.Target))
+
+	log.Printf("[handlers.Events.PostAction] [%s] Action '%s' on '%s' sent by '%s' to %d clients", user, req.Action, req.Target, accountName(CurrentAccount(ctx)), delivered)
+
+	ctx.IndentedJSON(http.StatusAccepted, gin.H{
+		"action":    req.Action,
+		"target":    req.Target,
+		"delivered": delivered,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
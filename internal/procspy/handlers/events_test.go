@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"strings"
+	"testing"
+)
+
+func newTestEvents(t *testing.T) (*Events, *service.Events, *service.Target) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	t.Cleanup(func() { conn.Close() })
+
+	events := service.NewEvents()
+	targetService := service.NewTarget(cfg, conn)
+	targetService.SetEvents(events)
+
+	handler := NewEvents(events, targetService, service.NewMatch(conn), service.NewUsers(cfg, targetService))
+
+	return handler, events, targetService
+}
+
+// readEvent lê o próximo evento SSE do stream
+func readEvent(t *testing.T, reader *bufio.Reader) (string, *domain.Event) {
+	name := ""
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Erro lendo stream: %v", err)
+		}
+
+		line = strings.TrimRight(line, "\n")
+
+		if strings.HasPrefix(line, "event:") {
+			name = line[len("event:"):]
+		}
+
+		if strings.HasPrefix(line, "data:") {
+			event, err := domain.EventFromJson(line[len("data:"):])
+			if err != nil {
+				t.Fatalf("Evento inválido: %v", err)
+			}
+			return name, event
+		}
+	}
+}
+
+// TestEvents_Stream testa o envio de eventos por Server-Sent Events
+func TestEvents_Stream(t *testing.T) {
+	handler, events, targetService := newTestEvents(t)
+
+	if err := targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam"}); err != nil {
+		t.Fatalf("CreateTarget() erro = %v", err)
+	}
+
+	router := setupTestRouter()
+	router.GET("/events/:user", handler.Stream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events/user1")
+	if err != nil {
+		t.Fatalf("Erro conectando ao stream: %v", err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Content-Type = %s, esperado text/event-stream", ct)
+	}
+
+	reader := bufio.NewReader(res.Body)
+
+	// Lista atual enviada na conexão
+	name, event := readEvent(t, reader)
+	if name != domain.EVENT_TARGETS || len(event.Targets) != 1 || event.ETag == "" {
+		t.Fatalf("Primeiro evento = %s %+v, esperado targets com 1 target e ETag", name, event)
+	}
+
+	// Alteração de regra enviada com a lista atualizada
+	if err := targetService.CreateTarget("user1", &domain.Target{Name: "videos", Pattern: "vlc"}); err != nil {
+		t.Fatalf("CreateTarget() erro = %v", err)
+	}
+
+	name, event = readEvent(t, reader)
+	if name != domain.EVENT_TARGETS || len(event.Targets) != 2 {
+		t.Errorf("Evento = %s com %d targets, esperado targets com 2", name, len(event.Targets))
+	}
+
+	events.Publish(domain.NewAction("user1", domain.ACTION_KILL, "games"))
+
+	name, event = readEvent(t, reader)
+	if name != domain.EVENT_ACTION || event.Action != domain.ACTION_KILL || event.Target != "games" {
+		t.Errorf("Evento = %s %+v, esperado ação kill de games", name, event)
+	}
+}
+
+// TestEvents_PostAction testa o envio de ações aos clientes conectados
+func TestEvents_PostAction(t *testing.T) {
+	handler, events, _ := newTestEvents(t)
+
+	ch, unsubscribe := events.Subscribe("user1")
+	defer unsubscribe()
+
+	router := setupTestRouter()
+	router.POST("/admin/users/:user/actions", handler.PostAction)
+
+	tests := []struct {
+		name     string
+		url      string
+		body     string
+		expected int
+	}{
+		{"Kill", "/admin/users/user1/actions", `{"action":"kill","target":"games"}`, 202},
+		{"Refresh", "/admin/users/user1/actions", `{"action":"refresh"}`, 202},
+		{"Ação inválida", "/admin/users/user1/actions", `{"action":"shutdown"}`, 400},
+		{"JSON inválido", "/admin/users/user1/actions", `{invalid`, 400},
+		{"Usuário inexistente", "/admin/users/unknown/actions", `{"action":"kill"}`, 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest("POST", tt.url, tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	if len(ch) != 2 {
+		t.Errorf("Eventos entregues = %d, esperado 2", len(ch))
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Extension struct {
+	service *service.Extension
+	users   *service.Users
+}
+
+func NewExtension(extensionService *service.Extension, usersService *service.Users) *Extension {
+	return &Extension{
+		service: extensionService,
+		users:   usersService,
+	}
+}
+
+// RequestExtension queues a request for more time sent by a client.
+func (e *Extension) RequestExtension(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(e.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Extension.RequestExtension] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusUnauthorized, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	body, err := ctx.GetRawData()
+
+	if err != nil {
+		log.Printf("[handlers.Extension.RequestExtension] [%s] Failed to read request body: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	extension, err := domain.ExtensionFromJson(string(body))
+
+	if err != nil {
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if e.writeError(ctx, start, user, e.service.RequestExtension(user, extension)) {
+		return
+	}
+
+	log.Printf("[handlers.Extension.RequestExtension] [%s] Extension request queued: %s", user, extension.ToLog())
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"extension": extension,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// GetExtensions lists the requests of a user, optionally filtered by the
+// status query parameter.
+func (e *Extension) GetExtensions(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(e.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Extension.GetExtensions] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	extensions, err := e.service.GetExtensions(user, ctx.Query("status"))
+
+	if err != nil {
+		log.Printf("[handlers.Extension.GetExtensions] [%s] Failed to retrieve extensions from service: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"extensions": extensions,
+		"elapsed":    time.Since(start).Milliseconds(),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// ApproveExtension grants the time of a pending request. The body may set
+// the duration, the expiry and a note; all are optional.
+func (e *Extension) ApproveExtension(ctx *gin.Context) {
+	e.decide(ctx, "ApproveExtension", true)
+}
+
+// DenyExtension refuses a pending request. The body may carry a note.
+func (e *Extension) DenyExtension(ctx *gin.Context) {
+	e.decide(ctx, "DenyExtension", false)
+}
+
+func (e *Extension) decide(ctx *gin.Context, method string, approve bool) {
+	start := time.Now()
+	user, err := ValidateUser(e.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Extension.%s] [%s] User validation failed: %v", method, user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		log.Printf("[handlers.Extension.%s] [%s] Invalid extension id '%s': %v", method, user, ctx.Param("id"), err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid extension id",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	req := struct {
+		Duration  domain.Duration `json:"duration"`
+		ExpiresAt time.Time       `json:"expires_at"`
+		Note      string          `json:"note"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil && len(body) > 0 {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Extension.%s] [%s] Invalid decision request: %v", method, user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	by := accountName(CurrentAccount(ctx))
+
+	var extension *domain.Extension
+
+	if approve {
+		extension, err = e.service.ApproveExtension(user, id, req.Duration, req.ExpiresAt, by, req.Note)
+	} else {
+		extension, err = e.service.DenyExtension(user, id, by, req.Note)
+	}
+
+	if e.writeError(ctx, start, user, err) {
+		return
+	}
+
+	if extension == nil {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "extension request not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"extension": extension,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// writeError answers a failed request with the status matching the error.
+// It returns false when there is no error.
+func (e *Extension) writeError(ctx *gin.Context, start time.Time, user string, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrInvalidExtension), errors.Is(err, service.ErrInvalidGrant):
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     err.Error(),
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	case errors.Is(err, service.ErrExtensionDecided):
+		ctx.IndentedJSON(http.StatusConflict, gin.H{
+			"error":     err.Error(),
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	default:
+		log.Printf("[handlers.Extension.writeError] [%s] Failed to handle extension request: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"strings"
+	"testing"
+)
+
+// TestExtension_Flow testa o pedido de tempo, a aprovação como concessão e o registro no log de comandos
+func TestExtension_Flow(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	commandService := service.NewCommand(conn)
+	users := service.NewUsers(cfg, targetService)
+	handler := NewExtension(service.NewExtension(conn, targetService, commandService), users)
+	targets := NewTarget(targetService, users, service.NewMatch(conn))
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam", Limit: 3600})
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", targets.GetTargets)
+	router.POST("/extensions/:user", handler.RequestExtension)
+	router.GET("/admin/users/:user/extensions", handler.GetExtensions)
+	router.POST("/admin/users/:user/extensions/:id/approve", handler.ApproveExtension)
+	router.POST("/admin/users/:user/extensions/:id/deny", handler.DenyExtension)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		expected int
+	}{
+		{"Pedir 15 minutos", "POST", "/extensions/user1", `{"target":"games","duration":"15m","reason":"terminar a partida"}`, 201},
+		{"Pedir sem duração", "POST", "/extensions/user1", `{"target":"games"}`, 201},
+		{"Target inexistente", "POST", "/extensions/user1", `{"target":"videos"}`, 400},
+		{"Usuário inexistente", "POST", "/extensions/unknown", `{"target":"games"}`, 401},
+		{"Aprovar com 30 minutos", "POST", "/admin/users/user1/extensions/1/approve", `{"duration":"30m","note":"ok"}`, 200},
+		{"Aprovar de novo", "POST", "/admin/users/user1/extensions/1/approve", "", 409},
+		{"Negar pedido aprovado", "POST", "/admin/users/user1/extensions/1/deny", "", 409},
+		{"Negar pedido", "POST", "/admin/users/user1/extensions/2/deny", `{"note":"já é tarde"}`, 200},
+		{"Pedido inexistente", "POST", "/admin/users/user1/extensions/9/approve", "", 404},
+		{"ID inválido", "POST", "/admin/users/user1/extensions/abc/deny", "", 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest(tt.method, tt.url, tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	w := executeRequest(router, makeTestRequest("GET", "/admin/users/user1/extensions?status=approved", ""))
+
+	list := struct {
+		Extensions []*domain.Extension `json:"extensions"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Extensions) != 1 || list.Extensions[0].GrantID == 0 {
+		t.Fatalf("Pedidos aprovados = %s, esperado um com concessão", w.Body.String())
+	}
+
+	// A aprovação vira concessão com a duração da aprovação
+	w = executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Targets []*domain.Target `json:"targets"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Targets) != 1 || res.Targets[0].Granted != 1800 {
+		t.Errorf("Targets = %s, esperado 1800s concedidos", w.Body.String())
+	}
+
+	commands, _ := commandService.GetCommands("user1")
+
+	logged := make([]string, 0)
+	for _, cmd := range commands {
+		if cmd.Source == service.EXTENSION_COMMAND_SOURCE {
+			logged = append(logged, cmd.CommandLine)
+		}
+	}
+
+	if len(logged) != 4 {
+		t.Fatalf("Log de comandos = %v, esperado 2 pedidos e 2 decisões", logged)
+	}
+
+	for _, expected := range []string{"extension request 1 for 15m0s", "extension request 1 approved: 30m0s granted", "extension request 2 denied"} {
+		if !strings.Contains(strings.Join(logged, "\n"), expected) {
+			t.Errorf("Log de comandos deveria conter %q: %v", expected, logged)
+		}
+	}
+}
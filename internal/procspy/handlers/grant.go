@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListGrants returns the grants of a user that did not expire before today.
+func (t *Target) ListGrants(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ListGrants] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	grants, err := t.service.GetGrants(user)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ListGrants] [%s] Failed to retrieve grants from service: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"grants":    grants,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// CreateGrant gives extra time to a target of a user.
+func (t *Target) CreateGrant(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.CreateGrant] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	grant := &domain.Grant{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, grant)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Target.CreateGrant] [%s] Invalid grant request: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	grant.CreatedBy = accountName(CurrentAccount(ctx))
+
+	if t.writeError(ctx, start, user, t.service.AddGrant(user, grant)) {
+		return
+	}
+
+	log.Printf("[handlers.Target.CreateGrant] [%s] Grant %d created by '%s': %s", user, grant.ID, grant.CreatedBy, grant.ToLog())
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"grant":     grant,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// RevokeGrant cancels a grant of a user before it expires.
+func (t *Target) RevokeGrant(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.RevokeGrant] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		log.Printf("[handlers.Target.RevokeGrant] [%s] Invalid grant id '%s': %v", user, ctx.Param("id"), err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid grant id",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	found, err := t.service.RevokeGrant(user, id)
+
+	if err != nil {
+		log.Printf("[handlers.Target.RevokeGrant] [%s] Failed to revoke grant %d: %v", user, id, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if !found {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "grant not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Target.RevokeGrant] [%s] Grant %d revoked by '%s'", user, id, accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"message":   "grant revoked",
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
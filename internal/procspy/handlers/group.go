@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListGroups returns the budget groups of a user, without usage data.
+func (t *Target) ListGroups(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ListGroups] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	groups, err := t.service.GetGroups(user)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ListGroups] [%s] Failed to retrieve groups from service: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"groups":    groups,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// ReplaceGroups replaces all budget groups of a user.
+func (t *Target) ReplaceGroups(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ReplaceGroups] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	req := struct {
+		Groups []*domain.Group `json:"groups"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Target.ReplaceGroups] [%s] Invalid groups request: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if t.writeError(ctx, start, user, t.service.ReplaceGroups(user, req.Groups)) {
+		return
+	}
+
+	log.Printf("[handlers.Target.ReplaceGroups] [%s] %d groups stored by '%s'", user, len(req.Groups), accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"groups":    req.Groups,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"log"
 	"net/http"
+	"procspy/internal/procspy/service"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,18 +11,76 @@ import (
 
 type Healthcheck struct {
 	startTime time.Time
+	targets   *service.Target
 }
 
-func NewHealthcheck() *Healthcheck {
+func NewHealthcheck(targetService *service.Target) *Healthcheck {
 	return &Healthcheck{
 		startTime: time.Now(),
+		targets:   targetService,
 	}
 }
 
 func (h *Healthcheck) GetStatus(ctx *gin.Context) {
 	log.Printf("[handlers.Healthcheck.GetStatus] Health check passed - server is running")
-	ctx.IndentedJSON(http.StatusOK, gin.H{
+
+	ret := gin.H{
 		"elapsed":   time.Since(h.startTime).Milliseconds(),
 		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	if h.targets != nil {
+		ret["targets_cache"] = h.targetsCache()
+	}
+
+	ctx.IndentedJSON(http.StatusOK, ret)
+}
+
+// GetSources lists the cache state of each remote target list. Unlike the
+// healthcheck summary it names the users, so it is served to admins only.
+func (h *Healthcheck) GetSources(ctx *gin.Context) {
+	start := time.Now()
+
+	sources, err := h.targets.GetSources()
+
+	if err != nil {
+		log.Printf("[handlers.Healthcheck.GetSources] Failed to retrieve target sources: %v", err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"sources":   sources,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
 	})
 }
+
+// targetsCache summarizes the remote target lists. Stale sources are being
+// served from the last known good copy. Only counts are returned, as the
+// healthcheck is not authenticated.
+func (h *Healthcheck) targetsCache() gin.H {
+	sources, err := h.targets.GetSources()
+
+	if err != nil {
+		log.Printf("[handlers.Healthcheck.targetsCache] Failed to retrieve targets cache status: %v", err)
+		return gin.H{"error": "unavailable"}
+	}
+
+	stale := 0
+
+	for _, source := range sources {
+		if source.IsStale() {
+			stale++
+		}
+	}
+
+	return gin.H{
+		"total": len(sources),
+		"stale": stale,
+	}
+}
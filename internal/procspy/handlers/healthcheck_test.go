@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -10,7 +15,7 @@ import (
 
 // TestNewHealthcheck testa criação de handler de healthcheck
 func TestNewHealthcheck(t *testing.T) {
-	handler := NewHealthcheck()
+	handler := NewHealthcheck(nil)
 	if handler == nil {
 		t.Fatal("NewHealthcheck retornou nil")
 	}
@@ -23,7 +28,7 @@ func TestNewHealthcheck(t *testing.T) {
 // TestHealthcheck_GetStatus testa endpoint de healthcheck
 func TestHealthcheck_GetStatus(t *testing.T) {
 	// Arrange: Cria handler e router
-	handler := NewHealthcheck()
+	handler := NewHealthcheck(nil)
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	router.GET("/healthcheck", handler.GetStatus)
@@ -43,3 +48,70 @@ func TestHealthcheck_GetStatus(t *testing.T) {
 		t.Error("Content-Type deveria ser application/json")
 	}
 }
+
+// TestHealthcheck_GetStatus_TargetsCache testa status do cache de targets no healthcheck
+func TestHealthcheck_GetStatus_TargetsCache(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	source := domain.NewTargetSource("user1", "http://example.com/user1.json")
+	source.Status = domain.TARGET_SOURCE_STALE
+	storage.NewTarget(conn).SaveSource(source)
+
+	handler := NewHealthcheck(service.NewTarget(newTestServerConfig(), conn))
+	router := setupTestRouter()
+	router.GET("/healthcheck", handler.GetStatus)
+
+	w := executeRequest(router, makeTestRequest("GET", "/healthcheck", ""))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Status = %d, esperado %d", w.Code, http.StatusOK)
+	}
+
+	res := struct {
+		TargetsCache map[string]any `json:"targets_cache"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Resposta inválida: %v, %s", err, w.Body.String())
+	}
+
+	if res.TargetsCache["total"] != 1.0 || res.TargetsCache["stale"] != 1.0 {
+		t.Errorf("targets_cache = %v, esperado total 1 e stale 1", res.TargetsCache)
+	}
+
+	// O healthcheck não é autenticado: não pode expor os usuários
+	if strings.Contains(w.Body.String(), "user1") || strings.Contains(w.Body.String(), "sources") {
+		t.Errorf("Healthcheck não deveria expor as fontes: %s", w.Body.String())
+	}
+}
+
+// TestHealthcheck_GetSources testa a lista de fontes de targets servida aos admins
+func TestHealthcheck_GetSources(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage.NewTarget(conn).SaveSource(domain.NewTargetSource("user1", "http://example.com/user1.json"))
+
+	handler := NewHealthcheck(service.NewTarget(newTestServerConfig(), conn))
+	router := setupTestRouter()
+	router.GET("/admin/sources", handler.GetSources)
+
+	w := executeRequest(router, makeTestRequest("GET", "/admin/sources", ""))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Status = %d, esperado %d", w.Code, http.StatusOK)
+	}
+
+	res := struct {
+		Sources []*domain.TargetSource `json:"sources"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Sources) != 1 || res.Sources[0].User != "user1" {
+		t.Errorf("Sources = %+v, esperado user1: %v, %s", res.Sources, err, w.Body.String())
+	}
+
+	if strings.Contains(w.Body.String(), "example.com") {
+		t.Errorf("URL da fonte não deveria ser exposta: %s", w.Body.String())
+	}
+}
@@ -61,6 +61,7 @@ func (m *Match) InsertMatch(ctx *gin.Context) {
 		return
 	}
 
+	match.User = user
 	err = m.service.InsertMatch(match)
 
 	if err != nil {
@@ -14,7 +14,7 @@ func TestNewMatch(t *testing.T) {
 
 	matchService := service.NewMatch(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 
 	handler := NewMatch(matchService, usersService)
 	if handler == nil {
@@ -29,7 +29,7 @@ func TestMatch_InsertMatch(t *testing.T) {
 
 	matchService := service.NewMatch(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 	handler := NewMatch(matchService, usersService)
 
 	gin := setupTestRouter()
@@ -51,7 +51,7 @@ func TestMatch_InsertMatch_InvalidUser(t *testing.T) {
 
 	matchService := service.NewMatch(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 	handler := NewMatch(matchService, usersService)
 
 	gin := setupTestRouter()
@@ -73,7 +73,7 @@ func TestMatch_InsertMatch_InvalidJSON(t *testing.T) {
 
 	matchService := service.NewMatch(conn)
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	usersService := service.NewUsers(cfg)
+	usersService := service.NewUsers(cfg, nil)
 	handler := NewMatch(matchService, usersService)
 
 	gin := setupTestRouter()
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOverride returns the mode of a user in effect now.
+func (t *Target) GetOverride(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.GetOverride] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	override, err := t.service.GetOverride(user)
+
+	if err != nil {
+		log.Printf("[handlers.Target.GetOverride] [%s] Failed to retrieve override from service: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if override == nil {
+		override = &domain.Override{User: user, Mode: domain.OVERRIDE_NORMAL}
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"override":  override,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// SetOverride locks a user, lifts its limits or brings it back to normal.
+func (t *Target) SetOverride(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.SetOverride] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	override := &domain.Override{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, override)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.Target.SetOverride] [%s] Invalid override request: %v", user, err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	override.CreatedBy = accountName(CurrentAccount(ctx))
+
+	if t.writeError(ctx, start, user, t.service.SetOverride(user, override)) {
+		return
+	}
+
+	log.Printf("[handlers.Target.SetOverride] [%s] Mode set to %s by '%s'", user, override.Describe(), override.CreatedBy)
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"override":  override,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"html"
 	"log"
 	"net/http"
+	"procspy/internal/procspy/domain"
 	"procspy/internal/procspy/service"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -41,10 +43,10 @@ func (r *Report) GetReport(ctx *gin.Context) {
 		return
 	}
 
-	targets, err := r.service.GetTargets(user)
+	targets, err := targetsWithUsage(r.service, r.matches, user)
 
 	if err != nil {
-		log.Printf("[handlers.Report.GetReport] [%s] Failed to retrieve targets from service: %v", user, err)
+		log.Printf("[handlers.Report.GetReport] [%s] Failed to retrieve targets with usage: %v", user, err)
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error":     "internal error",
 			"elapsed":   time.Since(start).Milliseconds(),
@@ -53,10 +55,10 @@ func (r *Report) GetReport(ctx *gin.Context) {
 		return
 	}
 
-	matches, err := r.matches.GetMatchesInfo(user)
+	commands, err := r.commands.GetCommands(user)
 
 	if err != nil {
-		log.Printf("[handlers.Report.GetReport] [%s] Failed to retrieve match information: %v", user, err)
+		log.Printf("[handlers.Report.GetReport] [%s] Failed to retrieve commands from service: %v", user, err)
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error":     "internal error",
 			"elapsed":   time.Since(start).Milliseconds(),
@@ -65,10 +67,10 @@ func (r *Report) GetReport(ctx *gin.Context) {
 		return
 	}
 
-	commands, err := r.commands.GetCommands(user)
+	grants, err := r.service.GetGrants(user)
 
 	if err != nil {
-		log.Printf("[handlers.Report.GetReport] [%s] Failed to retrieve commands from service: %v", user, err)
+		log.Printf("[handlers.Report.GetReport] [%s] Failed to retrieve grants from service: %v", user, err)
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error":     "internal error",
 			"elapsed":   time.Since(start).Milliseconds(),
@@ -77,11 +79,6 @@ func (r *Report) GetReport(ctx *gin.Context) {
 		return
 	}
 
-	for _, target := range targets.Targets {
-		if info, ok := matches[target.Name]; ok {
-			target.AddMatchInfo(info)
-		}
-	}
 	htmlContent := ` 		
 <html>
 <head>
@@ -105,9 +102,11 @@ tr:nth-child(even) {
 </head>
 <body>
 <h1 font-family: monospace;>Procspy Report: ` + user + `</h1>
+<p>Mode: ` + html.EscapeString(targets.Override.Describe()) + `</p>
+` + formatCalendarDay(targets.Calendar, targets.Now()) + `
 <h2>Targets</h2>
 <table>
-<tr><th>Name</th><th>Limit</th><th>Elapsed</th><th>Remaining</th><th>First</th><th>Last</th>
+<tr><th>Name</th><th>Limit</th><th>Carry-over</th><th>Elapsed</th><th>Remaining</th><th>Week</th><th>Month</th><th>First</th><th>Last</th>
 <th>Sun</th>
 <th>Mon</th>
 <th>Tue</th>
@@ -118,22 +117,64 @@ tr:nth-child(even) {
 <th>Kill</th></tr>`
 	for _, target := range targets.Targets {
 		htmlContent += "<tr>"
-		htmlContent += "<td>" + html.EscapeString(target.Name) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Limit, time.Second)) + "</td>"
+		htmlContent += "<td>" + html.EscapeString(target.Name)
+		if len(target.Groups) > 0 {
+			htmlContent += "<br><small>" + html.EscapeString(strings.Join(target.Groups, ", ")) + "</small>"
+		}
+		htmlContent += "</td>"
+		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.DailyLimit, time.Second))
+		if target.Granted > 0 {
+			htmlContent += "<br><small>+" + html.EscapeString(FormatInterval(target.Granted, time.Second)) + " granted</small>"
+		}
+		htmlContent += "</td>"
+		htmlContent += "<td>" + html.EscapeString(formatCarryover(target.Carryover)) + "</td>"
 		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Elapsed, time.Second)) + "</td>"
 		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Remaining, time.Second)) + "</td>"
+		htmlContent += "<td>" + html.EscapeString(formatQuota(target.WeeklyElapsed, target.WeeklyLimit.Seconds())) + "</td>"
+		htmlContent += "<td>" + html.EscapeString(formatQuota(target.MonthlyElapsed, target.MonthlyLimit.Seconds())) + "</td>"
 		htmlContent += "<td>" + html.EscapeString(target.FirstMatch) + "</td>"
 		htmlContent += "<td>" + html.EscapeString(target.LastMatch) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Weekdays[0], time.Hour)) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Weekdays[1], time.Hour)) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Weekdays[2], time.Hour)) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Weekdays[3], time.Hour)) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Weekdays[4], time.Hour)) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Weekdays[5], time.Hour)) + "</td>"
-		htmlContent += "<td>" + html.EscapeString(FormatInterval(target.Weekdays[6], time.Hour)) + "</td>"
+		for day := range 7 {
+			htmlContent += "<td>" + html.EscapeString(FormatInterval(target.LimitOn(time.Weekday(day)), time.Second))
+			if windows := target.Schedule.Describe(day); windows != "" {
+				htmlContent += "<br><small>" + html.EscapeString(windows) + "</small>"
+			}
+			htmlContent += "</td>"
+		}
 		htmlContent += "<td>" + strconv.FormatBool(target.Kill) + "</td></tr>"
 	}
 	htmlContent += "</table><br>"
+	if len(targets.Groups) > 0 {
+		htmlContent += "<br><h2>Groups</h2>"
+		htmlContent += "<table>"
+		htmlContent += "<tr><th>Name</th><th>Limit</th><th>Elapsed</th><th>Remaining</th><th>Targets</th></tr>"
+		for _, group := range targets.Groups {
+			htmlContent += "<tr>"
+			htmlContent += "<td>" + html.EscapeString(group.Name) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(FormatInterval(group.DailyLimit, time.Second)) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(FormatInterval(group.Elapsed, time.Second)) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(FormatInterval(group.Remaining, time.Second)) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(strings.Join(targets.Members(group.Name), ", ")) + "</td>"
+			htmlContent += "</tr>"
+		}
+		htmlContent += "</table><br>"
+	}
+	if len(grants) > 0 {
+		htmlContent += "<br><h2>Grants</h2>"
+		htmlContent += "<table>"
+		htmlContent += "<tr><th>Target</th><th>Duration</th><th>Expires At</th><th>Status</th><th>Reason</th><th>Created By</th></tr>"
+		for _, grant := range grants {
+			htmlContent += "<tr>"
+			htmlContent += "<td>" + html.EscapeString(grant.Target) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(FormatInterval(grant.Duration.Seconds(), time.Second)) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(grant.ExpiresAt.Format(time.RFC3339)) + "</td>"
+			htmlContent += "<td>" + formatGrantStatus(grant, start) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(grant.Reason) + "</td>"
+			htmlContent += "<td>" + html.EscapeString(grant.CreatedBy) + "</td>"
+			htmlContent += "</tr>"
+		}
+		htmlContent += "</table><br>"
+	}
 	htmlContent += "<br><h2>Commands</h2>"
 	htmlContent += "<table>"
 	htmlContent += "<tr><th>Created At</th><th>Name</th><th>Command</th><th>Return</th><th>Source</th><th>Log</th></tr>"
@@ -153,7 +194,50 @@ tr:nth-child(even) {
 	ctx.Data(http.StatusOK, "text/html", []byte(htmlContent))
 }
 
+// formatCarryover renders the time carried over from previous days, already
+// included in the limit.
+func formatCarryover(seconds float64) string {
+	if seconds <= 0 {
+		return ""
+	}
+
+	return "+" + FormatInterval(seconds, time.Second)
+}
+
+func formatGrantStatus(grant *domain.Grant, now time.Time) string {
+	switch {
+	case grant.IsRevoked():
+		return "revoked"
+	case grant.IsActive(now):
+		return "active"
+	}
+
+	return "expired"
+}
+
+// formatQuota renders the usage of a quota as "used / limit", or nothing
+// when there is no quota.
+func formatQuota(elapsed float64, limit float64) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	return FormatInterval(elapsed, time.Second) + " / " + FormatInterval(limit, time.Second)
+}
+
 func FormatInterval(seconds float64, scale time.Duration) string {
 	d := time.Duration(seconds * float64(scale))
 	return d.String()
 }
+
+// formatCalendarDay renders the calendar entry that applies today, or
+// nothing on a regular day.
+func formatCalendarDay(calendar domain.Calendar, now time.Time) string {
+	entry := calendar.Entry(now)
+
+	if entry == nil {
+		return ""
+	}
+
+	return "<p>Today: " + html.EscapeString(entry.Name) + " (" + calendar.Weekday(now).String() + " rules)</p>"
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
 	"procspy/internal/procspy/service"
 	"procspy/internal/procspy/storage"
+	"strings"
 	"testing"
 	"time"
 )
@@ -11,11 +13,11 @@ import (
 // TestNewReport testa criação de handler de report
 func TestNewReport(t *testing.T) {
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	targetService := service.NewTarget(cfg)
-	usersService := service.NewUsers(cfg)
-
 	conn := storage.NewDbConnection(":memory:")
 	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	usersService := service.NewUsers(cfg, targetService)
 	matchService := service.NewMatch(conn)
 	commandService := service.NewCommand(conn)
 
@@ -51,11 +53,11 @@ func TestFormatInterval(t *testing.T) {
 // TestReport_GetReport_InvalidUser testa busca com usuário inválido
 func TestReport_GetReport_InvalidUser(t *testing.T) {
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	targetService := service.NewTarget(cfg)
-	usersService := service.NewUsers(cfg)
-
 	conn := storage.NewDbConnection(":memory:")
 	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	usersService := service.NewUsers(cfg, targetService)
 	matchService := service.NewMatch(conn)
 	commandService := service.NewCommand(conn)
 	handler := NewReport(targetService, usersService, matchService, commandService)
@@ -70,3 +72,105 @@ func TestReport_GetReport_InvalidUser(t *testing.T) {
 		t.Errorf("Status = %d, esperado 401", w.Code)
 	}
 }
+
+// TestReport_GetReport_ScheduleGroupsAndGrants testa a exibição das janelas de horário, dos grupos e das concessões no relatório
+func TestReport_GetReport_ScheduleGroupsAndGrants(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	handler := NewReport(targetService, service.NewUsers(cfg, targetService), service.NewMatch(conn), service.NewCommand(conn))
+
+	if err := targetService.ReplaceGroups("user1", []*domain.Group{{Name: "entertainment", Limit: 5400}}); err != nil {
+		t.Fatalf("ReplaceGroups() erro = %v", err)
+	}
+
+	target := &domain.Target{
+		Name:     "games",
+		Pattern:  "steam",
+		Schedule: domain.Schedule{1: {"16:00-20:00"}, 0: {}},
+		Groups:   []string{"entertainment"},
+	}
+
+	if err := targetService.CreateTarget("user1", target); err != nil {
+		t.Fatalf("CreateTarget() erro = %v", err)
+	}
+
+	if err := targetService.AddGrant("user1", &domain.Grant{Target: "games", Duration: 1800, Reason: "homework done"}); err != nil {
+		t.Fatalf("AddGrant() erro = %v", err)
+	}
+
+	router := setupTestRouter()
+	router.GET("/report/:user", handler.GetReport)
+
+	w := executeRequest(router, makeTestRequest("GET", "/report/user1", ""))
+
+	if w.Code != 200 {
+		t.Fatalf("Status = %d, esperado 200", w.Code)
+	}
+
+	for _, expected := range []string{"<small>16:00-20:00</small>", "<small>blocked</small>", "<small>entertainment</small>", "<h2>Groups</h2>", "<td>1h30m0s</td>", "<h2>Grants</h2>", "<small>+30m0s granted</small>", "<td>homework done</td>", "<td>active</td>"} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Relatório deveria conter %s", expected)
+		}
+	}
+}
+
+// TestReport_GetReport_WeekdayLimits testa os limites por dia da semana de targets com limite absoluto
+func TestReport_GetReport_WeekdayLimits(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	handler := NewReport(targetService, service.NewUsers(cfg, targetService), service.NewMatch(conn), service.NewCommand(conn))
+
+	targets := []*domain.Target{
+		{Name: "games", Pattern: "steam", Limit: 7200},
+		{Name: "videos", Pattern: "vlc", WeekdayLimits: map[int]domain.Duration{0: 10800, 1: 2700}},
+	}
+
+	for _, target := range targets {
+		if err := targetService.CreateTarget("user1", target); err != nil {
+			t.Fatalf("CreateTarget() erro = %v", err)
+		}
+	}
+
+	router := setupTestRouter()
+	router.GET("/report/:user", handler.GetReport)
+
+	w := executeRequest(router, makeTestRequest("GET", "/report/user1", ""))
+
+	if w.Code != 200 {
+		t.Fatalf("Status = %d, esperado 200", w.Code)
+	}
+
+	tests := []struct {
+		target   string
+		expected []string
+	}{
+		// Limite, domingo a sábado
+		{"games", []string{"2h0m0s", "2h0m0s", "2h0m0s", "2h0m0s", "2h0m0s", "2h0m0s", "2h0m0s"}},
+		{"videos", []string{"3h0m0s", "45m0s", "30m0s", "30m0s", "30m0s", "30m0s", "1h0m0s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			row := reportRow(w.Body.String(), tt.target)
+			days := strings.Split(row, "<td>")[10:17]
+
+			for day, expected := range tt.expected {
+				if !strings.HasPrefix(days[day], expected) {
+					t.Errorf("Dia %d = %q, esperado %s", day, days[day], expected)
+				}
+			}
+		})
+	}
+}
+
+// reportRow returns the row of a target in the targets table of a report.
+func reportRow(report string, target string) string {
+	row := report[strings.Index(report, "<tr><td>"+target):]
+	return row[:strings.Index(row, "</tr>")]
+}
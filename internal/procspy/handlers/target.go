@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"procspy/internal/procspy/domain"
 	"procspy/internal/procspy/service"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -37,10 +42,185 @@ func (t *Target) GetTargets(ctx *gin.Context) {
 		return
 	}
 
+	targets, err := targetsWithUsage(t.service, t.matches, user)
+
+	if err != nil {
+		log.Printf("[handlers.Target.GetTargets] [%s] Failed to retrieve targets: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	etag := targets.ETag()
+	ctx.Header("ETag", etag)
+
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"targets":    targets.Targets,
+		"groups":     targets.Groups,
+		"override":   targets.Override,
+		"calendar":   targets.Calendar,
+		"timezone":   targets.Timezone,
+		"allowlist":  targets.Allowlist,
+		"week_start": targets.WeekStart,
+		"elapsed":    time.Since(start).Milliseconds(),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// targetsWithUsage returns the targets and groups of a user with today's
+// usage, as served to the clients.
+func targetsWithUsage(targetService *service.Target, matchService *service.Match, user string) (*domain.TargetList, error) {
+	targets, err := targetService.GetTargets(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := matchService.GetMatchesInfo(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, target := range targets.Targets {
+		if info, ok := matches[target.Name]; ok {
+			target.AddMatchInfo(info)
+		}
+	}
+
+	grants, err := targetService.GetQuotaGrants(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	targets.ApplyGrants(time.Now(), grants)
+
+	targets.Override, err = targetService.GetOverride(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyRollover(matchService, user, targets); err != nil {
+		return nil, err
+	}
+
+	if err := addQuotaElapsed(matchService, user, targets); err != nil {
+		return nil, err
+	}
+
+	groups, err := matchService.GetGroupsElapsed(user, targets)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range targets.Groups {
+		group.SetElapsed(groups[group.Name])
+	}
+
+	return targets, nil
+}
+
+// applyRollover adds the time carried over from previous days to the
+// limit of the targets with a rollover policy.
+func applyRollover(matchService *service.Match, user string, targets *domain.TargetList) error {
+	now := targets.Now()
+	since := now
+
+	for _, target := range targets.Targets {
+		if start := target.RolloverStart(now); start.Before(since) {
+			since = start
+		}
+	}
+
+	if since.Equal(now) {
+		return nil
+	}
+
+	usage, err := matchService.GetDailyElapsed(user, since)
+
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets.Targets {
+		if target.Rollover != nil {
+			target.ApplyRollover(now, usage[target.Name])
+		}
+	}
+
+	return nil
+}
+
+// addQuotaElapsed sets the weekly and monthly usage of the targets with a
+// quota. The usage of past days is only queried when one of them has it.
+func addQuotaElapsed(matchService *service.Match, user string, targets *domain.TargetList) error {
+	quota := false
+
+	for _, target := range targets.Targets {
+		quota = quota || target.HasQuota()
+	}
+
+	if !quota {
+		return nil
+	}
+
+	weekly, monthly, err := matchService.GetQuotaElapsed(user)
+
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets.Targets {
+		if target.HasQuota() {
+			target.SetQuotaElapsed(weekly[target.Name], monthly[target.Name])
+		}
+	}
+
+	return nil
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListTargets returns the stored targets of a user, without usage data.
+func (t *Target) ListTargets(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.ListTargets] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
 	targets, err := t.service.GetTargets(user)
 
 	if err != nil {
-		log.Printf("[handlers.Target.GetTargets] [%s] Failed to retrieve targets from service: %v", user, err)
+		log.Printf("[handlers.Target.ListTargets] [%s] Failed to retrieve targets from service: %v", user, err)
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error":     "internal error",
 			"elapsed":   time.Since(start).Milliseconds(),
@@ -49,10 +229,26 @@ func (t *Target) GetTargets(ctx *gin.Context) {
 		return
 	}
 
-	matches, err := t.matches.GetMatchesInfo(user)
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"targets":   targets.Targets,
+		"groups":    targets.Groups,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (t *Target) GetTarget(ctx *gin.Context) {
+	start := time.Now()
+	user, id, ok := t.targetParams(ctx, start)
+
+	if !ok {
+		return
+	}
+
+	target, err := t.service.GetTarget(user, id)
 
 	if err != nil {
-		log.Printf("[handlers.Target.GetTargets] [%s] Failed to retrieve match information: %v", user, err)
+		log.Printf("[handlers.Target.GetTarget] [%s] Failed to retrieve target %d: %v", user, id, err)
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
 			"error":     "internal error",
 			"elapsed":   time.Since(start).Milliseconds(),
@@ -61,15 +257,209 @@ func (t *Target) GetTargets(ctx *gin.Context) {
 		return
 	}
 
-	for _, target := range targets.Targets {
-		if info, ok := matches[target.Name]; ok {
-			target.AddMatchInfo(info)
-		}
+	if target == nil {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "target not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
 	}
 
 	ctx.IndentedJSON(http.StatusOK, gin.H{
-		"targets":   targets.Targets,
+		"target":    target,
 		"elapsed":   time.Since(start).Milliseconds(),
 		"timestamp": time.Now().Format(time.RFC3339),
 	})
 }
+
+func (t *Target) CreateTarget(ctx *gin.Context) {
+	start := time.Now()
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.CreateTarget] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	target, ok := readTarget(ctx, start)
+
+	if !ok {
+		return
+	}
+
+	err = t.service.CreateTarget(user, target)
+
+	if t.writeError(ctx, start, user, err) {
+		return
+	}
+
+	log.Printf("[handlers.Target.CreateTarget] [%s] Target %d '%s' created by '%s'", user, target.ID, target.Name, accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"target":    target,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (t *Target) UpdateTarget(ctx *gin.Context) {
+	start := time.Now()
+	user, id, ok := t.targetParams(ctx, start)
+
+	if !ok {
+		return
+	}
+
+	target, ok := readTarget(ctx, start)
+
+	if !ok {
+		return
+	}
+
+	found, err := t.service.UpdateTarget(user, id, target)
+
+	if t.writeError(ctx, start, user, err) {
+		return
+	}
+
+	if !found {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "target not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Target.UpdateTarget] [%s] Target %d '%s' updated by '%s'", user, id, target.Name, accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"target":    target,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (t *Target) DeleteTarget(ctx *gin.Context) {
+	start := time.Now()
+	user, id, ok := t.targetParams(ctx, start)
+
+	if !ok {
+		return
+	}
+
+	found, err := t.service.DeleteTarget(user, id)
+
+	if err != nil {
+		log.Printf("[handlers.Target.DeleteTarget] [%s] Failed to delete target %d: %v", user, id, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if !found {
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "target not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.Target.DeleteTarget] [%s] Target %d deleted by '%s'", user, id, accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"message":   "target deleted",
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (t *Target) targetParams(ctx *gin.Context, start time.Time) (string, int64, bool) {
+	user, err := ValidateUser(t.users, ctx)
+
+	if err != nil {
+		log.Printf("[handlers.Target.targetParams] [%s] User validation failed: %v", user, err)
+		ctx.IndentedJSON(http.StatusNotFound, gin.H{
+			"error":     "user not found",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return user, 0, false
+	}
+
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		log.Printf("[handlers.Target.targetParams] [%s] Invalid target id '%s': %v", user, ctx.Param("id"), err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid target id",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return user, 0, false
+	}
+
+	return user, id, true
+}
+
+// writeError answers a failed create or update of targets or groups and
+// reports whether the request was already answered.
+func (t *Target) writeError(ctx *gin.Context, start time.Time, user string, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrInvalidTarget), errors.Is(err, service.ErrInvalidGroup), errors.Is(err, service.ErrInvalidGrant), errors.Is(err, service.ErrInvalidOverride), errors.Is(err, service.ErrInvalidCalendar):
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     err.Error(),
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	case errors.Is(err, service.ErrTargetExists):
+		ctx.IndentedJSON(http.StatusConflict, gin.H{
+			"error":     "target already exists",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	default:
+		log.Printf("[handlers.Target.writeError] [%s] Failed to store target: %v", user, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	}
+
+	return true
+}
+
+func readTarget(ctx *gin.Context, start time.Time) (*domain.Target, bool) {
+	target := &domain.Target{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, target)
+	}
+
+	if err != nil {
+		log.Printf("[handlers.readTarget] Invalid target request: %v", err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return nil, false
+	}
+
+	return target, true
+}
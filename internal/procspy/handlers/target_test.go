@@ -1,20 +1,25 @@
 package handlers
 
 import (
+	"encoding/json"
+	"net/http"
 	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
 	"procspy/internal/procspy/service"
 	"procspy/internal/procspy/storage"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewTarget testa criação de handler de target
 func TestNewTarget(t *testing.T) {
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	targetService := service.NewTarget(cfg)
-	usersService := service.NewUsers(cfg)
-
 	conn := storage.NewDbConnection(":memory:")
 	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	usersService := service.NewUsers(cfg, targetService)
 	matchService := service.NewMatch(conn)
 
 	handler := NewTarget(targetService, usersService, matchService)
@@ -26,11 +31,11 @@ func TestNewTarget(t *testing.T) {
 // TestTarget_GetTargets_InvalidUser testa busca com usuário inválido
 func TestTarget_GetTargets_InvalidUser(t *testing.T) {
 	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
-	targetService := service.NewTarget(cfg)
-	usersService := service.NewUsers(cfg)
-
 	conn := storage.NewDbConnection(":memory:")
 	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	usersService := service.NewUsers(cfg, targetService)
 	matchService := service.NewMatch(conn)
 	handler := NewTarget(targetService, usersService, matchService)
 
@@ -44,3 +49,613 @@ func TestTarget_GetTargets_InvalidUser(t *testing.T) {
 		t.Errorf("Status = %d, esperado 401", w.Code)
 	}
 }
+
+// TestTarget_CRUD testa a API de targets do admin
+func TestTarget_CRUD(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	usersService := service.NewUsers(cfg, targetService)
+	handler := NewTarget(targetService, usersService, service.NewMatch(conn))
+
+	router := setupTestRouter()
+	router.GET("/admin/users/:user/targets", handler.ListTargets)
+	router.POST("/admin/users/:user/targets", handler.CreateTarget)
+	router.GET("/admin/users/:user/targets/:id", handler.GetTarget)
+	router.PUT("/admin/users/:user/targets/:id", handler.UpdateTarget)
+	router.DELETE("/admin/users/:user/targets/:id", handler.DeleteTarget)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		expected int
+	}{
+		{"Criar target", "POST", "/admin/users/user1/targets", `{"name":"games","pattern":"steam","weekdays":{"0":2}}`, 201},
+		{"Nome duplicado", "POST", "/admin/users/user1/targets", `{"name":"games","pattern":"roblox"}`, 409},
+		{"Pattern inválido", "POST", "/admin/users/user1/targets", `{"name":"bad","pattern":"steam("}`, 400},
+		{"Schedule inválido", "POST", "/admin/users/user1/targets", `{"name":"bad","pattern":"steam","schedule":{"1":["16h-20h"]}}`, 400},
+		{"JSON inválido", "POST", "/admin/users/user1/targets", `{invalid`, 400},
+		{"Usuário inexistente", "POST", "/admin/users/unknown/targets", `{"name":"games","pattern":"steam"}`, 404},
+		{"Listar targets", "GET", "/admin/users/user1/targets", "", 200},
+		{"Buscar target", "GET", "/admin/users/user1/targets/1", "", 200},
+		{"Target inexistente", "GET", "/admin/users/user1/targets/99", "", 404},
+		{"ID inválido", "GET", "/admin/users/user1/targets/abc", "", 400},
+		{"Atualizar target", "PUT", "/admin/users/user1/targets/1", `{"name":"games","pattern":"steam|roblox"}`, 200},
+		{"Atualizar target inexistente", "PUT", "/admin/users/user1/targets/99", `{"name":"x","pattern":"x"}`, 404},
+		{"Remover target", "DELETE", "/admin/users/user1/targets/1", "", 200},
+		{"Remover target removido", "DELETE", "/admin/users/user1/targets/1", "", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest(tt.method, tt.url, tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestTarget_GetTargets_ETag testa a revalidação condicional da lista de targets
+func TestTarget_GetTargets_ETag(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	if err := targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam"}); err != nil {
+		t.Fatalf("CreateTarget() erro = %v", err)
+	}
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+	etag := w.Header().Get("ETag")
+
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Status = %d, ETag = %q, esperado 200 com ETag", w.Code, etag)
+	}
+
+	req := makeTestRequest("GET", "/targets/user1", "")
+	req.Header.Set("If-None-Match", etag)
+	w = executeRequest(router, req)
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Status = %d, corpo = %q, esperado 304 sem corpo", w.Code, w.Body.String())
+	}
+
+	// Novo uso de outro dispositivo muda a ETag
+	if err := matchService.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam", 60)); err != nil {
+		t.Fatalf("InsertMatch() erro = %v", err)
+	}
+
+	req = makeTestRequest("GET", "/targets/user1", "")
+	req.Header.Set("If-None-Match", etag)
+	w = executeRequest(router, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("Status = %d, ETag = %q, esperado 200 com nova ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+// TestTarget_Groups testa a API de grupos e o uso compartilhado servido aos clientes
+func TestTarget_Groups(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+	router.POST("/admin/users/:user/targets", handler.CreateTarget)
+	router.GET("/admin/users/:user/groups", handler.ListGroups)
+	router.PUT("/admin/users/:user/groups", handler.ReplaceGroups)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		expected int
+	}{
+		{"Grupo sem limite", "PUT", "/admin/users/user1/groups", `{"groups":[{"name":"entertainment"}]}`, 400},
+		{"Grupo duplicado", "PUT", "/admin/users/user1/groups", `{"groups":[{"name":"a","limit":60},{"name":"a","limit":60}]}`, 400},
+		{"Criar grupos", "PUT", "/admin/users/user1/groups", `{"groups":[{"name":"entertainment","limit":"90m"}]}`, 200},
+		{"Listar grupos", "GET", "/admin/users/user1/groups", "", 200},
+		{"Target com grupo", "POST", "/admin/users/user1/targets", `{"name":"games","pattern":"steam","groups":["entertainment"]}`, 201},
+		{"Target com grupo inexistente", "POST", "/admin/users/user1/targets", `{"name":"videos","pattern":"vlc","groups":["unknown"]}`, 400},
+		{"Remover grupo em uso", "PUT", "/admin/users/user1/groups", `{"groups":[]}`, 400},
+		{"Usuário inexistente", "PUT", "/admin/users/unknown/groups", `{"groups":[]}`, 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest(tt.method, tt.url, tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "videos", Pattern: "vlc", Groups: []string{"entertainment"}})
+	for _, name := range []string{"games", "videos"} {
+		conn.Exec("INSERT INTO matches (user, name, pattern, match, elapsed, created_at) VALUES ('user1', ?, '', '', 60, datetime('now', 'localtime', 'start of day'));", name)
+	}
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Groups []*domain.Group `json:"groups"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Groups) != 1 {
+		t.Fatalf("Resposta sem grupos: %v, %s", err, w.Body.String())
+	}
+
+	// Os dois targets rodaram no mesmo minuto: o grupo conta uma vez
+	if group := res.Groups[0]; group.DailyLimit != 5400 || group.Elapsed != 60 || group.Remaining != 5340 {
+		t.Errorf("Grupo = %+v, esperado limite 5400, uso 60 e restante 5340", group)
+	}
+}
+
+// TestTarget_Calendar testa o calendário de feriados e o limite servido aos clientes
+func TestTarget_Calendar(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+	router.GET("/admin/users/:user/calendar", handler.GetCalendar)
+	router.PUT("/admin/users/:user/calendar", handler.ReplaceCalendar)
+	router.POST("/admin/users/:user/calendar/ics", handler.ImportCalendar)
+
+	now := time.Now()
+	profile := "sunday"
+	if now.Weekday() == time.Sunday {
+		profile = "monday"
+	}
+
+	today := now.Format(time.DateOnly)
+	ics := "BEGIN:VEVENT\nSUMMARY:Feriado\nDTSTART;VALUE=DATE:20251225\nEND:VEVENT\n"
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		expected int
+	}{
+		{"Perfil inválido", "PUT", "/admin/users/user1/calendar", `{"calendar":[{"name":"Férias","from":"` + today + `","profile":"holiday"}]}`, 400},
+		{"Data inválida", "PUT", "/admin/users/user1/calendar", `{"calendar":[{"name":"Férias","from":"amanhã","profile":"weekend"}]}`, 400},
+		{"JSON inválido", "PUT", "/admin/users/user1/calendar", `{"calendar":`, 400},
+		{"Gravar calendário", "PUT", "/admin/users/user1/calendar", `{"calendar":[{"name":"Férias","from":"` + today + `","profile":"` + profile + `"}]}`, 200},
+		{"Importar iCalendar", "POST", "/admin/users/user1/calendar/ics?profile=weekend", ics, 201},
+		{"Importar perfil inválido", "POST", "/admin/users/user1/calendar/ics?profile=holiday", ics, 400},
+		{"Listar calendário", "GET", "/admin/users/user1/calendar", "", 200},
+		{"Usuário inexistente", "GET", "/admin/users/unknown/calendar", "", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest(tt.method, tt.url, tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	if calendar, _ := targetService.GetCalendar("user1"); len(calendar) != 2 || calendar[1].From != "2025-12-25" {
+		t.Fatalf("Calendário incorreto após importação: %v", calendar)
+	}
+
+	targetService.CreateTarget("user1", &domain.Target{
+		Name:          "games",
+		Pattern:       "steam",
+		Limit:         3600,
+		WeekdayLimits: map[int]domain.Duration{0: 7200, 1: 7200},
+	})
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Targets  []*domain.Target `json:"targets"`
+		Calendar domain.Calendar  `json:"calendar"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Targets) != 1 {
+		t.Fatalf("Resposta sem targets: %v, %s", err, w.Body.String())
+	}
+
+	if len(res.Calendar) != 2 {
+		t.Errorf("Resposta deveria incluir o calendário, obteve %v", res.Calendar)
+	}
+
+	// Hoje segue as regras do perfil do calendário
+	if res.Targets[0].DailyLimit != 7200 {
+		t.Errorf("DailyLimit = %f, esperado 7200 pelo perfil %s", res.Targets[0].DailyLimit, profile)
+	}
+}
+
+// TestTarget_GetTargets_Timezone testa o dia servido no fuso configurado do usuário
+func TestTarget_GetTargets_Timezone(t *testing.T) {
+	zone := "Pacific/Kiritimati"
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		t.Skipf("Fuso indisponível: %v", err)
+	}
+
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}, UserTimezones: map[string]string{"user1": zone}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	matchService.SetTimezones(service.NewTimezones(cfg.UserTimezones))
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+
+	weekday := time.Now().In(loc).Weekday()
+
+	limits := map[int]domain.Duration{}
+	for day := range 7 {
+		limits[day] = domain.Duration((day + 1) * 600)
+	}
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam", WeekdayLimits: limits})
+
+	// Um match de ontem no fuso do usuário não conta no dia de hoje
+	now := time.Now().In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	for _, created := range []time.Time{midnight.Add(-time.Minute), midnight.Add(time.Minute)} {
+		match := domain.NewMatch("user1", "games", "steam", "steam", 60)
+		match.CreatedAt = created
+		matchService.InsertMatch(match)
+	}
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Targets  []*domain.Target `json:"targets"`
+		Timezone string           `json:"timezone"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Targets) != 1 {
+		t.Fatalf("Resposta sem targets: %v, %s", err, w.Body.String())
+	}
+
+	if res.Timezone != zone {
+		t.Errorf("Timezone = %s, esperado %s", res.Timezone, zone)
+	}
+
+	target := res.Targets[0]
+	if expected := float64((int(weekday) + 1) * 600); target.DailyLimit != expected || target.Elapsed != 60 {
+		t.Errorf("Target = limite %f e uso %f, esperado %f de %s e uso 60", target.DailyLimit, target.Elapsed, expected, weekday)
+	}
+}
+
+// TestTarget_Grants testa concessões de tempo e o restante servido aos clientes
+func TestTarget_Grants(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam", Limit: 3600})
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+	router.GET("/admin/users/:user/grants", handler.ListGrants)
+	router.POST("/admin/users/:user/grants", handler.CreateGrant)
+	router.DELETE("/admin/users/:user/grants/:id", handler.RevokeGrant)
+
+	expired := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		expected int
+	}{
+		{"Conceder 30 minutos", "POST", "/admin/users/user1/grants", `{"target":"games","duration":"30m","reason":"homework done"}`, 201},
+		{"Target inexistente", "POST", "/admin/users/user1/grants", `{"target":"videos","duration":"30m"}`, 400},
+		{"Duração inválida", "POST", "/admin/users/user1/grants", `{"target":"games","duration":"-5m"}`, 400},
+		{"Expiração no passado", "POST", "/admin/users/user1/grants", `{"target":"games","duration":"5m","expires_at":"` + expired + `"}`, 400},
+		{"JSON inválido", "POST", "/admin/users/user1/grants", `{`, 400},
+		{"Conceder 10 minutos", "POST", "/admin/users/user1/grants", `{"target":"games","duration":600}`, 201},
+		{"Revogar concessão", "DELETE", "/admin/users/user1/grants/2", "", 200},
+		{"Revogar de novo", "DELETE", "/admin/users/user1/grants/2", "", 404},
+		{"ID inválido", "DELETE", "/admin/users/user1/grants/abc", "", 400},
+		{"Listar concessões", "GET", "/admin/users/user1/grants", "", 200},
+		{"Usuário inexistente", "GET", "/admin/users/unknown/grants", "", 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest(tt.method, tt.url, tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	matchService.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam", 60))
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Targets []*domain.Target `json:"targets"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Targets) != 1 {
+		t.Fatalf("Resposta inválida: %v, %s", err, w.Body.String())
+	}
+
+	// Só a concessão não revogada soma ao limite
+	if target := res.Targets[0]; target.Granted != 1800 || target.DailyLimit != 5400 || target.Remaining != 5340 {
+		t.Errorf("Target = granted %.0f, limite %.0f, restante %.0f, esperado 1800, 5400 e 5340", target.Granted, target.DailyLimit, target.Remaining)
+	}
+}
+
+// TestTarget_GroupGrants testa que concessões de um target somam ao orçamento dos seus grupos
+func TestTarget_GroupGrants(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+	router.PUT("/admin/users/:user/groups", handler.ReplaceGroups)
+	router.POST("/admin/users/:user/targets", handler.CreateTarget)
+	router.POST("/admin/users/:user/grants", handler.CreateGrant)
+
+	requests := []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{"PUT", "/admin/users/user1/groups", `{"groups":[{"name":"entertainment","limit":"1m"}]}`},
+		{"POST", "/admin/users/user1/targets", `{"name":"games","pattern":"steam","limit":"2h","groups":["entertainment"]}`},
+		{"POST", "/admin/users/user1/grants", `{"target":"games","duration":"30m"}`},
+	}
+
+	for _, r := range requests {
+		if w := executeRequest(router, makeTestRequest(r.method, r.url, r.body)); w.Code >= 300 {
+			t.Fatalf("%s %s: status %d: %s", r.method, r.url, w.Code, w.Body.String())
+		}
+	}
+
+	matchService.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam", 60))
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Groups []*domain.Group `json:"groups"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Groups) != 1 {
+		t.Fatalf("Resposta sem grupos: %v, %s", err, w.Body.String())
+	}
+
+	// O grupo esgotou o limite próprio, mas a concessão do target ainda vale
+	if group := res.Groups[0]; group.Granted != 1800 || group.DailyLimit != 1860 || group.Remaining != 1800 {
+		t.Errorf("Grupo = granted %.0f, limite %.0f, restante %.0f, esperado 1800, 1860 e 1800", group.Granted, group.DailyLimit, group.Remaining)
+	}
+}
+
+// TestTarget_Override testa o bloqueio e a liberação do usuário servidos com os targets
+func TestTarget_Override(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	commandService := service.NewCommand(conn)
+	targetService.SetCommands(commandService)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), service.NewMatch(conn))
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam"})
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+	router.GET("/admin/users/:user/override", handler.GetOverride)
+	router.PUT("/admin/users/:user/override", handler.SetOverride)
+
+	until := time.Now().Add(time.Hour).Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		expected int
+	}{
+		{"Modo inválido", "PUT", "/admin/users/user1/override", `{"mode":"paused"}`, 400},
+		{"Fim no passado", "PUT", "/admin/users/user1/override", `{"mode":"locked","until":"` + past + `"}`, 400},
+		{"JSON inválido", "PUT", "/admin/users/user1/override", `{`, 400},
+		{"Bloquear até daqui a 1h", "PUT", "/admin/users/user1/override", `{"mode":"locked","until":"` + until + `","reason":"jantar"}`, 200},
+		{"Consultar modo", "GET", "/admin/users/user1/override", "", 200},
+		{"Usuário inexistente", "PUT", "/admin/users/unknown/override", `{"mode":"locked"}`, 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := executeRequest(router, makeTestRequest(tt.method, tt.url, tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("Status = %d, esperado %d: %s", w.Code, tt.expected, w.Body.String())
+			}
+		})
+	}
+
+	getTargets := func() *domain.TargetList {
+		w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+		targets, err := domain.TargetListFromJson(w.Body.String())
+		if err != nil {
+			t.Fatalf("Resposta inválida: %v, %s", err, w.Body.String())
+		}
+		return targets
+	}
+
+	if targets := getTargets(); targets.Mode(time.Now()) != domain.OVERRIDE_LOCKED || targets.Override.Reason != "jantar" {
+		t.Errorf("Override = %+v, esperado bloqueado", targets.Override)
+	}
+
+	executeRequest(router, makeTestRequest("PUT", "/admin/users/user1/override", `{"mode":"normal","until":"`+until+`"}`))
+
+	if targets := getTargets(); targets.Override != nil {
+		t.Errorf("Override = %+v, esperado nenhum no modo normal", targets.Override)
+	}
+
+	// Cada mudança fica no log de comandos
+	commands, _ := commandService.GetCommands("user1")
+
+	logged := make([]string, 0)
+	for _, cmd := range commands {
+		if cmd.Source == service.OVERRIDE_COMMAND_SOURCE {
+			logged = append(logged, cmd.CommandLine)
+		}
+	}
+
+	if all := strings.Join(logged, "\n"); len(logged) != 2 || !strings.Contains(all, "mode locked until") || !strings.Contains(all, "mode normal") {
+		t.Errorf("Log de comandos = %v, esperado o bloqueio e a volta ao normal", logged)
+	}
+}
+
+// TestTarget_GetTargets_Quota testa o uso semanal e mensal servido aos clientes
+func TestTarget_GetTargets_Quota(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam", Limit: 3 * 3600, WeeklyLimit: 10 * 3600, MonthlyLimit: 1000 * 3600})
+	matchService.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam", 60))
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Targets []*domain.Target `json:"targets"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Targets) != 1 {
+		t.Fatalf("Resposta inválida: %v, %s", err, w.Body.String())
+	}
+
+	target := res.Targets[0]
+
+	if target.WeeklyElapsed != 60 || target.WeeklyRemaining != 10*3600-60 || target.MonthlyElapsed != 60 {
+		t.Errorf("Cotas = %.0f / %.0f / %.0f, esperado 60 / 35940 / 60", target.WeeklyElapsed, target.WeeklyRemaining, target.MonthlyElapsed)
+	}
+
+	if target.Remaining != 3*3600-60 {
+		t.Errorf("Remaining = %.0f, esperado %d", target.Remaining, 3*3600-60)
+	}
+}
+
+// TestTarget_GetTargets_QuotaGrants testa o tempo concedido ontem somado à cota semanal
+func TestTarget_GetTargets_QuotaGrants(t *testing.T) {
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+
+	// A semana começou ontem
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}, WeekStart: int(yesterday.Weekday())}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	matchService := service.NewMatch(conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), matchService)
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam", Limit: 3600, WeeklyLimit: 3600})
+	conn.Exec("INSERT INTO grants (user, target, duration, expires_at, created_at) VALUES ('user1', 'games', 1800, ?, ?);",
+		domain.EndOfDay(yesterday).Format(time.DateTime), yesterday.Format(time.DateTime))
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Targets   []*domain.Target `json:"targets"`
+		WeekStart time.Weekday     `json:"week_start"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Targets) != 1 {
+		t.Fatalf("Resposta inválida: %v, %s", err, w.Body.String())
+	}
+
+	if res.WeekStart != yesterday.Weekday() {
+		t.Errorf("week_start = %s, esperado %s", res.WeekStart, yesterday.Weekday())
+	}
+
+	// A concessão de ontem não vale mais hoje, mas continua na cota da semana
+	if target := res.Targets[0]; target.Granted != 0 || target.WeeklyGranted != 1800 || target.WeeklyRemaining != 5400 {
+		t.Errorf("games = granted %.0f, semana %.0f, restante %.0f, esperado 0, 1800 e 5400", target.Granted, target.WeeklyGranted, target.WeeklyRemaining)
+	}
+}
+
+// TestTarget_GetTargets_Rollover testa o tempo não usado de dias anteriores somado ao limite
+func TestTarget_GetTargets_Rollover(t *testing.T) {
+	cfg := &config.Server{UserTarges: map[string]string{"user1": "url"}}
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targetService := service.NewTarget(cfg, conn)
+	handler := NewTarget(targetService, service.NewUsers(cfg, targetService), service.NewMatch(conn))
+
+	targetService.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam", Limit: 3600, Rollover: &domain.Rollover{Days: 2, Cap: 1800}})
+
+	// Ontem usou 50m de 1h, anteontem 1h50m
+	conn.Exec("INSERT INTO matches_old (id, user, name, pattern, match, elapsed, created_at) VALUES (1, 'user1', 'games', '', '', 6600, date('now', 'localtime', '-2 days'));")
+	conn.Exec("INSERT INTO matches_old (id, user, name, pattern, match, elapsed, created_at) VALUES (2, 'user1', 'games', '', '', 3000, date('now', 'localtime', '-1 days'));")
+
+	router := setupTestRouter()
+	router.GET("/targets/:user", handler.GetTargets)
+
+	w := executeRequest(router, makeTestRequest("GET", "/targets/user1", ""))
+
+	res := struct {
+		Targets []*domain.Target `json:"targets"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Targets) != 1 {
+		t.Fatalf("Resposta inválida: %v, %s", err, w.Body.String())
+	}
+
+	if target := res.Targets[0]; target.Carryover != 600 || target.DailyLimit != 4200 || target.Remaining != 4200 {
+		t.Errorf("Carryover = %.0f, limite = %.0f, restante = %.0f, esperado 600, 4200 e 4200", target.Carryover, target.DailyLimit, target.Remaining)
+	}
+}
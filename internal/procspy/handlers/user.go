@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"procspy/internal/procspy/service"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type User struct {
+	users   *service.Users
+	targets *service.Target
+}
+
+func NewUser(usersService *service.Users, targetService *service.Target) *User {
+	return &User{
+		users:   usersService,
+		targets: targetService,
+	}
+}
+
+// GetUsers returns the users the logged in account can see.
+func (u *User) GetUsers(ctx *gin.Context) {
+	start := time.Now()
+	account := CurrentAccount(ctx)
+
+	users, err := u.users.GetUsers()
+
+	if err != nil {
+		log.Printf("[handlers.User.GetUsers] Failed to retrieve users: %v", err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ret := make([]string, 0)
+
+	for _, user := range users {
+		if account != nil && account.CanRead(user) {
+			ret = append(ret, user)
+		}
+	}
+
+	sort.Strings(ret)
+
+	ctx.IndentedJSON(http.StatusOK, gin.H{
+		"users":     ret,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (u *User) CreateUser(ctx *gin.Context) {
+	start := time.Now()
+
+	req := struct {
+		Name string `json:"name"`
+	}{}
+
+	body, err := ctx.GetRawData()
+
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+
+	if err != nil || req.Name == "" {
+		log.Printf("[handlers.User.CreateUser] Invalid user request: %v", err)
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{
+			"error":     "invalid json, user name is required",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	err = u.targets.CreateUser(req.Name)
+
+	if err != nil {
+		log.Printf("[handlers.User.CreateUser] [%s] Failed to create user: %v", req.Name, err)
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{
+			"error":     "internal error",
+			"elapsed":   time.Since(start).Milliseconds(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	log.Printf("[handlers.User.CreateUser] [%s] User created by '%s'", req.Name, accountName(CurrentAccount(ctx)))
+
+	ctx.IndentedJSON(http.StatusCreated, gin.H{
+		"user":      req.Name,
+		"elapsed":   time.Since(start).Milliseconds(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/service"
+	"procspy/internal/procspy/storage"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestUser_GetUsers testa que a listagem respeita o escopo da conta
+func TestUser_GetUsers(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	cfg := newTestServerConfig()
+	targets := service.NewTarget(cfg, conn)
+	handler := NewUser(service.NewUsers(cfg, targets), targets)
+
+	router := setupTestRouter()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set(CONTEXT_ACCOUNT, domain.NewAccount("parent", domain.ROLE_PARENT, []string{"user2"}))
+	})
+	router.POST("/admin/users", handler.CreateUser)
+	router.GET("/admin/users", handler.GetUsers)
+
+	if w := executeRequest(router, makeTestRequest("POST", "/admin/users", `{"name":"user2"}`)); w.Code != 201 {
+		t.Fatalf("Status = %d, esperado 201", w.Code)
+	}
+
+	if w := executeRequest(router, makeTestRequest("POST", "/admin/users", `{}`)); w.Code != 400 {
+		t.Errorf("Status = %d, esperado 400 sem nome", w.Code)
+	}
+
+	w := executeRequest(router, makeTestRequest("GET", "/admin/users", ""))
+	if w.Code != 200 {
+		t.Fatalf("Status = %d, esperado 200", w.Code)
+	}
+
+	if !strings.Contains(w.Body.String(), "user2") || strings.Contains(w.Body.String(), "user1") {
+		t.Errorf("Listagem deveria conter apenas user2: %s", w.Body.String())
+	}
+}
@@ -19,7 +19,7 @@ func TestValidateUser_ValidUser(t *testing.T) {
 			"user1": "http://example.com/user1.json",
 		},
 	}
-	users := service.NewUsers(cfg)
+	users := service.NewUsers(cfg, nil)
 
 	// Cria contexto gin com parâmetro user
 	gin.SetMode(gin.TestMode)
@@ -48,7 +48,7 @@ func TestValidateUser_InvalidUser(t *testing.T) {
 			"user1": "http://example.com/user1.json",
 		},
 	}
-	users := service.NewUsers(cfg)
+	users := service.NewUsers(cfg, nil)
 
 	// Cria contexto gin com usuário inexistente
 	gin.SetMode(gin.TestMode)
@@ -84,3 +84,11 @@ func executeRequest(router *gin.Engine, req *http.Request) *httptest.ResponseRec
 	router.ServeHTTP(w, req)
 	return w
 }
+
+func newTestServerConfig() *config.Server {
+	return &config.Server{
+		UserTarges: map[string]string{
+			"user1": "http://example.com/user1.json",
+		},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -29,8 +30,17 @@ type Server struct {
 	commandHandler     *handlers.Command
 	targetHandler      *handlers.Target
 	matchHandler       *handlers.Match
+	batchHandler       *handlers.Batch
 	reportHandler      *handlers.Report
 	healthcheckHandler *handlers.Healthcheck
+	deviceHandler      *handlers.Device
+	accountHandler     *handlers.Account
+	userHandler        *handlers.User
+	eventsHandler      *handlers.Events
+	extensionHandler   *handlers.Extension
+
+	deviceAuth  gin.HandlerFunc
+	accountAuth gin.HandlerFunc
 
 	srv *http.Server
 }
@@ -49,17 +59,41 @@ func NewServer(config *config.Server) *Server {
 func (s *Server) initServices() {
 	log.Printf("[server.initServices] Initializing application services...")
 	commandService := service.NewCommand(s.dbConn)
-	targetService := service.NewTarget(s.config)
+	targetService := service.NewTarget(s.config, s.dbConn)
 	matchService := service.NewMatch(s.dbConn)
-	userService := service.NewUsers(s.config)
+	userService := service.NewUsers(s.config, targetService)
+	batchService := service.NewBatch(s.dbConn)
+	deviceService := service.NewDevice(s.dbConn)
+	accountService := service.NewAccount(s.dbConn)
+	eventsService := service.NewEvents()
+	extensionService := service.NewExtension(s.dbConn, targetService, commandService)
 	log.Printf("[server.initServices] All services initialized successfully")
 
+	if err := targetService.ImportConfigured(true); err != nil {
+		log.Printf("[server.initServices] Failed to import targets of configured users: %v", err)
+	}
+
+	targetService.SetEvents(eventsService)
+	targetService.SetCommands(commandService)
+	matchService.SetEvents(eventsService)
+	matchService.SetWeekStart(s.config.WeekStart)
+	matchService.SetTimezones(service.NewTimezones(s.config.UserTimezones))
+	batchService.SetEvents(eventsService)
+
 	log.Printf("[server.initServices] Initializing HTTP handlers...")
 	s.commandHandler = handlers.NewCommand(commandService, userService)
 	s.targetHandler = handlers.NewTarget(targetService, userService, matchService)
 	s.matchHandler = handlers.NewMatch(matchService, userService)
+	s.batchHandler = handlers.NewBatch(batchService, userService)
 	s.reportHandler = handlers.NewReport(targetService, userService, matchService, commandService)
-	s.healthcheckHandler = handlers.NewHealthcheck()
+	s.healthcheckHandler = handlers.NewHealthcheck(targetService)
+	s.deviceHandler = handlers.NewDevice(deviceService, userService)
+	s.deviceAuth = handlers.DeviceAuth(deviceService)
+	s.accountHandler = handlers.NewAccount(accountService, userService)
+	s.userHandler = handlers.NewUser(userService, targetService)
+	s.accountAuth = handlers.AccountAuth(accountService, s.config.AdminToken)
+	s.eventsHandler = handlers.NewEvents(eventsService, targetService, matchService, userService)
+	s.extensionHandler = handlers.NewExtension(extensionService, userService)
 	log.Printf("[server.initServices] All HTTP handlers initialized successfully")
 }
 
@@ -76,18 +110,65 @@ func (s *Server) Start() {
 	}
 
 	s.router = gin.Default()
-	s.router.GET("/targets/:user", s.targetHandler.GetTargets)
-	s.router.POST("/match/:user", s.matchHandler.InsertMatch)
-	s.router.POST("/command/:user", s.commandHandler.InsertCommand)
-	s.router.GET("/report/:user", s.reportHandler.GetReport)
 	s.router.GET("/healthcheck", s.healthcheckHandler.GetStatus)
 
+	clientApi := s.router.Group("", s.deviceAuth)
+	clientApi.GET("/targets/:user", s.targetHandler.GetTargets)
+	clientApi.POST("/match/:user", s.matchHandler.InsertMatch)
+	clientApi.POST("/command/:user", s.commandHandler.InsertCommand)
+	clientApi.POST("/batch/:user", s.batchHandler.InsertBatch)
+	clientApi.GET("/events/:user", s.eventsHandler.Stream)
+	clientApi.POST("/extensions/:user", s.extensionHandler.RequestExtension)
+
+	s.router.POST("/login", s.accountHandler.Login)
+
+	accountApi := s.router.Group("", s.accountAuth)
+	accountApi.POST("/logout", s.accountHandler.Logout)
+	accountApi.GET("/me", s.accountHandler.GetMe)
+	accountApi.GET("/report/:user", handlers.RequireRead(), s.reportHandler.GetReport)
+
+	adminApi := accountApi.Group("/admin")
+	adminApi.GET("/users", s.userHandler.GetUsers)
+	adminApi.POST("/users", handlers.RequireAdmin(), s.userHandler.CreateUser)
+	adminApi.GET("/users/:user/targets", handlers.RequireRead(), s.targetHandler.ListTargets)
+	adminApi.POST("/users/:user/targets", handlers.RequireManage(), s.targetHandler.CreateTarget)
+	adminApi.GET("/users/:user/targets/:id", handlers.RequireRead(), s.targetHandler.GetTarget)
+	adminApi.PUT("/users/:user/targets/:id", handlers.RequireManage(), s.targetHandler.UpdateTarget)
+	adminApi.DELETE("/users/:user/targets/:id", handlers.RequireManage(), s.targetHandler.DeleteTarget)
+	adminApi.GET("/users/:user/groups", handlers.RequireRead(), s.targetHandler.ListGroups)
+	adminApi.PUT("/users/:user/groups", handlers.RequireManage(), s.targetHandler.ReplaceGroups)
+	adminApi.GET("/users/:user/grants", handlers.RequireRead(), s.targetHandler.ListGrants)
+	adminApi.POST("/users/:user/grants", handlers.RequireManage(), s.targetHandler.CreateGrant)
+	adminApi.DELETE("/users/:user/grants/:id", handlers.RequireManage(), s.targetHandler.RevokeGrant)
+	adminApi.GET("/users/:user/extensions", handlers.RequireRead(), s.extensionHandler.GetExtensions)
+	adminApi.POST("/users/:user/extensions/:id/approve", handlers.RequireManage(), s.extensionHandler.ApproveExtension)
+	adminApi.POST("/users/:user/extensions/:id/deny", handlers.RequireManage(), s.extensionHandler.DenyExtension)
+	adminApi.GET("/users/:user/override", handlers.RequireRead(), s.targetHandler.GetOverride)
+	adminApi.PUT("/users/:user/override", handlers.RequireManage(), s.targetHandler.SetOverride)
+	adminApi.GET("/users/:user/calendar", handlers.RequireRead(), s.targetHandler.GetCalendar)
+	adminApi.PUT("/users/:user/calendar", handlers.RequireManage(), s.targetHandler.ReplaceCalendar)
+	adminApi.POST("/users/:user/calendar/ics", handlers.RequireManage(), s.targetHandler.ImportCalendar)
+	adminApi.POST("/users/:user/actions", handlers.RequireManage(), s.eventsHandler.PostAction)
+	adminApi.GET("/users/:user/devices", handlers.RequireRead(), s.deviceHandler.GetDevices)
+	adminApi.POST("/users/:user/devices", handlers.RequireManage(), s.deviceHandler.IssueDevice)
+	adminApi.DELETE("/devices/:id", s.deviceHandler.RevokeDevice)
+	adminApi.GET("/sources", handlers.RequireAdmin(), s.healthcheckHandler.GetSources)
+	adminApi.GET("/accounts", handlers.RequireAdmin(), s.accountHandler.GetAccounts)
+	adminApi.POST("/accounts", handlers.RequireAdmin(), s.accountHandler.CreateAccount)
+	adminApi.DELETE("/accounts/:id", handlers.RequireAdmin(), s.accountHandler.DeleteAccount)
+
 	log.Print("[server.Start] HTTP router configured with all endpoints")
 
+	// Event streams never end on their own: their requests are cancelled
+	// when shutting down so Shutdown does not wait for them.
+	baseCtx, cancelStreams := context.WithCancel(context.Background())
+
 	s.srv = &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", s.config.APIHost, s.config.APIPort),
-		Handler: s.router,
+		Addr:        fmt.Sprintf("%s:%d", s.config.APIHost, s.config.APIPort),
+		Handler:     s.router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
+	s.srv.RegisterOnShutdown(cancelStreams)
 
 	go func() {
 		log.Printf("[server.Start] HTTP server listening on %s:%d", s.config.APIHost, s.config.APIPort)
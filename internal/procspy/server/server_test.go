@@ -306,8 +306,10 @@ func TestNewServer_AllHandlersInitialized(t *testing.T) {
 		{"commandHandler", server.commandHandler},
 		{"targetHandler", server.targetHandler},
 		{"matchHandler", server.matchHandler},
+		{"batchHandler", server.batchHandler},
 		{"reportHandler", server.reportHandler},
 		{"healthcheckHandler", server.healthcheckHandler},
+		{"eventsHandler", server.eventsHandler},
 	}
 
 	for _, h := range handlers {
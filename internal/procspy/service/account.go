@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SESSION_TTL is how long a login stays valid, in seconds.
+const SESSION_TTL = 12 * 60 * 60
+
+const MIN_PASSWORD_LENGTH = 8
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+var ErrInvalidSession = errors.New("invalid session")
+var ErrInvalidAccount = errors.New("invalid account")
+var ErrAccountExists = errors.New("account already exists")
+
+type Account struct {
+	storage *storage.Account
+}
+
+func NewAccount(conn *storage.DbConnection) *Account {
+	log.Printf("[service.Account.NewAccount] Initializing account storage layer")
+
+	return &Account{
+		storage: storage.NewAccount(conn),
+	}
+}
+
+func (a *Account) Close() error {
+	log.Printf("[service.Account.Close] Closing account storage connection")
+	return a.storage.Close()
+}
+
+// CreateAccount registers an account. Only the bcrypt hash of the password
+// is stored.
+func (a *Account) CreateAccount(username string, password string, role string, children []string) (*domain.Account, error) {
+	log.Printf("[service.Account.CreateAccount] Creating account '%s' with role '%s'", username, role)
+
+	if username == "" || len(password) < MIN_PASSWORD_LENGTH || !domain.ValidRole(role) {
+		log.Printf("[service.Account.CreateAccount] Invalid account '%s': username, password of at least %d characters and a valid role are required", username, MIN_PASSWORD_LENGTH)
+		return nil, ErrInvalidAccount
+	}
+
+	existing, _, err := a.storage.GetAccountByUsername(username)
+
+	if err != nil {
+		log.Printf("[service.Account.CreateAccount] Failed to lookup account '%s': %v", username, err)
+		return nil, err
+	}
+
+	if existing != nil {
+		log.Printf("[service.Account.CreateAccount] Account '%s' already exists", username)
+		return nil, ErrAccountExists
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		log.Printf("[service.Account.CreateAccount] Failed to hash password of account '%s': %v", username, err)
+		return nil, err
+	}
+
+	account := domain.NewAccount(username, role, children)
+
+	err = a.storage.InsertAccount(account, string(hash))
+
+	if err != nil {
+		log.Printf("[service.Account.CreateAccount] Failed to store account '%s': %v", username, err)
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// Login checks a password and opens a session. The returned token is not
+// stored and cannot be recovered later.
+func (a *Account) Login(username string, password string) (string, *domain.Account, error) {
+	account, hash, err := a.storage.GetAccountByUsername(username)
+
+	if err != nil {
+		log.Printf("[service.Account.Login] Failed to lookup account '%s': %v", username, err)
+		return "", nil, err
+	}
+
+	if account == nil || bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		log.Printf("[service.Account.Login] Invalid credentials for account '%s'", username)
+		return "", nil, ErrInvalidCredentials
+	}
+
+	token, err := newToken()
+
+	if err != nil {
+		log.Printf("[service.Account.Login] Failed to generate session token: %v", err)
+		return "", nil, err
+	}
+
+	err = a.storage.InsertSession(HashToken(token), account.ID, SESSION_TTL)
+
+	if err != nil {
+		log.Printf("[service.Account.Login] Failed to store session of account '%s': %v", username, err)
+		return "", nil, err
+	}
+
+	log.Printf("[service.Account.Login] Account '%s' logged in", username)
+
+	return token, account, nil
+}
+
+// Authenticate returns the account of an open session.
+func (a *Account) Authenticate(token string) (*domain.Account, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+
+	account, err := a.storage.GetAccountBySession(HashToken(token))
+
+	if err != nil {
+		log.Printf("[service.Account.Authenticate] Failed to lookup session: %v", err)
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrInvalidSession
+	}
+
+	return account, nil
+}
+
+func (a *Account) Logout(token string) error {
+	return a.storage.DeleteSession(HashToken(token))
+}
+
+func (a *Account) GetAccounts() ([]*domain.Account, error) {
+	log.Printf("[service.Account.GetAccounts] Retrieving accounts")
+	return a.storage.GetAccounts()
+}
+
+func (a *Account) DeleteAccount(id int64) (bool, error) {
+	log.Printf("[service.Account.DeleteAccount] Deleting account %d", id)
+	return a.storage.DeleteAccount(id)
+}
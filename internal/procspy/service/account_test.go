@@ -0,0 +1,92 @@
+package service
+
+import (
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
+	"testing"
+)
+
+// TestAccount_CreateAccount testa criação de conta com senha protegida
+func TestAccount_CreateAccount(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewAccount(conn)
+
+	account, err := service.CreateAccount("parent", "password1", domain.ROLE_PARENT, []string{"user1"})
+	if err != nil {
+		t.Fatalf("CreateAccount() erro = %v", err)
+	}
+
+	if account.ID == 0 {
+		t.Error("ID não foi atribuído")
+	}
+
+	if _, err := service.CreateAccount("parent", "password1", domain.ROLE_PARENT, nil); err != ErrAccountExists {
+		t.Errorf("CreateAccount() erro = %v, esperado ErrAccountExists", err)
+	}
+
+	if _, err := service.CreateAccount("other", "short", domain.ROLE_VIEWER, nil); err != ErrInvalidAccount {
+		t.Errorf("CreateAccount() erro = %v, esperado ErrInvalidAccount", err)
+	}
+
+	if _, err := service.CreateAccount("other", "password1", "root", nil); err != ErrInvalidAccount {
+		t.Errorf("CreateAccount() erro = %v, esperado ErrInvalidAccount", err)
+	}
+}
+
+// TestAccount_Login testa login, autenticação e logout
+func TestAccount_Login(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewAccount(conn)
+	service.CreateAccount("parent", "password1", domain.ROLE_PARENT, []string{"user1"})
+
+	if _, _, err := service.Login("parent", "wrong"); err != ErrInvalidCredentials {
+		t.Errorf("Login() erro = %v, esperado ErrInvalidCredentials", err)
+	}
+
+	if _, _, err := service.Login("unknown", "password1"); err != ErrInvalidCredentials {
+		t.Errorf("Login() erro = %v, esperado ErrInvalidCredentials", err)
+	}
+
+	token, _, err := service.Login("parent", "password1")
+	if err != nil {
+		t.Fatalf("Login() erro = %v", err)
+	}
+
+	account, err := service.Authenticate(token)
+	if err != nil {
+		t.Fatalf("Authenticate() erro = %v", err)
+	}
+
+	if account.Username != "parent" || !account.CanRead("user1") || account.CanRead("user2") {
+		t.Errorf("Conta autenticada incorreta: %+v", account)
+	}
+
+	service.Logout(token)
+
+	if _, err := service.Authenticate(token); err != ErrInvalidSession {
+		t.Errorf("Authenticate() erro = %v, esperado ErrInvalidSession após logout", err)
+	}
+}
+
+// TestAccount_DeleteAccount testa que remover a conta encerra as sessões
+func TestAccount_DeleteAccount(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewAccount(conn)
+	account, _ := service.CreateAccount("viewer", "password1", domain.ROLE_VIEWER, nil)
+	token, _, _ := service.Login("viewer", "password1")
+
+	found, err := service.DeleteAccount(account.ID)
+	if err != nil || !found {
+		t.Fatalf("DeleteAccount() = %v, %v", found, err)
+	}
+
+	if _, err := service.Authenticate(token); err != ErrInvalidSession {
+		t.Errorf("Authenticate() erro = %v, esperado ErrInvalidSession", err)
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"log"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
+)
+
+type Batch struct {
+	storage *storage.Batch
+	events  *Events
+}
+
+func NewBatch(conn *storage.DbConnection) *Batch {
+	log.Printf("[service.Batch.NewBatch] Initializing batch storage layer")
+
+	return &Batch{
+		storage: storage.NewBatch(conn),
+	}
+}
+
+// SetEvents enables pushing usage updates to the connected clients.
+func (b *Batch) SetEvents(events *Events) {
+	b.events = events
+}
+
+// InsertBatch stores the matches and commands of a batch for a user. Items
+// are forced to the given user so a batch can only write usage for the
+// validated user.
+func (b *Batch) InsertBatch(user string, batch *domain.Batch) (*domain.BatchResult, error) {
+	log.Printf("[service.Batch.InsertBatch] Inserting batch of %d matches and %d commands (user: '%s')", len(batch.Matches), len(batch.Commands), user)
+
+	for _, match := range batch.Matches {
+		match.User = user
+		capElapsed(match)
+	}
+
+	for _, cmd := range batch.Commands {
+		cmd.User = user
+	}
+
+	result, err := b.storage.InsertBatch(batch)
+
+	if err == nil && len(batch.Matches) > 0 {
+		b.events.Publish(domain.NewEvent(domain.EVENT_REMAINING, user))
+	}
+
+	return result, err
+}
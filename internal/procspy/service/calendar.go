@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"procspy/internal/procspy/domain"
+)
+
+// GetCalendar returns the holiday and vacation entries of a user.
+func (t *Target) GetCalendar(user string) (domain.Calendar, error) {
+	return t.storage.GetCalendar(user)
+}
+
+// ReplaceCalendar swaps the calendar of a user and pushes it to the
+// connected clients.
+func (t *Target) ReplaceCalendar(user string, calendar domain.Calendar) error {
+	log.Printf("[service.Target.ReplaceCalendar] Replacing %d calendar entries for user '%s'", len(calendar), user)
+
+	if err := calendar.Validate(); err != nil {
+		log.Printf("[service.Target.ReplaceCalendar] Invalid calendar for user '%s': %v", user, err)
+		return fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	err := t.storage.ReplaceCalendar(user, calendar)
+
+	if err == nil {
+		t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+	}
+
+	return err
+}
+
+// ImportCalendar appends the events of an iCalendar file to the calendar of
+// a user, all of them following the same profile. It returns the imported
+// entries.
+func (t *Target) ImportCalendar(user string, r io.Reader, profile string) (domain.Calendar, error) {
+	imported, err := domain.ParseICS(r, profile, t.timezones.Location(user))
+
+	if err != nil {
+		log.Printf("[service.Target.ImportCalendar] Invalid iCalendar file for user '%s': %v", user, err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	calendar, err := t.storage.GetCalendar(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.ReplaceCalendar(user, append(calendar, imported...)); err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
+)
+
+const DEVICE_TOKEN_BYTES = 32
+
+var ErrInvalidToken = errors.New("invalid device token")
+
+type Device struct {
+	storage *storage.Device
+}
+
+func NewDevice(conn *storage.DbConnection) *Device {
+	log.Printf("[service.Device.NewDevice] Initializing device storage layer")
+
+	return &Device{
+		storage: storage.NewDevice(conn),
+	}
+}
+
+func (d *Device) Close() error {
+	log.Printf("[service.Device.Close] Closing device storage connection")
+	return d.storage.Close()
+}
+
+// IssueDevice registers a new device for a user and returns it with its
+// plain token. The token is not stored and cannot be recovered later.
+func (d *Device) IssueDevice(user string, name string) (*domain.Device, error) {
+	log.Printf("[service.Device.IssueDevice] Issuing token for device '%s' (user: '%s')", name, user)
+
+	token, err := newToken()
+
+	if err != nil {
+		log.Printf("[service.Device.IssueDevice] Failed to generate token: %v", err)
+		return nil, err
+	}
+
+	device := domain.NewDevice(user, name)
+
+	err = d.storage.InsertDevice(device, HashToken(token))
+
+	if err != nil {
+		log.Printf("[service.Device.IssueDevice] Failed to store device '%s' for user '%s': %v", name, user, err)
+		return nil, err
+	}
+
+	device.Token = token
+
+	return device, nil
+}
+
+// Authenticate returns the active device that owns a token.
+func (d *Device) Authenticate(token string) (*domain.Device, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	device, err := d.storage.GetDeviceByTokenHash(HashToken(token))
+
+	if err != nil {
+		log.Printf("[service.Device.Authenticate] Failed to lookup device token: %v", err)
+		return nil, err
+	}
+
+	if device == nil || device.IsRevoked() {
+		return nil, ErrInvalidToken
+	}
+
+	d.storage.TouchDevice(device.ID)
+
+	return device, nil
+}
+
+func (d *Device) GetDevices(user string) ([]*domain.Device, error) {
+	log.Printf("[service.Device.GetDevices] Retrieving devices for user '%s'", user)
+	return d.storage.GetDevices(user)
+}
+
+func (d *Device) GetDevice(id int64) (*domain.Device, error) {
+	return d.storage.GetDevice(id)
+}
+
+func (d *Device) RevokeDevice(id int64) (bool, error) {
+	log.Printf("[service.Device.RevokeDevice] Revoking device %d", id)
+	return d.storage.RevokeDevice(id)
+}
+
+// HashToken is the only form in which tokens are persisted.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, DEVICE_TOKEN_BYTES)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
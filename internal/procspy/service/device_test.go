@@ -0,0 +1,85 @@
+package service
+
+import (
+	"procspy/internal/procspy/storage"
+	"testing"
+)
+
+// TestDevice_IssueDevice testa emissão de token de dispositivo
+func TestDevice_IssueDevice(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewDevice(conn)
+
+	device, err := service.IssueDevice("user1", "notebook")
+	if err != nil {
+		t.Fatalf("IssueDevice() erro = %v", err)
+	}
+
+	if device.Token == "" {
+		t.Fatal("Token não foi gerado")
+	}
+
+	if device.ID == 0 {
+		t.Error("ID não foi atribuído")
+	}
+
+	// Token é armazenado apenas como hash
+	devices, _ := service.GetDevices("user1")
+	if len(devices) != 1 || devices[0].Token != "" {
+		t.Errorf("Listagem não deveria expor token: %+v", devices)
+	}
+}
+
+// TestDevice_Authenticate testa autenticação e revogação de dispositivo
+func TestDevice_Authenticate(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewDevice(conn)
+	device, _ := service.IssueDevice("user1", "notebook")
+
+	authenticated, err := service.Authenticate(device.Token)
+	if err != nil {
+		t.Fatalf("Authenticate() erro = %v", err)
+	}
+
+	if authenticated.User != "user1" {
+		t.Errorf("User = %s, esperado user1", authenticated.User)
+	}
+
+	if _, err := service.Authenticate("invalid"); err != ErrInvalidToken {
+		t.Errorf("Authenticate() com token inválido erro = %v, esperado ErrInvalidToken", err)
+	}
+
+	if _, err := service.Authenticate(""); err != ErrInvalidToken {
+		t.Errorf("Authenticate() com token vazio erro = %v, esperado ErrInvalidToken", err)
+	}
+
+	found, err := service.RevokeDevice(device.ID)
+	if err != nil || !found {
+		t.Fatalf("RevokeDevice() = %v, %v", found, err)
+	}
+
+	if _, err := service.Authenticate(device.Token); err != ErrInvalidToken {
+		t.Errorf("Authenticate() com token revogado erro = %v, esperado ErrInvalidToken", err)
+	}
+
+	// Revogar novamente retorna não encontrado
+	found, _ = service.RevokeDevice(device.ID)
+	if found {
+		t.Error("RevokeDevice() de dispositivo já revogado deveria retornar false")
+	}
+}
+
+// TestHashToken testa que o hash é determinístico e não expõe o token
+func TestHashToken(t *testing.T) {
+	if HashToken("abc") != HashToken("abc") {
+		t.Error("HashToken deveria ser determinístico")
+	}
+
+	if HashToken("abc") == "abc" || HashToken("abc") == HashToken("abd") {
+		t.Error("HashToken retornou valor inesperado")
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"log"
+	"procspy/internal/procspy/domain"
+	"sync"
+)
+
+// EVENTS_BUFFER is how many events wait for a slow subscriber before new
+// ones are dropped. Clients still poll, so a dropped event only delays it.
+const EVENTS_BUFFER = 16
+
+// Events delivers pushed events to the clients connected for each user.
+// A nil *Events is valid and discards everything, so services can publish
+// without knowing whether push is enabled.
+type Events struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan *domain.Event]struct{}
+}
+
+func NewEvents() *Events {
+	log.Printf("[service.Events.NewEvents] Initializing event broker")
+
+	return &Events{
+		subscribers: make(map[string]map[chan *domain.Event]struct{}),
+	}
+}
+
+// Subscribe registers a client of a user. The returned function must be
+// called when the client disconnects.
+func (e *Events) Subscribe(user string) (<-chan *domain.Event, func()) {
+	ch := make(chan *domain.Event, EVENTS_BUFFER)
+
+	e.mu.Lock()
+	if e.subscribers[user] == nil {
+		e.subscribers[user] = make(map[chan *domain.Event]struct{})
+	}
+	e.subscribers[user][ch] = struct{}{}
+	e.mu.Unlock()
+
+	log.Printf("[service.Events.Subscribe] Client subscribed to events of user '%s'", user)
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		delete(e.subscribers[user], ch)
+		if len(e.subscribers[user]) == 0 {
+			delete(e.subscribers, user)
+		}
+
+		log.Printf("[service.Events.Subscribe] Client unsubscribed from events of user '%s'", user)
+	}
+}
+
+// Publish sends an event to every client of its user without blocking and
+// returns how many clients received it.
+func (e *Events) Publish(event *domain.Event) int {
+	if e == nil {
+		return 0
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delivered := 0
+
+	for ch := range e.subscribers[event.User] {
+		select {
+		case ch <- event:
+			delivered++
+		default:
+			log.Printf("[service.Events.Publish] Subscriber of user '%s' is not keeping up, dropping '%s' event", event.User, event.Type)
+		}
+	}
+
+	return delivered
+}
+
+// Subscribers returns how many clients of a user are connected.
+func (e *Events) Subscribers(user string) int {
+	if e == nil {
+		return 0
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return len(e.subscribers[user])
+}
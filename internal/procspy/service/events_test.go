@@ -0,0 +1,86 @@
+package service
+
+import (
+	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
+	"testing"
+)
+
+// TestEvents_PublishSubscribe testa entrega de eventos por usuário
+func TestEvents_PublishSubscribe(t *testing.T) {
+	events := NewEvents()
+
+	ch, unsubscribe := events.Subscribe("user1")
+
+	if delivered := events.Publish(domain.NewEvent(domain.EVENT_TARGETS, "user2")); delivered != 0 {
+		t.Errorf("Publish() para outro usuário = %d, esperado 0", delivered)
+	}
+
+	if delivered := events.Publish(domain.NewAction("user1", domain.ACTION_KILL, "games")); delivered != 1 {
+		t.Fatalf("Publish() = %d, esperado 1", delivered)
+	}
+
+	event := <-ch
+	if event.Action != domain.ACTION_KILL || event.Target != "games" {
+		t.Errorf("Evento = %+v, esperado kill de games", event)
+	}
+
+	unsubscribe()
+
+	if events.Subscribers("user1") != 0 {
+		t.Errorf("Subscribers() = %d, esperado 0 após unsubscribe", events.Subscribers("user1"))
+	}
+
+	// Broker nil descarta eventos
+	var disabled *Events
+	if disabled.Publish(domain.NewEvent(domain.EVENT_TARGETS, "user1")) != 0 {
+		t.Error("Publish() em broker nil deveria retornar 0")
+	}
+}
+
+// TestEvents_SlowSubscriber testa que um cliente lento não bloqueia a publicação
+func TestEvents_SlowSubscriber(t *testing.T) {
+	events := NewEvents()
+	_, unsubscribe := events.Subscribe("user1")
+	defer unsubscribe()
+
+	for range EVENTS_BUFFER {
+		events.Publish(domain.NewEvent(domain.EVENT_REMAINING, "user1"))
+	}
+
+	if delivered := events.Publish(domain.NewEvent(domain.EVENT_REMAINING, "user1")); delivered != 0 {
+		t.Errorf("Publish() com buffer cheio = %d, esperado 0", delivered)
+	}
+}
+
+// TestEvents_PublishedByServices testa eventos gerados por alterações de targets e uso
+func TestEvents_PublishedByServices(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	events := NewEvents()
+	targets := NewTarget(&config.Server{}, conn)
+	targets.SetEvents(events)
+	matches := NewMatch(conn)
+	matches.SetEvents(events)
+
+	ch, unsubscribe := events.Subscribe("user1")
+	defer unsubscribe()
+
+	if err := targets.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "steam"}); err != nil {
+		t.Fatalf("CreateTarget() erro = %v", err)
+	}
+
+	if event := <-ch; event.Type != domain.EVENT_TARGETS {
+		t.Errorf("Evento = %s, esperado %s", event.Type, domain.EVENT_TARGETS)
+	}
+
+	if err := matches.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam", 30)); err != nil {
+		t.Fatalf("InsertMatch() erro = %v", err)
+	}
+
+	if event := <-ch; event.Type != domain.EVENT_REMAINING {
+		t.Errorf("Evento = %s, esperado %s", event.Type, domain.EVENT_REMAINING)
+	}
+}
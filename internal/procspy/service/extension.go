@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
+	"time"
+)
+
+const EXTENSION_COMMAND_SOURCE = "Extension"
+
+var ErrInvalidExtension = errors.New("invalid extension request")
+var ErrExtensionDecided = errors.New("extension request already decided")
+
+// Extension queues the requests for more time made on the clients. An
+// approval becomes a grant of the target service, which pushes it to the
+// clients; requests and decisions are recorded in the command log.
+type Extension struct {
+	storage  *storage.Extension
+	targets  *Target
+	commands *Command
+}
+
+func NewExtension(conn *storage.DbConnection, targets *Target, commands *Command) *Extension {
+	log.Printf("[service.Extension.NewExtension] Initializing extension storage layer")
+
+	return &Extension{
+		storage:  storage.NewExtension(conn),
+		targets:  targets,
+		commands: commands,
+	}
+}
+
+func (e *Extension) Close() error {
+	log.Printf("[service.Extension.Close] Closing extension storage connection")
+	return e.storage.Close()
+}
+
+// RequestExtension queues a request of a user for a parent to decide.
+func (e *Extension) RequestExtension(user string, extension *domain.Extension) error {
+	extension.User = user
+
+	if err := extension.Validate(); err != nil {
+		log.Printf("[service.Extension.RequestExtension] Invalid extension request for user '%s': %v", user, err)
+		return fmt.Errorf("%w: %v", ErrInvalidExtension, err)
+	}
+
+	targets, err := e.targets.storage.GetTargets(user)
+
+	if err != nil {
+		return err
+	}
+
+	if !hasTarget(targets, extension.Target) {
+		log.Printf("[service.Extension.RequestExtension] Extension request for unknown target '%s' of user '%s'", extension.Target, user)
+		return fmt.Errorf("%w: unknown target '%s'", ErrInvalidExtension, extension.Target)
+	}
+
+	if err := e.storage.InsertExtension(extension); err != nil {
+		return err
+	}
+
+	log.Printf("[service.Extension.RequestExtension] Extension request %d queued for target '%s' of user '%s'", extension.ID, extension.Target, user)
+
+	e.record(extension, fmt.Sprintf("extension request %d for %s", extension.ID, formatSeconds(extension.Duration.Seconds())), extension.Reason)
+
+	return nil
+}
+
+// GetExtensions returns the requests of a user with a status, or all of
+// them when status is empty.
+func (e *Extension) GetExtensions(user string, status string) ([]*domain.Extension, error) {
+	return e.storage.GetExtensions(user, status)
+}
+
+// ApproveExtension turns a pending request into a grant. A zero duration
+// keeps the requested one and a zero expiry lasts until the end of today.
+// It returns nil when the request does not exist.
+func (e *Extension) ApproveExtension(user string, id int64, duration domain.Duration, expiresAt time.Time, by string, note string) (*domain.Extension, error) {
+	extension, err := e.pending(user, id)
+
+	if err != nil || extension == nil {
+		return nil, err
+	}
+
+	grant := extension.NewGrant(duration)
+	grant.ExpiresAt = expiresAt
+	grant.CreatedBy = by
+
+	if err := e.targets.AddGrant(user, grant); err != nil {
+		return nil, err
+	}
+
+	extension.Status = domain.EXTENSION_APPROVED
+	extension.DecidedBy = by
+	extension.Note = note
+	extension.GrantID = grant.ID
+
+	found, err := e.storage.DecideExtension(extension)
+
+	if err != nil || !found {
+		// Decided meanwhile by someone else: the grant must not stay.
+		e.targets.RevokeGrant(user, grant.ID)
+
+		if err == nil {
+			err = ErrExtensionDecided
+		}
+
+		return nil, err
+	}
+
+	log.Printf("[service.Extension.ApproveExtension] Extension request %d of user '%s' approved by '%s' with grant %d", id, user, by, grant.ID)
+
+	e.record(extension, fmt.Sprintf("extension request %d approved: %s granted until %s", id, formatSeconds(grant.Duration.Seconds()), grant.ExpiresAt.Format(time.RFC3339)), auditLog("decided", by, note))
+
+	return extension, nil
+}
+
+// DenyExtension refuses a pending request. It returns nil when the request
+// does not exist.
+func (e *Extension) DenyExtension(user string, id int64, by string, note string) (*domain.Extension, error) {
+	extension, err := e.pending(user, id)
+
+	if err != nil || extension == nil {
+		return nil, err
+	}
+
+	extension.Status = domain.EXTENSION_DENIED
+	extension.DecidedBy = by
+	extension.Note = note
+
+	found, err := e.storage.DecideExtension(extension)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrExtensionDecided
+	}
+
+	log.Printf("[service.Extension.DenyExtension] Extension request %d of user '%s' denied by '%s'", id, user, by)
+
+	e.record(extension, fmt.Sprintf("extension request %d denied", id), auditLog("decided", by, note))
+
+	return extension, nil
+}
+
+func (e *Extension) pending(user string, id int64) (*domain.Extension, error) {
+	extension, err := e.storage.GetExtension(user, id)
+
+	if err != nil || extension == nil {
+		return nil, err
+	}
+
+	if !extension.IsPending() {
+		return nil, ErrExtensionDecided
+	}
+
+	return extension, nil
+}
+
+// record adds a request or a decision to the command log of the user, next
+// to the commands run by the clients. Failures are only logged: the request
+// itself is already stored.
+func (e *Extension) record(extension *domain.Extension, commandLine string, commandLog string) {
+	if e.commands == nil {
+		return
+	}
+
+	cmd := domain.NewCommand(extension.User, extension.Target, commandLine, extension.Status)
+	cmd.Source = EXTENSION_COMMAND_SOURCE
+	cmd.CommandLog = commandLog
+
+	if err := e.commands.InsertCommand(cmd); err != nil {
+		log.Printf("[service.Extension.record] Failed to record extension request %d of user '%s': %v", extension.ID, extension.User, err)
+	}
+}
+
+// auditLog describes who did something, and why, for the command log.
+func auditLog(action string, by string, note string) string {
+	switch {
+	case by == "":
+		return note
+	case note == "":
+		return fmt.Sprintf("%s by '%s'", action, by)
+	}
+
+	return fmt.Sprintf("%s by '%s': %s", action, by, note)
+}
+
+func formatSeconds(seconds float64) string {
+	if seconds <= 0 {
+		return "unspecified time"
+	}
+
+	return (time.Duration(seconds) * time.Second).String()
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"procspy/internal/procspy/domain"
+	"time"
+)
+
+// AddGrant stores extra time for a target of a user and pushes the new
+// limits to the connected clients.
+func (t *Target) AddGrant(user string, grant *domain.Grant) error {
+	now := t.timezones.Now(user)
+
+	grant.User = user
+	grant.ApplyDefaults(now)
+
+	if err := grant.Validate(now); err != nil {
+		log.Printf("[service.Target.AddGrant] Invalid grant for user '%s': %v", user, err)
+		return fmt.Errorf("%w: %v", ErrInvalidGrant, err)
+	}
+
+	targets, err := t.storage.GetTargets(user)
+
+	if err != nil {
+		return err
+	}
+
+	if !hasTarget(targets, grant.Target) {
+		log.Printf("[service.Target.AddGrant] Grant for unknown target '%s' of user '%s'", grant.Target, user)
+		return fmt.Errorf("%w: unknown target '%s'", ErrInvalidGrant, grant.Target)
+	}
+
+	log.Printf("[service.Target.AddGrant] Granting %.0fs of target '%s' to user '%s' until %s", grant.Duration.Seconds(), grant.Target, user, grant.ExpiresAt.Format(time.RFC3339))
+
+	if err := t.grants.InsertGrant(grant); err != nil {
+		return err
+	}
+
+	t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+
+	return nil
+}
+
+// GetGrants returns the grants of a user that did not expire before today,
+// revoked ones included.
+func (t *Target) GetGrants(user string) ([]*domain.Grant, error) {
+	return t.grants.GetGrants(user, t.timezones.Today(user))
+}
+
+// GetQuotaGrants returns the grants of a user that did not expire before the
+// current week or month, revoked ones included, which are the grants that
+// may add time to today or to the quotas. See domain.TargetList.ApplyGrants.
+func (t *Target) GetQuotaGrants(user string) ([]*domain.Grant, error) {
+	now := t.timezones.Now(user)
+	since := domain.WeekStart(now, t.weekStart)
+
+	if month := domain.MonthStart(now); month.Before(since) {
+		since = month
+	}
+
+	return t.grants.GetGrants(user, since)
+}
+
+// RevokeGrant cancels a grant of a user. It returns false when the grant
+// does not exist or was already revoked.
+func (t *Target) RevokeGrant(user string, id int64) (bool, error) {
+	log.Printf("[service.Target.RevokeGrant] Revoking grant %d of user '%s'", id, user)
+
+	found, err := t.grants.RevokeGrant(user, id)
+
+	if found {
+		t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+	}
+
+	return found, err
+}
+
+func hasTarget(targets []*domain.Target, name string) bool {
+	for _, target := range targets {
+		if target.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
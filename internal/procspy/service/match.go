@@ -4,10 +4,14 @@ import (
 	"log"
 	"procspy/internal/procspy/domain"
 	"procspy/internal/procspy/storage"
+	"time"
 )
 
 type Match struct {
-	storage *storage.Match
+	storage   *storage.Match
+	events    *Events
+	weekStart time.Weekday
+	timezones Timezones
 }
 
 var MATCH_MAX_ELAPSED float64 = 120
@@ -29,6 +33,36 @@ func NewMatch(conn *storage.DbConnection) *Match {
 	return ret
 }
 
+// SetEvents enables pushing usage updates to the connected clients.
+func (m *Match) SetEvents(events *Events) {
+	m.events = events
+}
+
+// SetWeekStart sets the first day of the week of weekly quotas (0 is
+// sunday).
+func (m *Match) SetWeekStart(day int) {
+	if day < 0 || day > 6 {
+		log.Printf("[service.Match.SetWeekStart] Invalid week start %d, weeks start on sunday", day)
+	}
+
+	m.weekStart = weekStart(day)
+}
+
+// weekStart is the first day of the week of a configured week_start, or
+// sunday when it is invalid.
+func weekStart(day int) time.Weekday {
+	if day < 0 || day > 6 {
+		return time.Sunday
+	}
+
+	return time.Weekday(day)
+}
+
+// SetTimezones sets the zones whose midnight starts the days of the users.
+func (m *Match) SetTimezones(timezones Timezones) {
+	m.timezones = timezones
+}
+
 func (m *Match) Close() error {
 	log.Printf("[service.Match.Close] Closing match storage connection")
 	return m.storage.Close()
@@ -37,16 +71,26 @@ func (m *Match) Close() error {
 func (m *Match) InsertMatch(match *domain.Match) error {
 	log.Printf("[service.Match.InsertMatch] Inserting match for pattern '%s' (user: '%s')", match.Pattern, match.User)
 
+	capElapsed(match)
+
+	err := m.storage.InsertMatch(match)
+
+	if err == nil {
+		m.events.Publish(domain.NewEvent(domain.EVENT_REMAINING, match.User))
+	}
+
+	return err
+}
+
+func capElapsed(match *domain.Match) {
 	if match.Elapsed > MATCH_MAX_ELAPSED {
 		log.Printf("[service.Match.InsertMatch] Match elapsed time exceeds maximum allowed (%f > %f), capping to maximum", match.Elapsed, MATCH_MAX_ELAPSED)
 		match.Elapsed = MATCH_MAX_ELAPSED
 	}
-
-	return m.storage.InsertMatch(match)
 }
 
 func (m *Match) GetMatches(user string) (map[string]float64, error) {
-	data, err := m.storage.GetMatches(user)
+	data, err := m.storage.GetMatches(user, m.timezones.Today(user))
 
 	if err != nil {
 		log.Printf("[service.Match.GetMatches] Failed to retrieve matches for user '%s': %v", user, err)
@@ -56,7 +100,7 @@ func (m *Match) GetMatches(user string) (map[string]float64, error) {
 }
 
 func (m *Match) GetMatchesInfo(user string) (map[string]*domain.MatchInfo, error) {
-	data, err := m.storage.GetMatchesInfo(user)
+	data, err := m.storage.GetMatchesInfo(user, m.timezones.Today(user))
 
 	if err != nil {
 		log.Printf("[service.Match.GetMatchesInfo] Failed to retrieve match information for user '%s': %v", user, err)
@@ -64,3 +108,61 @@ func (m *Match) GetMatchesInfo(user string) (map[string]*domain.MatchInfo, error
 
 	return data, err
 }
+
+// GetGroupsElapsed returns today's usage of each group of a target list, as
+// the union of the usage of its members.
+func (m *Match) GetGroupsElapsed(user string, targets *domain.TargetList) (map[string]float64, error) {
+	ret := make(map[string]float64, len(targets.Groups))
+	today := m.timezones.Today(user)
+
+	for _, group := range targets.Groups {
+		elapsed, err := m.storage.GetUnionElapsed(user, targets.Members(group.Name), today)
+
+		if err != nil {
+			log.Printf("[service.Match.GetGroupsElapsed] Failed to retrieve usage of group '%s' for user '%s': %v", group.Name, user, err)
+			return nil, err
+		}
+
+		ret[group.Name] = elapsed
+	}
+
+	return ret, nil
+}
+
+// GetQuotaElapsed returns the usage of each target of a user in the current
+// week and month, today included.
+func (m *Match) GetQuotaElapsed(user string) (map[string]float64, map[string]float64, error) {
+	now := m.timezones.Now(user)
+
+	weekly, err := m.storage.GetElapsedSince(user, domain.WeekStart(now, m.weekStart))
+
+	if err != nil {
+		log.Printf("[service.Match.GetQuotaElapsed] Failed to retrieve weekly usage for user '%s': %v", user, err)
+		return nil, nil, err
+	}
+
+	monthly, err := m.storage.GetElapsedSince(user, domain.MonthStart(now))
+
+	if err != nil {
+		log.Printf("[service.Match.GetQuotaElapsed] Failed to retrieve monthly usage for user '%s': %v", user, err)
+		return nil, nil, err
+	}
+
+	return weekly, monthly, nil
+}
+
+// GetDailyElapsed returns the usage of each target of a user per day
+// (YYYY-MM-DD) from the day of a moment on, today included, with days in
+// the zone of the user.
+func (m *Match) GetDailyElapsed(user string, since time.Time) (map[string]map[string]float64, error) {
+	since = since.In(m.timezones.Now(user).Location())
+	since = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, since.Location())
+
+	data, err := m.storage.GetDailyElapsed(user, since)
+
+	if err != nil {
+		log.Printf("[service.Match.GetDailyElapsed] Failed to retrieve daily usage for user '%s': %v", user, err)
+	}
+
+	return data, err
+}
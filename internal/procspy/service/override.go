@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"procspy/internal/procspy/domain"
+	"time"
+)
+
+const OVERRIDE_COMMAND_SOURCE = "Override"
+
+// GetOverride returns the override of a user in effect now, or nil when the
+// user follows the rules of the targets.
+func (t *Target) GetOverride(user string) (*domain.Override, error) {
+	override, err := t.overrides.GetOverride(user)
+
+	if err != nil || override.ModeAt(time.Now()) == domain.OVERRIDE_NORMAL {
+		return nil, err
+	}
+
+	return override, nil
+}
+
+// SetOverride changes the mode of a user, pushes it to the connected
+// clients and records the change in the command log.
+func (t *Target) SetOverride(user string, override *domain.Override) error {
+	override.User = user
+
+	if override.Mode == domain.OVERRIDE_NORMAL {
+		override.Until = time.Time{}
+	}
+
+	if err := override.Validate(time.Now()); err != nil {
+		log.Printf("[service.Target.SetOverride] Invalid override for user '%s': %v", user, err)
+		return fmt.Errorf("%w: %v", ErrInvalidOverride, err)
+	}
+
+	log.Printf("[service.Target.SetOverride] Setting user '%s' to %s by '%s'", user, override.Describe(), override.CreatedBy)
+
+	if err := t.overrides.SetOverride(override); err != nil {
+		return err
+	}
+
+	t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+	t.auditOverride(override)
+
+	return nil
+}
+
+// auditOverride records an override change in the command log. Failures
+// are only logged: the override is already stored.
+func (t *Target) auditOverride(override *domain.Override) {
+	if t.commands == nil {
+		return
+	}
+
+	cmd := domain.NewCommand(override.User, "override", "mode "+override.Describe(), override.Mode)
+	cmd.Source = OVERRIDE_COMMAND_SOURCE
+	cmd.CommandLog = auditLog("set", override.CreatedBy, override.Reason)
+
+	if err := t.commands.InsertCommand(cmd); err != nil {
+		log.Printf("[service.Target.auditOverride] Failed to record override of user '%s': %v", override.User, err)
+	}
+}
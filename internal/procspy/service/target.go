@@ -1,78 +1,522 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
 	"procspy/internal/procspy/config"
 	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
+	"strings"
+	"sync"
+	"time"
 )
 
+// DEFAULT_TARGETS_TTL is how long, in seconds, a remote target list is used
+// before being revalidated.
+const DEFAULT_TARGETS_TTL = 300
+const TARGETS_FETCH_TIMEOUT = 10 * time.Second
+
+var ErrInvalidTarget = errors.New("invalid target")
+var ErrTargetExists = errors.New("target already exists")
+var ErrInvalidGroup = errors.New("invalid group")
+var ErrInvalidGrant = errors.New("invalid grant")
+var ErrInvalidOverride = errors.New("invalid override")
+var ErrInvalidCalendar = errors.New("invalid calendar")
+
+// Target serves the targets stored in the database. Users configured in
+// user_targets with an URL follow that URL: the list is revalidated when
+// its TTL expires and the stored targets are the last known good copy.
 type Target struct {
-	urls map[string]string
+	urls      map[string]string
+	storage   *storage.Target
+	grants    *storage.Grant
+	overrides *storage.Override
+	ttl       time.Duration
+	client    *http.Client
+	mu        sync.Mutex
+	checked   map[string]time.Time
+	locks     sync.Map
+	events    *Events
+	commands  *Command
+	timezones Timezones
+	allowlist []string
+	weekStart time.Weekday
+}
+
+type remoteTargets struct {
+	body         string
+	etag         string
+	lastModified string
+	notModified  bool
 }
 
-func NewTarget(config *config.Server) *Target {
+func NewTarget(config *config.Server, conn *storage.DbConnection) *Target {
+	log.Printf("[service.Target.NewTarget] Initializing target storage layer")
+
+	ttl := config.TargetsTTL
+	if ttl <= 0 {
+		ttl = DEFAULT_TARGETS_TTL
+	}
+
 	return &Target{
-		urls: config.UserTarges,
+		urls:      config.UserTarges,
+		storage:   storage.NewTarget(conn),
+		grants:    storage.NewGrant(conn),
+		overrides: storage.NewOverride(conn),
+		ttl:       time.Duration(ttl) * time.Second,
+		client:    &http.Client{Timeout: TARGETS_FETCH_TIMEOUT},
+		checked:   make(map[string]time.Time),
+		timezones: NewTimezones(config.UserTimezones),
+		allowlist: config.Allowlist,
+		weekStart: weekStart(config.WeekStart),
 	}
 }
 
+// SetEvents enables pushing target changes to the connected clients.
+func (t *Target) SetEvents(events *Events) {
+	t.events = events
+}
+
+// SetCommands enables the audit of override changes in the command log.
+func (t *Target) SetCommands(commands *Command) {
+	t.commands = commands
+}
+
+func (t *Target) Close() error {
+	log.Printf("[service.Target.Close] Closing target storage connection")
+	return t.storage.Close()
+}
+
 func (t *Target) GetTargets(user string) (*domain.TargetList, error) {
-	ret := &domain.TargetList{
-		Targets: []*domain.Target{},
+	if url, ok := t.urls[user]; ok && isUrl(url) {
+		if _, err := t.refresh(user, url, false); err != nil {
+			log.Printf("[service.Target.GetTargets] Remote targets of user '%s' unavailable, using last known good copy: %v", user, err)
+		}
 	}
 
-	for k, v := range t.urls {
-		if k == user {
-			data, err := t.getFromUrl(v)
+	targets, err := t.storage.GetTargets(user)
 
-			if err != nil {
-				log.Printf("[service.Target.GetTargets] Failed to fetch targets from URL '%s' for user '%s': %v", v, user, err)
-				return nil, err
-			}
+	if err != nil {
+		log.Printf("[service.Target.GetTargets] Failed to retrieve targets for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	groups, err := t.storage.GetGroups(user)
+
+	if err != nil {
+		log.Printf("[service.Target.GetTargets] Failed to retrieve groups for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	calendar, err := t.storage.GetCalendar(user)
+
+	if err != nil {
+		log.Printf("[service.Target.GetTargets] Failed to retrieve calendar for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	ret := domain.NewTargetList()
+
+	for _, target := range targets {
+		target.ApplyDefaults()
+		ret.Targets = append(ret.Targets, target)
+	}
+
+	for _, group := range groups {
+		group.ApplyDefaults()
+		ret.Groups = append(ret.Groups, group)
+	}
+
+	ret.SetTimezone(t.timezones.Name(user))
+	ret.SetAllowlist(t.allowlist)
+	ret.SetCalendar(calendar)
+	ret.WeekStart = t.weekStart
+
+	return ret, nil
+}
+
+// GetTarget returns a target of a user, or nil when it does not exist.
+func (t *Target) GetTarget(user string, id int64) (*domain.Target, error) {
+	target, err := t.storage.GetTarget(user, id)
+
+	if err != nil || target == nil {
+		return nil, err
+	}
+
+	target.ApplyDefaults()
+
+	return target, nil
+}
+
+func (t *Target) CreateTarget(user string, target *domain.Target) error {
+	log.Printf("[service.Target.CreateTarget] Creating target '%s' for user '%s'", target.Name, user)
+
+	target.User = user
+
+	if err := t.validate(target); err != nil {
+		return err
+	}
+
+	err := t.storage.InsertTarget(target)
+
+	if err == nil {
+		t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+	}
+
+	return err
+}
+
+// UpdateTarget replaces a target. It returns false when the target does not
+// exist for the user.
+func (t *Target) UpdateTarget(user string, id int64, target *domain.Target) (bool, error) {
+	log.Printf("[service.Target.UpdateTarget] Updating target %d '%s' for user '%s'", id, target.Name, user)
+
+	target.User = user
+	target.ID = id
+
+	if err := t.validate(target); err != nil {
+		return false, err
+	}
+
+	found, err := t.storage.UpdateTarget(target)
+
+	if found && err == nil {
+		t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+	}
+
+	return found, err
+}
+
+func (t *Target) DeleteTarget(user string, id int64) (bool, error) {
+	log.Printf("[service.Target.DeleteTarget] Deleting target %d for user '%s'", id, user)
+
+	found, err := t.storage.DeleteTarget(user, id)
+
+	if found && err == nil {
+		t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+	}
+
+	return found, err
+}
+
+// ImportTargets replaces the targets of a user with a target list in the
+// original JSON format, read from an URL or a local file.
+func (t *Target) ImportTargets(user string, source string) (int, error) {
+	log.Printf("[service.Target.ImportTargets] Importing targets for user '%s' from '%s'", user, source)
+
+	if isUrl(source) {
+		return t.refresh(user, source, true)
+	}
+
+	data, err := os.ReadFile(source)
+
+	if err != nil {
+		log.Printf("[service.Target.ImportTargets] Failed to read targets from '%s' for user '%s': %v", source, user, err)
+		return 0, err
+	}
+
+	return t.replaceTargets(user, string(data))
+}
+
+// refresh revalidates the remote target list of a user once its TTL has
+// expired, or always when forced. Only a changed and valid list replaces
+// the stored targets; on any failure they are kept untouched.
+func (t *Target) refresh(user string, url string, force bool) (int, error) {
+	lock, _ := t.locks.LoadOrStore(user, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if !force && !t.expired(user) {
+		return 0, nil
+	}
+
+	source, err := t.storage.GetSource(user)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if source == nil || source.URL != url || force {
+		source = domain.NewTargetSource(user, url)
+	}
+
+	count := 0
+	remote, err := t.getFromUrl(url, source.ETag, source.LastModified)
+
+	if err == nil && !remote.notModified && HashToken(remote.body) == source.Hash {
+		remote.notModified = true
+	}
+
+	if err == nil && !remote.notModified {
+		count, err = t.replaceTargets(user, remote.body)
+	}
+
+	t.mu.Lock()
+	t.checked[user] = time.Now()
+	t.mu.Unlock()
+
+	switch {
+	case err != nil:
+		source.Status = domain.TARGET_SOURCE_STALE
+		source.LastError = err.Error()
+	case remote.notModified:
+		source.Status = domain.TARGET_SOURCE_NOT_MODIFIED
+		source.LastError = ""
+	default:
+		source.Status = domain.TARGET_SOURCE_UPDATED
+		source.LastError = ""
+		source.ETag = remote.etag
+		source.LastModified = remote.lastModified
+		source.Hash = HashToken(remote.body)
+	}
+
+	if saveErr := t.storage.SaveSource(source); saveErr != nil {
+		log.Printf("[service.Target.refresh] Failed to save cache state of user '%s': %v", user, saveErr)
+	}
+
+	return count, err
+}
+
+func (t *Target) expired(user string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-			ret, err = domain.TargetListFromJson(data)
+	checked, ok := t.checked[user]
+
+	return !ok || time.Since(checked) >= t.ttl
+}
+
+func (t *Target) replaceTargets(user string, data string) (int, error) {
+	list, err := domain.TargetListFromJson(data)
+
+	if err != nil {
+		log.Printf("[service.Target.replaceTargets] Failed to parse target list JSON for user '%s': %v", user, err)
+		return 0, err
+	}
+
+	for _, target := range list.Targets {
+		if err := target.Validate(); err != nil {
+			log.Printf("[service.Target.replaceTargets] Invalid target '%s' for user '%s': %v", target.Name, user, err)
+			return 0, fmt.Errorf("%w: %v", ErrInvalidTarget, err)
+		}
+	}
+
+	if err := domain.ValidateGroups(list.Groups, list.Targets); err != nil {
+		log.Printf("[service.Target.replaceTargets] Invalid groups for user '%s': %v", user, err)
+		return 0, fmt.Errorf("%w: %v", ErrInvalidGroup, err)
+	}
+
+	err = t.storage.ReplaceTargets(user, list.Targets, list.Groups)
+
+	if err != nil {
+		log.Printf("[service.Target.replaceTargets] Failed to store targets for user '%s': %v", user, err)
+		return 0, err
+	}
+
+	log.Printf("[service.Target.replaceTargets] %d targets stored for user '%s'", len(list.Targets), user)
+
+	t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+
+	return len(list.Targets), nil
+}
+
+// GetGroups returns the budget groups of a user.
+func (t *Target) GetGroups(user string) ([]*domain.Group, error) {
+	groups, err := t.storage.GetGroups(user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		group.ApplyDefaults()
+	}
+
+	return groups, nil
+}
+
+// ReplaceGroups swaps the budget groups of a user. Groups still used by a
+// target cannot be removed.
+func (t *Target) ReplaceGroups(user string, groups []*domain.Group) error {
+	log.Printf("[service.Target.ReplaceGroups] Replacing %d groups for user '%s'", len(groups), user)
+
+	targets, err := t.storage.GetTargets(user)
+
+	if err != nil {
+		return err
+	}
+
+	if err := domain.ValidateGroups(groups, targets); err != nil {
+		log.Printf("[service.Target.ReplaceGroups] Invalid groups for user '%s': %v", user, err)
+		return fmt.Errorf("%w: %v", ErrInvalidGroup, err)
+	}
+
+	for _, group := range groups {
+		group.ApplyDefaults()
+	}
+
+	err = t.storage.ReplaceGroups(user, groups)
+
+	if err == nil {
+		t.events.Publish(domain.NewEvent(domain.EVENT_TARGETS, user))
+	}
+
+	return err
+}
+
+// GetSources returns the cache state of the remote target lists of the
+// configured users.
+func (t *Target) GetSources() ([]*domain.TargetSource, error) {
+	sources, err := t.storage.GetSources()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*domain.TargetSource, 0)
+
+	for _, source := range sources {
+		if t.urls[source.User] == source.URL {
+			ret = append(ret, source)
+		}
+	}
+
+	return ret, nil
+}
+
+// ImportConfigured imports the targets of every user in user_targets. When
+// onlyMissing is set, users already stored in the database are skipped, so
+// it can run on every start to migrate existing setups.
+func (t *Target) ImportConfigured(onlyMissing bool) error {
+	var errs []error
+
+	for user, source := range t.urls {
+		if onlyMissing {
+			exists, err := t.storage.UserExists(user)
 
 			if err != nil {
-				log.Printf("[service.Target.GetTargets] Failed to parse target list JSON for user '%s': %v", user, err)
-				return nil, err
+				return err
 			}
-			break
+
+			if exists {
+				continue
+			}
+		}
+
+		if _, err := t.ImportTargets(user, source); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", user, err))
 		}
 	}
 
-	for k, v := range ret.Targets {
-		v.User = user
-		ret.Targets[k] = v
+	return errors.Join(errs...)
+}
+
+// UserExists reports whether a user is stored in the database.
+func (t *Target) UserExists(user string) bool {
+	exists, err := t.storage.UserExists(user)
+
+	if err != nil {
+		log.Printf("[service.Target.UserExists] Failed to lookup user '%s': %v", user, err)
 	}
 
-	if ret == nil {
-		log.Printf("[service.Target.GetTargets] No targets configured for user '%s'", user)
-		return nil, fmt.Errorf("no targets found for user: %s", user)
+	return exists
+}
+
+func (t *Target) GetUsers() ([]string, error) {
+	return t.storage.GetUsers()
+}
+
+func (t *Target) CreateUser(user string) error {
+	log.Printf("[service.Target.CreateUser] Creating user '%s'", user)
+	return t.storage.InsertUser(user)
+}
+
+func (t *Target) validate(target *domain.Target) error {
+	if err := target.Validate(); err != nil {
+		log.Printf("[service.Target.validate] Invalid target '%s' for user '%s': %v", target.Name, target.User, err)
+		return fmt.Errorf("%w: %v", ErrInvalidTarget, err)
 	}
 
-	return ret, nil
+	targets, err := t.storage.GetTargets(target.User)
+
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range targets {
+		if existing.Name == target.Name && existing.ID != target.ID {
+			log.Printf("[service.Target.validate] Target '%s' already exists for user '%s'", target.Name, target.User)
+			return ErrTargetExists
+		}
+	}
+
+	if len(target.Groups) == 0 {
+		return nil
+	}
+
+	groups, err := t.storage.GetGroups(target.User)
+
+	if err != nil {
+		return err
+	}
+
+	if err := domain.ValidateGroups(groups, []*domain.Target{target}); err != nil {
+		log.Printf("[service.Target.validate] Invalid groups of target '%s' for user '%s': %v", target.Name, target.User, err)
+		return fmt.Errorf("%w: %v", ErrInvalidTarget, err)
+	}
+
+	return nil
 }
 
-func (t *Target) getFromUrl(url string) (string, error) {
-	res, err := http.Get(url)
+func (t *Target) getFromUrl(url string, etag string, lastModified string) (*remoteTargets, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Printf("[service.Target.getFromUrl] Failed to create request for URL '%s': %v", url, err)
+		return nil, err
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	res, err := t.client.Do(req)
 	if err != nil {
 		log.Printf("[service.Target.getFromUrl] Failed to fetch URL '%s': %v", url, err)
-		return "", err
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return &remoteTargets{notModified: true}, nil
 	}
 
-	if res.StatusCode != 200 {
+	if res.StatusCode != http.StatusOK {
 		log.Printf("[service.Target.getFromUrl] Received non-OK status code %d from URL '%s'", res.StatusCode, url)
-		return "", err
+		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
 	}
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		log.Printf("[service.Target.getFromUrl] Failed to read response body from URL '%s': %v", url, err)
-		return "", err
+		return nil, err
 	}
 
-	return string(body), nil
+	return &remoteTargets{
+		body:         string(body),
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+	}, nil
+}
+
+func isUrl(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
 }
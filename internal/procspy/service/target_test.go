@@ -1,10 +1,24 @@
 package service
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/domain"
+	"procspy/internal/procspy/storage"
 	"testing"
 )
 
+const testTargetsJson = `{
+	"targets": [
+		{"name": "games", "pattern": "steam|roblox", "kill": true, "weekdays": {"0": 2.0}},
+		{"name": "browsers", "pattern": "chrome|firefox"}
+	]
+}`
+
 // TestNewTarget testa criação de service de target
 func TestNewTarget(t *testing.T) {
 	cfg := &config.Server{
@@ -13,7 +27,10 @@ func TestNewTarget(t *testing.T) {
 		},
 	}
 
-	service := NewTarget(cfg)
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(cfg, conn)
 	if service == nil {
 		t.Fatal("NewTarget retornou nil")
 	}
@@ -29,7 +46,10 @@ func TestTarget_GetTargets_NoUser(t *testing.T) {
 		UserTarges: map[string]string{},
 	}
 
-	service := NewTarget(cfg)
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(cfg, conn)
 	targets, err := service.GetTargets("nonexistent")
 
 	// Quando usuário não existe, retorna lista vazia sem erro
@@ -46,19 +66,201 @@ func TestTarget_GetTargets_NoUser(t *testing.T) {
 	}
 }
 
-// TestTarget_GetTargets_InvalidURL testa busca com URL inválida
-func TestTarget_GetTargets_InvalidURL(t *testing.T) {
-	cfg := &config.Server{
-		UserTarges: map[string]string{
-			"user1": "http://invalid-url-that-does-not-exist-12345.com/targets.json",
-		},
+// TestTarget_CRUD testa criação, atualização e remoção de targets
+func TestTarget_CRUD(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(&config.Server{}, conn)
+
+	target := &domain.Target{Name: "games", Pattern: "steam", Weekdays: map[int]float64{6: 2.0}}
+	if err := service.CreateTarget("user1", target); err != nil {
+		t.Fatalf("CreateTarget() erro = %v", err)
+	}
+
+	if !service.UserExists("user1") {
+		t.Error("Usuário deveria ser registrado ao criar target")
 	}
 
-	service := NewTarget(cfg)
-	_, err := service.GetTargets("user1")
+	if err := service.CreateTarget("user1", &domain.Target{Name: "games", Pattern: "roblox"}); !errors.Is(err, ErrTargetExists) {
+		t.Errorf("CreateTarget() erro = %v, esperado ErrTargetExists", err)
+	}
 
-	if err == nil {
-		t.Error("GetTargets() deveria retornar erro para URL inválida")
+	if err := service.CreateTarget("user1", &domain.Target{Name: "bad", Pattern: "steam("}); !errors.Is(err, ErrInvalidTarget) {
+		t.Errorf("CreateTarget() erro = %v, esperado ErrInvalidTarget", err)
+	}
+
+	found, err := service.UpdateTarget("user1", target.ID, &domain.Target{Name: "games", Pattern: "steam|roblox", Kill: true})
+	if err != nil || !found {
+		t.Fatalf("UpdateTarget() = %v, %v", found, err)
+	}
+
+	stored, _ := service.GetTarget("user1", target.ID)
+	if stored == nil || stored.Pattern != "steam|roblox" || !stored.Kill {
+		t.Errorf("Target não foi atualizado: %+v", stored)
+	}
+
+	// Target de outro usuário não é encontrado
+	if found, _ := service.UpdateTarget("user2", target.ID, &domain.Target{Name: "games", Pattern: "x"}); found {
+		t.Error("UpdateTarget() não deveria alterar target de outro usuário")
+	}
+
+	if found, _ := service.DeleteTarget("user1", target.ID); !found {
+		t.Error("DeleteTarget() deveria remover o target")
+	}
+
+	targets, _ := service.GetTargets("user1")
+	if len(targets.Targets) != 0 {
+		t.Errorf("Esperado 0 targets, obteve %d", len(targets.Targets))
+	}
+}
+
+// TestTarget_ImportTargets testa importação do formato JSON original
+func TestTarget_ImportTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testTargetsJson))
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "targets.json")
+	os.WriteFile(file, []byte(testTargetsJson), 0644)
+
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(&config.Server{}, conn)
+
+	for _, source := range []string{server.URL, file} {
+		count, err := service.ImportTargets("user1", source)
+		if err != nil {
+			t.Fatalf("ImportTargets(%s) erro = %v", source, err)
+		}
+
+		if count != 2 {
+			t.Errorf("ImportTargets(%s) = %d, esperado 2", source, count)
+		}
+
+		// Importar de novo substitui os targets em vez de duplicar
+		targets, _ := service.GetTargets("user1")
+		if len(targets.Targets) != 2 {
+			t.Fatalf("Esperado 2 targets, obteve %d", len(targets.Targets))
+		}
+
+		if targets.Targets[0].User != "user1" || targets.Targets[0].Weekdays[0] != 2.0 || !targets.Targets[0].Kill {
+			t.Errorf("Target importado incorreto: %+v", targets.Targets[0])
+		}
+	}
+}
+
+// TestTarget_ImportTargets_Invalid testa importação com fonte ou pattern inválidos
+func TestTarget_ImportTargets_Invalid(t *testing.T) {
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(&config.Server{}, conn)
+
+	if _, err := service.ImportTargets("user1", "http://invalid-url-that-does-not-exist-12345.com/targets.json"); err == nil {
+		t.Error("ImportTargets() deveria retornar erro para URL inválida")
+	}
+
+	file := filepath.Join(t.TempDir(), "targets.json")
+	os.WriteFile(file, []byte(`{"targets":[{"name":"games","pattern":"steam("}]}`), 0644)
+
+	if _, err := service.ImportTargets("user1", file); !errors.Is(err, ErrInvalidTarget) {
+		t.Errorf("ImportTargets() erro = %v, esperado ErrInvalidTarget", err)
+	}
+}
+
+// TestTarget_ImportConfigured testa migração dos usuários configurados por URL
+func TestTarget_ImportConfigured(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(testTargetsJson))
+	}))
+	defer server.Close()
+
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(&config.Server{UserTarges: map[string]string{"user1": server.URL}}, conn)
+
+	if err := service.ImportConfigured(true); err != nil {
+		t.Fatalf("ImportConfigured() erro = %v", err)
+	}
+
+	// Usuário já importado não é buscado de novo
+	service.ImportConfigured(true)
+
+	if requests != 1 {
+		t.Errorf("Esperado 1 request, obteve %d", requests)
+	}
+
+	service.ImportConfigured(false)
+
+	if requests != 2 {
+		t.Errorf("Esperado 2 requests, obteve %d", requests)
+	}
+}
+
+// TestTarget_RemoteCache testa revalidação condicional e fallback para a última cópia válida
+func TestTarget_RemoteCache(t *testing.T) {
+	requests := 0
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if down {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testTargetsJson))
+	}))
+	defer server.Close()
+
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(&config.Server{UserTarges: map[string]string{"user1": server.URL}}, conn)
+
+	targets, err := service.GetTargets("user1")
+	if err != nil || len(targets.Targets) != 2 {
+		t.Fatalf("GetTargets() = %v, %v", targets, err)
+	}
+	firstID := targets.Targets[0].ID
+
+	// Dentro do TTL não há nova busca
+	service.GetTargets("user1")
+	if requests != 1 {
+		t.Errorf("Esperado 1 request dentro do TTL, obteve %d", requests)
+	}
+
+	// TTL expirado: revalida com ETag e mantém os targets
+	service.ttl = 0
+	targets, _ = service.GetTargets("user1")
+	if requests != 2 || targets.Targets[0].ID != firstID {
+		t.Errorf("Revalidação deveria manter os targets (requests %d)", requests)
+	}
+
+	sources, _ := service.GetSources()
+	if len(sources) != 1 || sources[0].Status != domain.TARGET_SOURCE_NOT_MODIFIED {
+		t.Errorf("Status do cache incorreto: %+v", sources)
+	}
+
+	// Fonte fora do ar: serve a última cópia válida e marca como stale
+	down = true
+	targets, err = service.GetTargets("user1")
+	if err != nil || len(targets.Targets) != 2 {
+		t.Fatalf("GetTargets() deveria usar a última cópia válida: %v, %v", targets, err)
+	}
+
+	sources, _ = service.GetSources()
+	if len(sources) != 1 || !sources[0].IsStale() {
+		t.Errorf("Status do cache deveria ser stale: %+v", sources)
 	}
 }
 
@@ -68,8 +270,11 @@ func TestTarget_getFromUrl_InvalidURL(t *testing.T) {
 		UserTarges: map[string]string{},
 	}
 
-	service := NewTarget(cfg)
-	_, err := service.getFromUrl("http://invalid-url-that-does-not-exist-12345.com")
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	service := NewTarget(cfg, conn)
+	_, err := service.getFromUrl("http://invalid-url-that-does-not-exist-12345.com", "", "")
 
 	if err == nil {
 		t.Error("getFromUrl() deveria retornar erro para URL inválida")
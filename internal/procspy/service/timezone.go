@@ -0,0 +1,64 @@
+package service
+
+import (
+	"log"
+	"procspy/internal/procspy/domain"
+	"time"
+)
+
+// Timezones holds the zone of each user whose days do not follow the zone
+// of the server.
+type Timezones map[string]*time.Location
+
+// NewTimezones loads the zones configured in user_timezones. An invalid
+// zone is logged and its user follows the zone of the server.
+func NewTimezones(names map[string]string) Timezones {
+	ret := make(Timezones, len(names))
+
+	for user, name := range names {
+		loc, err := domain.LoadTimezone(name)
+
+		if err != nil {
+			log.Printf("[service.NewTimezones] Ignoring timezone of user '%s': %v", user, err)
+			continue
+		}
+
+		ret[user] = loc
+	}
+
+	return ret
+}
+
+// Name returns the zone name of a user, or empty when it follows the zone
+// of the server.
+func (z Timezones) Name(user string) string {
+	if loc, found := z[user]; found {
+		return loc.String()
+	}
+
+	return ""
+}
+
+// Location returns the zone of a user, or the zone of the server.
+func (z Timezones) Location(user string) *time.Location {
+	if loc, found := z[user]; found {
+		return loc
+	}
+
+	return time.Local
+}
+
+// Now returns the current moment in the zone of a user.
+func (z Timezones) Now(user string) time.Time {
+	if loc, found := z[user]; found {
+		return time.Now().In(loc)
+	}
+
+	return time.Now()
+}
+
+// Today returns the midnight that started the current day of a user.
+func (z Timezones) Today(user string) time.Time {
+	now := z.Now(user)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
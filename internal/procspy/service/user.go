@@ -2,12 +2,15 @@ package service
 
 import "procspy/internal/procspy/config"
 
+// Users are the children monitored by the server: the ones in user_targets
+// and the ones stored in the database.
 type Users struct {
-	config *config.Server
+	config  *config.Server
+	targets *Target
 }
 
-func NewUsers(config *config.Server) *Users {
-	return &Users{config: config}
+func NewUsers(config *config.Server, targets *Target) *Users {
+	return &Users{config: config, targets: targets}
 }
 
 func (u *Users) GetUsers() ([]string, error) {
@@ -17,10 +20,29 @@ func (u *Users) GetUsers() ([]string, error) {
 		ret = append(ret, k)
 	}
 
+	if u.targets == nil {
+		return ret, nil
+	}
+
+	stored, err := u.targets.GetUsers()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range stored {
+		if _, ok := u.config.UserTarges[user]; !ok {
+			ret = append(ret, user)
+		}
+	}
+
 	return ret, nil
 }
 
 func (u *Users) Exists(user string) bool {
-	_, ok := u.config.UserTarges[user]
-	return ok
+	if _, ok := u.config.UserTarges[user]; ok {
+		return true
+	}
+
+	return u.targets != nil && u.targets.UserExists(user)
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"procspy/internal/procspy/config"
+	"procspy/internal/procspy/storage"
 	"testing"
 )
 
@@ -13,7 +14,7 @@ func TestNewUsers(t *testing.T) {
 		},
 	}
 
-	service := NewUsers(cfg)
+	service := NewUsers(cfg, nil)
 	if service == nil {
 		t.Fatal("NewUsers retornou nil")
 	}
@@ -28,7 +29,7 @@ func TestUsers_GetUsers(t *testing.T) {
 		},
 	}
 
-	service := NewUsers(cfg)
+	service := NewUsers(cfg, nil)
 	users, err := service.GetUsers()
 
 	if err != nil {
@@ -48,7 +49,7 @@ func TestUsers_Exists(t *testing.T) {
 		},
 	}
 
-	service := NewUsers(cfg)
+	service := NewUsers(cfg, nil)
 
 	if !service.Exists("user1") {
 		t.Error("user1 deveria existir")
@@ -65,7 +66,7 @@ func TestUsers_GetUsers_Empty(t *testing.T) {
 		UserTarges: map[string]string{},
 	}
 
-	service := NewUsers(cfg)
+	service := NewUsers(cfg, nil)
 	users, err := service.GetUsers()
 
 	if err != nil {
@@ -83,9 +84,36 @@ func TestUsers_Exists_EmptyConfig(t *testing.T) {
 		UserTarges: map[string]string{},
 	}
 
-	service := NewUsers(cfg)
+	service := NewUsers(cfg, nil)
 
 	if service.Exists("anyuser") {
 		t.Error("Nenhum usuário deveria existir em config vazia")
 	}
 }
+
+// TestUsers_StoredUsers testa usuários cadastrados no banco além da config
+func TestUsers_StoredUsers(t *testing.T) {
+	cfg := &config.Server{
+		UserTarges: map[string]string{
+			"user1": "http://example.com/user1.json",
+		},
+	}
+
+	conn := storage.NewDbConnection(":memory:")
+	defer conn.Close()
+
+	targets := NewTarget(cfg, conn)
+	targets.CreateUser("user1")
+	targets.CreateUser("user2")
+
+	service := NewUsers(cfg, targets)
+
+	if !service.Exists("user2") {
+		t.Error("Usuário cadastrado no banco deveria existir")
+	}
+
+	users, _ := service.GetUsers()
+	if len(users) != 2 {
+		t.Errorf("Esperado 2 usuários sem duplicados, obteve %v", users)
+	}
+}
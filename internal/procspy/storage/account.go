@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+)
+
+type Account struct {
+	conn *DbConnection
+}
+
+func NewAccount(dbConn *DbConnection) *Account {
+	ret := &Account{
+		conn: dbConn,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Account.NewAccount] Failed to initialize account storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+func (a *Account) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS accounts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	role TEXT NOT NULL,
+	children TEXT NOT NULL DEFAULT '[]',
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash TEXT PRIMARY KEY,
+	account_id INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_account_id ON sessions (account_id);
+`
+	if a.conn == nil {
+		log.Printf("[storage.Account.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := a.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Account.Init] Failed to create account tables: %v", err)
+	}
+
+	return err
+}
+
+func (a *Account) Close() error {
+	if a.conn == nil {
+		log.Printf("[storage.Account.Close] Database connection is already closed")
+		return nil
+	}
+
+	return a.conn.Close()
+}
+
+// InsertAccount stores an account with the hash of its password and sets
+// its ID.
+func (a *Account) InsertAccount(account *domain.Account, passwordHash string) error {
+	insert := `
+INSERT INTO accounts
+(
+	username,
+	password_hash,
+	role,
+	children
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?
+);`
+
+	if a.conn == nil {
+		log.Printf("[storage.Account.InsertAccount] Cannot insert account: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	children, err := json.Marshal(account.Children)
+
+	if err != nil {
+		log.Printf("[storage.Account.InsertAccount] Failed to marshal children of account '%s': %v", account.Username, err)
+		return err
+	}
+
+	conn, err := a.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Account.InsertAccount] Failed to get database connection: %v", err)
+		return err
+	}
+
+	res, err := conn.Exec(insert, account.Username, passwordHash, account.Role, string(children))
+
+	if err != nil {
+		log.Printf("[storage.Account.InsertAccount] Failed to insert account '%s': %v", account.Username, err)
+		return err
+	}
+
+	account.ID, err = res.LastInsertId()
+
+	return err
+}
+
+const selectAccount = `
+SELECT
+	id,
+	username,
+	role,
+	children,
+	coalesce(created_at, ''),
+	password_hash
+FROM
+	accounts
+`
+
+func scanAccount(row interface{ Scan(dest ...any) error }) (*domain.Account, string, error) {
+	ret := &domain.Account{}
+	var children, passwordHash string
+
+	err := row.Scan(&ret.ID, &ret.Username, &ret.Role, &children, &ret.CreatedAt, &passwordHash)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = json.Unmarshal([]byte(children), &ret.Children)
+
+	return ret, passwordHash, err
+}
+
+// GetAccountByUsername returns an account and its password hash, or nil
+// when no account matches.
+func (a *Account) GetAccountByUsername(username string) (*domain.Account, string, error) {
+	if a.conn == nil {
+		log.Printf("[storage.Account.GetAccountByUsername] Cannot query account: database connection is nil")
+		return nil, "", errors.New("db is nil")
+	}
+
+	conn, err := a.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Account.GetAccountByUsername] Failed to get database connection: %v", err)
+		return nil, "", err
+	}
+
+	ret, passwordHash, err := scanAccount(conn.QueryRow(selectAccount+"WHERE username = ?;", username))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Account.GetAccountByUsername] Failed to query account '%s': %v", username, err)
+		return nil, "", err
+	}
+
+	return ret, passwordHash, nil
+}
+
+func (a *Account) GetAccounts() ([]*domain.Account, error) {
+	if a.conn == nil {
+		log.Printf("[storage.Account.GetAccounts] Cannot query accounts: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := a.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Account.GetAccounts] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(selectAccount + "ORDER BY id;")
+
+	if err != nil {
+		log.Printf("[storage.Account.GetAccounts] Failed to query accounts: %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]*domain.Account, 0)
+
+	for rows.Next() {
+		account, _, err := scanAccount(rows)
+		if err != nil {
+			log.Printf("[storage.Account.GetAccounts] Failed to scan account row: %v", err)
+			return nil, err
+		}
+		ret = append(ret, account)
+	}
+
+	return ret, nil
+}
+
+// DeleteAccount removes an account and its sessions. It returns false when
+// the account does not exist.
+func (a *Account) DeleteAccount(id int64) (bool, error) {
+	if a.conn == nil {
+		log.Printf("[storage.Account.DeleteAccount] Cannot delete account: database connection is nil")
+		return false, errors.New("db is nil")
+	}
+
+	err := a.conn.Exec("DELETE FROM sessions WHERE account_id = ?;", id)
+
+	if err != nil {
+		log.Printf("[storage.Account.DeleteAccount] Failed to delete sessions of account %d: %v", id, err)
+		return false, err
+	}
+
+	affected, err := a.conn.ExecAffected("DELETE FROM accounts WHERE id = ?;", id)
+
+	if err != nil {
+		log.Printf("[storage.Account.DeleteAccount] Failed to delete account %d: %v", id, err)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// InsertSession stores the hash of a session token valid for ttl seconds.
+func (a *Account) InsertSession(tokenHash string, accountID int64, ttl int) error {
+	insert := `
+INSERT INTO sessions
+(
+	token_hash,
+	account_id,
+	expires_at
+)
+VALUES
+(
+	?,
+	?,
+	datetime('now', 'localtime', printf('%+d seconds', ?))
+);`
+
+	if a.conn == nil {
+		log.Printf("[storage.Account.InsertSession] Cannot insert session: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := a.conn.Exec(insert, tokenHash, accountID, ttl)
+
+	if err != nil {
+		log.Printf("[storage.Account.InsertSession] Failed to insert session for account %d: %v", accountID, err)
+	}
+
+	return err
+}
+
+// GetAccountBySession returns the account of a session that has not
+// expired, or nil when there is none.
+func (a *Account) GetAccountBySession(tokenHash string) (*domain.Account, error) {
+	query := selectAccount + `
+WHERE
+	id = (
+		SELECT account_id FROM sessions WHERE token_hash = ? and expires_at > datetime('now', 'localtime')
+	);`
+
+	if a.conn == nil {
+		log.Printf("[storage.Account.GetAccountBySession] Cannot query session: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := a.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Account.GetAccountBySession] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	ret, _, err := scanAccount(conn.QueryRow(query, tokenHash))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Account.GetAccountBySession] Failed to query session: %v", err)
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// DeleteSession removes a session and any other expired ones.
+func (a *Account) DeleteSession(tokenHash string) error {
+	if a.conn == nil {
+		log.Printf("[storage.Account.DeleteSession] Cannot delete session: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := a.conn.Exec("DELETE FROM sessions WHERE token_hash = ? or expires_at <= datetime('now', 'localtime');", tokenHash)
+
+	if err != nil {
+		log.Printf("[storage.Account.DeleteSession] Failed to delete session: %v", err)
+	}
+
+	return err
+}
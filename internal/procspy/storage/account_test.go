@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"procspy/internal/procspy/domain"
+	"testing"
+)
+
+// TestAccount_InsertAccount testa inserção e busca de conta
+func TestAccount_InsertAccount(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewAccount(conn)
+
+	account := domain.NewAccount("parent", domain.ROLE_PARENT, []string{"user1", "user2"})
+	if err := storage.InsertAccount(account, "hash"); err != nil {
+		t.Fatalf("InsertAccount() erro = %v", err)
+	}
+
+	found, hash, err := storage.GetAccountByUsername("parent")
+	if err != nil {
+		t.Fatalf("GetAccountByUsername() erro = %v", err)
+	}
+
+	if found == nil || hash != "hash" || len(found.Children) != 2 {
+		t.Errorf("Conta incorreta: %+v, hash %s", found, hash)
+	}
+
+	missing, _, err := storage.GetAccountByUsername("unknown")
+	if err != nil || missing != nil {
+		t.Errorf("GetAccountByUsername() = %v, %v, esperado nil", missing, err)
+	}
+
+	if err := storage.InsertAccount(domain.NewAccount("parent", domain.ROLE_VIEWER, nil), "hash"); err == nil {
+		t.Error("InsertAccount() deveria falhar com username duplicado")
+	}
+}
+
+// TestAccount_Sessions testa sessões válidas, expiradas e removidas
+func TestAccount_Sessions(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewAccount(conn)
+
+	account := domain.NewAccount("viewer", domain.ROLE_VIEWER, nil)
+	storage.InsertAccount(account, "hash")
+
+	storage.InsertSession("valid", account.ID, 3600)
+	storage.InsertSession("expired", account.ID, -10)
+
+	found, err := storage.GetAccountBySession("valid")
+	if err != nil || found == nil || found.ID != account.ID {
+		t.Errorf("GetAccountBySession() = %v, %v", found, err)
+	}
+
+	if found, _ := storage.GetAccountBySession("expired"); found != nil {
+		t.Error("Sessão expirada não deveria ser aceita")
+	}
+
+	storage.DeleteSession("valid")
+
+	if found, _ := storage.GetAccountBySession("valid"); found != nil {
+		t.Error("Sessão removida não deveria ser aceita")
+	}
+}
+
+// TestAccount_NilConnection testa operações com conexão nil
+func TestAccount_NilConnection(t *testing.T) {
+	storage := &Account{conn: nil}
+
+	if err := storage.InsertAccount(domain.NewAccount("a", domain.ROLE_ADMIN, nil), "hash"); err == nil {
+		t.Error("InsertAccount() deveria retornar erro com conexão nil")
+	}
+
+	if _, err := storage.GetAccounts(); err == nil {
+		t.Error("GetAccounts() deveria retornar erro com conexão nil")
+	}
+
+	if err := storage.Close(); err != nil {
+		t.Errorf("Close() com conn nil deveria retornar nil, obteve erro = %v", err)
+	}
+}
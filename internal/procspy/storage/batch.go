@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+)
+
+type Batch struct {
+	conn *DbConnection
+}
+
+// NewBatch expects the matches and command_log tables to be created by
+// NewMatch and NewCommand on the same connection.
+func NewBatch(dbConn *DbConnection) *Batch {
+	return &Batch{
+		conn: dbConn,
+	}
+}
+
+// InsertBatch stores all matches and commands in a single transaction. A
+// failing item does not abort the others; its error is reported in the
+// result at the same position.
+func (b *Batch) InsertBatch(batch *domain.Batch) (*domain.BatchResult, error) {
+	if b.conn == nil {
+		log.Printf("[storage.Batch.InsertBatch] Cannot insert batch: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := b.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Batch.InsertBatch] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	tx, err := conn.Begin()
+
+	if err != nil {
+		log.Printf("[storage.Batch.InsertBatch] Failed to begin transaction: %v", err)
+		return nil, err
+	}
+
+	ret := domain.NewBatchResult()
+
+	for _, match := range batch.Matches {
+		res, err := tx.Exec(insertMatchQuery, insertMatchArgs(match)...)
+		ret.Matches = append(ret.Matches, batchItemResult(match.EventID, res, err))
+	}
+
+	for _, cmd := range batch.Commands {
+		res, err := tx.Exec(insertCommandQuery, insertCommandArgs(cmd)...)
+		ret.Commands = append(ret.Commands, batchItemResult(cmd.EventID, res, err))
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Printf("[storage.Batch.InsertBatch] Failed to commit batch of %d matches and %d commands: %v", len(batch.Matches), len(batch.Commands), err)
+		return nil, err
+	}
+
+	log.Printf("[storage.Batch.InsertBatch] Batch of %d matches and %d commands committed", len(batch.Matches), len(batch.Commands))
+
+	return ret, nil
+}
+
+func batchItemResult(eventID string, res sql.Result, err error) *domain.BatchItemResult {
+	ret := &domain.BatchItemResult{
+		EventID: eventID,
+		Status:  domain.BATCH_STATUS_INSERTED,
+	}
+
+	if err == nil {
+		var affected int64
+		affected, err = res.RowsAffected()
+
+		if err == nil && affected == 0 {
+			ret.Status = domain.BATCH_STATUS_DUPLICATE
+		}
+	}
+
+	if err != nil {
+		log.Printf("[storage.Batch.InsertBatch] Failed to insert item '%s': %v", eventID, err)
+		ret.Status = domain.BATCH_STATUS_ERROR
+		ret.Error = err.Error()
+	}
+
+	return ret
+}
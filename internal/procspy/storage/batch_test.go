@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"procspy/internal/procspy/domain"
+	"testing"
+)
+
+// TestBatch_InsertBatch testa inserção de matches e commands em lote
+func TestBatch_InsertBatch(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	matches := NewMatch(conn)
+	commands := NewCommand(conn)
+	storage := NewBatch(conn)
+
+	duplicated := domain.NewMatch("user1", "games", "steam", "steam.exe", 50.0)
+	matches.InsertMatch(duplicated)
+
+	batch := domain.NewBatch()
+	batch.Matches = append(batch.Matches,
+		domain.NewMatch("user1", "games", "steam", "steam.exe", 100.0),
+		duplicated,
+	)
+	batch.Commands = append(batch.Commands, domain.NewCommand("user1", "games", "cmd1", "out1"))
+
+	result, err := storage.InsertBatch(batch)
+	if err != nil {
+		t.Fatalf("InsertBatch() erro = %v", err)
+	}
+
+	if len(result.Matches) != 2 || len(result.Commands) != 1 {
+		t.Fatalf("Resultado com tamanho inesperado: %d matches, %d commands", len(result.Matches), len(result.Commands))
+	}
+
+	if result.Matches[0].Status != domain.BATCH_STATUS_INSERTED {
+		t.Errorf("Status = %s, esperado %s", result.Matches[0].Status, domain.BATCH_STATUS_INSERTED)
+	}
+
+	if result.Matches[1].Status != domain.BATCH_STATUS_DUPLICATE {
+		t.Errorf("Status = %s, esperado %s", result.Matches[1].Status, domain.BATCH_STATUS_DUPLICATE)
+	}
+
+	if result.Commands[0].Status != domain.BATCH_STATUS_INSERTED {
+		t.Errorf("Status = %s, esperado %s", result.Commands[0].Status, domain.BATCH_STATUS_INSERTED)
+	}
+
+	elapsed, _ := matches.GetMatches("user1", today())
+	if elapsed["games"] != 150.0 {
+		t.Errorf("games elapsed = %.2f, esperado 150.00", elapsed["games"])
+	}
+
+	cmds, _ := commands.GetCommands("user1")
+	if len(cmds) != 1 {
+		t.Errorf("Esperado 1 command, obteve %d", len(cmds))
+	}
+}
+
+// TestBatch_InsertBatch_NilConnection testa inserção com conexão nil
+func TestBatch_InsertBatch_NilConnection(t *testing.T) {
+	storage := &Batch{conn: nil}
+
+	_, err := storage.InsertBatch(domain.NewBatch())
+	if err == nil {
+		t.Error("InsertBatch() deveria retornar erro com conexão nil")
+	}
+}
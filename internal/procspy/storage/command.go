@@ -48,6 +48,11 @@ CREATE TABLE IF NOT EXISTS command_log_old (
 	command_log TEXT DEFAULT NULL,
 	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
 );
+`
+	archive := `
+CREATE UNIQUE INDEX IF NOT EXISTS command_log_event_id ON command_log (event_id);
+
+CREATE INDEX IF NOT EXISTS command_log_old_event_id ON command_log_old (event_id);
 
 INSERT INTO command_log_old
 SELECT
@@ -58,7 +63,8 @@ SELECT
 	command_return,
 	source,
 	command_log,
-	created_at
+	created_at,
+	event_id
 FROM	
 	command_log
 WHERE
@@ -79,6 +85,22 @@ WHERE
 
 	if err != nil {
 		log.Printf("[storage.Command.Init] Failed to create command tables: %v", err)
+		return err
+	}
+
+	for _, table := range []string{"command_log", "command_log_old"} {
+		err = c.conn.AddColumn(table, "event_id", "TEXT DEFAULT NULL")
+
+		if err != nil {
+			log.Printf("[storage.Command.Init] Failed to migrate table '%s': %v", table, err)
+			return err
+		}
+	}
+
+	err = c.conn.Exec(archive)
+
+	if err != nil {
+		log.Printf("[storage.Command.Init] Failed to archive old commands: %v", err)
 	}
 
 	return err
@@ -93,25 +115,48 @@ func (c *Command) Close() error {
 	return c.conn.Close()
 }
 
-func (c *Command) InsertCommand(cmd *domain.Command) error {
-	insert := `
+const insertCommandQuery = `
 INSERT INTO command_log (
+	event_id,
 	user, 
 	name, 
 	command_line, 
 	command_return, 
 	source, 
 	command_log)
-VALUES 
-	(?, ?, ?, ?, ?, ?)
+SELECT 
+	?, ?, ?, ?, ?, ?, ?
+WHERE NOT EXISTS (
+	SELECT 1 FROM command_log_old WHERE event_id = ?
+)
+ON CONFLICT (event_id) DO NOTHING;
 `
-	err := c.conn.Exec(insert, cmd.User, cmd.Name, cmd.CommandLine, cmd.Return, cmd.Source, cmd.CommandLog)
+
+func insertCommandArgs(cmd *domain.Command) []any {
+	eventID := nullIfEmpty(cmd.EventID)
+	return []any{eventID, cmd.User, cmd.Name, cmd.CommandLine, cmd.Return, cmd.Source, cmd.CommandLog, eventID}
+}
+
+// InsertCommand stores a command. A command whose event_id was already
+// stored is ignored and reported as success, so clients can safely retry.
+func (c *Command) InsertCommand(cmd *domain.Command) error {
+	if c.conn == nil {
+		log.Printf("[storage.Command.InsertCommand] Cannot insert command: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	affected, err := c.conn.ExecAffected(insertCommandQuery, insertCommandArgs(cmd)...)
 
 	if err != nil {
 		log.Printf("[storage.Command.InsertCommand] Failed to insert command for user '%s': %v", cmd.User, err)
+		return err
 	}
 
-	return err
+	if affected == 0 {
+		log.Printf("[storage.Command.InsertCommand] Duplicate command '%s' for user '%s' ignored", cmd.EventID, cmd.User)
+	}
+
+	return nil
 }
 
 func (c *Command) GetCommands(user string) ([]*domain.Command, error) {
@@ -177,3 +177,25 @@ func TestCommand_InsertCommand_WithAllFields(t *testing.T) {
 		t.Errorf("CommandLine = %s, esperado %s", retrieved.CommandLine, cmd.CommandLine)
 	}
 }
+
+// TestCommand_InsertCommand_Duplicate testa que reenvio do mesmo evento não duplica o log
+func TestCommand_InsertCommand_Duplicate(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewCommand(conn)
+	cmd := domain.NewCommand("user1", "games", "notify-send test", "success")
+
+	if err := storage.InsertCommand(cmd); err != nil {
+		t.Fatalf("InsertCommand() erro = %v", err)
+	}
+
+	if err := storage.InsertCommand(cmd); err != nil {
+		t.Errorf("InsertCommand() duplicado deveria retornar nil, obteve erro = %v", err)
+	}
+
+	commands, _ := storage.GetCommands("user1")
+	if len(commands) != 1 {
+		t.Errorf("Esperado 1 command, obteve %d", len(commands))
+	}
+}
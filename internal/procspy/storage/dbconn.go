@@ -35,6 +35,12 @@ func (d *DbConnection) GetConn() (*sql.DB, error) {
 			log.Printf("[storage.DbConnection.GetConn] Failed to connect to database '%s': %v", path, err)
 			return nil, err
 		}
+
+		if path == ":memory:" {
+			// each pooled connection would get its own empty in-memory database
+			conn.SetMaxOpenConns(1)
+		}
+
 		d.conn = conn
 	}
 
@@ -62,37 +68,98 @@ func (d *DbConnection) Close() error {
 }
 
 func (d *DbConnection) Exec(query string, args ...any) error {
+	_, err := d.ExecAffected(query, args...)
+	return err
+}
+
+// ExecAffected executes a statement and returns the number of rows affected.
+func (d *DbConnection) ExecAffected(query string, args ...any) (int64, error) {
 	conn, err := d.GetConn()
 
 	if err != nil {
 		log.Printf("[storage.DbConnection.Exec] Failed to get database connection: %v", err)
-		return err
+		return 0, err
 	}
 
 	res, err := conn.Exec(query, args...)
 
 	if err != nil {
 		log.Printf("[storage.DbConnection.Exec] Failed to execute query: %v", err)
-		return err
+		return 0, err
 	}
 
+	var affected int64
+
 	if res != nil {
-		affected, err := res.RowsAffected()
+		affected, err = res.RowsAffected()
 
 		if err != nil {
 			log.Printf("[storage.DbConnection.Exec] Failed to get rows affected count: %v", err)
-			return err
+			return 0, err
 		}
 
 		lastId, err := res.LastInsertId()
 
 		if err != nil {
 			log.Printf("[storage.DbConnection.Exec] Failed to get last insert ID: %v", err)
-			return err
+			return affected, err
 		}
 
 		log.Printf("[storage.DbConnection.Exec] Query executed successfully (%d rows affected, last insert ID: %d)", affected, lastId)
 	}
 
-	return nil
+	return affected, nil
+}
+
+// AddColumn adds a column to an existing table when it is missing, so
+// databases created by older versions are migrated in place.
+func (d *DbConnection) AddColumn(table string, column string, definition string) error {
+	conn, err := d.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.DbConnection.AddColumn] Failed to get database connection: %v", err)
+		return err
+	}
+
+	rows, err := conn.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s');", table))
+
+	if err != nil {
+		log.Printf("[storage.DbConnection.AddColumn] Failed to read columns of table '%s': %v", table, err)
+		return err
+	}
+
+	found := false
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			log.Printf("[storage.DbConnection.AddColumn] Failed to scan columns of table '%s': %v", table, err)
+			return err
+		}
+
+		if name == column {
+			found = true
+		}
+	}
+
+	rows.Close()
+
+	if found {
+		return nil
+	}
+
+	log.Printf("[storage.DbConnection.AddColumn] Adding column '%s' to table '%s'", column, table)
+
+	return d.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, definition))
+}
+
+// nullIfEmpty stores empty strings as NULL, so optional unique columns do
+// not collide for rows sent by older clients.
+func nullIfEmpty(value string) any {
+	if value == "" {
+		return nil
+	}
+
+	return value
 }
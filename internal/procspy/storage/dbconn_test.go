@@ -153,3 +153,24 @@ func TestDbConnection_Exec_MultipleOperations(t *testing.T) {
 		t.Errorf("Exec() erro ao deletar = %v", err)
 	}
 }
+
+// TestDbConnection_AddColumn testa adição idempotente de coluna
+func TestDbConnection_AddColumn(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	conn.Exec("CREATE TABLE test4 (id INTEGER PRIMARY KEY)")
+
+	if err := conn.AddColumn("test4", "value", "TEXT DEFAULT NULL"); err != nil {
+		t.Fatalf("AddColumn() erro = %v", err)
+	}
+
+	// Segunda chamada não deve falhar com coluna duplicada
+	if err := conn.AddColumn("test4", "value", "TEXT DEFAULT NULL"); err != nil {
+		t.Errorf("AddColumn() segunda vez erro = %v", err)
+	}
+
+	if err := conn.Exec("INSERT INTO test4 (value) VALUES (?)", "ok"); err != nil {
+		t.Errorf("Exec() erro ao inserir na nova coluna = %v", err)
+	}
+}
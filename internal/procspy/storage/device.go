@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+)
+
+type Device struct {
+	conn *DbConnection
+}
+
+func NewDevice(dbConn *DbConnection) *Device {
+	ret := &Device{
+		conn: dbConn,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Device.NewDevice] Failed to initialize device storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+func (d *Device) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS devices (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user TEXT NOT NULL,
+	name TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	last_seen_at TIMESTAMP DEFAULT NULL,
+	revoked_at TIMESTAMP DEFAULT NULL
+);
+`
+	if d.conn == nil {
+		log.Printf("[storage.Device.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := d.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Device.Init] Failed to create device tables: %v", err)
+	}
+
+	return err
+}
+
+func (d *Device) Close() error {
+	if d.conn == nil {
+		log.Printf("[storage.Device.Close] Database connection is already closed")
+		return nil
+	}
+
+	return d.conn.Close()
+}
+
+// InsertDevice stores a device with the hash of its token and sets its ID.
+func (d *Device) InsertDevice(device *domain.Device, tokenHash string) error {
+	insert := `
+INSERT INTO devices
+(
+	user,
+	name,
+	token_hash
+)
+VALUES
+(
+	?,
+	?,
+	?
+);`
+
+	if d.conn == nil {
+		log.Printf("[storage.Device.InsertDevice] Cannot insert device: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	conn, err := d.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Device.InsertDevice] Failed to get database connection: %v", err)
+		return err
+	}
+
+	res, err := conn.Exec(insert, device.User, device.Name, tokenHash)
+
+	if err != nil {
+		log.Printf("[storage.Device.InsertDevice] Failed to insert device '%s' for user '%s': %v", device.Name, device.User, err)
+		return err
+	}
+
+	device.ID, err = res.LastInsertId()
+
+	return err
+}
+
+const selectDevice = `
+SELECT
+	id,
+	user,
+	name,
+	coalesce(created_at, ''),
+	coalesce(last_seen_at, ''),
+	coalesce(revoked_at, '')
+FROM
+	devices
+`
+
+func scanDevice(row interface{ Scan(dest ...any) error }) (*domain.Device, error) {
+	ret := &domain.Device{}
+	err := row.Scan(&ret.ID, &ret.User, &ret.Name, &ret.CreatedAt, &ret.LastSeenAt, &ret.RevokedAt)
+	return ret, err
+}
+
+// GetDeviceByTokenHash returns the device owning a token hash, or nil when
+// no device matches.
+func (d *Device) GetDeviceByTokenHash(tokenHash string) (*domain.Device, error) {
+	if d.conn == nil {
+		log.Printf("[storage.Device.GetDeviceByTokenHash] Cannot query device: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := d.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Device.GetDeviceByTokenHash] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	ret, err := scanDevice(conn.QueryRow(selectDevice+"WHERE token_hash = ?;", tokenHash))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Device.GetDeviceByTokenHash] Failed to query device: %v", err)
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// GetDevice returns a device by ID, or nil when it does not exist.
+func (d *Device) GetDevice(id int64) (*domain.Device, error) {
+	if d.conn == nil {
+		log.Printf("[storage.Device.GetDevice] Cannot query device: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := d.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Device.GetDevice] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	ret, err := scanDevice(conn.QueryRow(selectDevice+"WHERE id = ?;", id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Device.GetDevice] Failed to query device %d: %v", id, err)
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (d *Device) GetDevices(user string) ([]*domain.Device, error) {
+	if d.conn == nil {
+		log.Printf("[storage.Device.GetDevices] Cannot query devices: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := d.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Device.GetDevices] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(selectDevice+"WHERE user = ? ORDER BY id;", user)
+
+	if err != nil {
+		log.Printf("[storage.Device.GetDevices] Failed to query devices for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]*domain.Device, 0)
+
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			log.Printf("[storage.Device.GetDevices] Failed to scan device row for user '%s': %v", user, err)
+			return nil, err
+		}
+		ret = append(ret, device)
+	}
+
+	return ret, nil
+}
+
+// RevokeDevice marks a device as revoked. It returns false when the device
+// does not exist or was already revoked.
+func (d *Device) RevokeDevice(id int64) (bool, error) {
+	if d.conn == nil {
+		log.Printf("[storage.Device.RevokeDevice] Cannot revoke device: database connection is nil")
+		return false, errors.New("db is nil")
+	}
+
+	affected, err := d.conn.ExecAffected("UPDATE devices SET revoked_at = datetime('now', 'localtime') WHERE id = ? and revoked_at IS NULL;", id)
+
+	if err != nil {
+		log.Printf("[storage.Device.RevokeDevice] Failed to revoke device %d: %v", id, err)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (d *Device) TouchDevice(id int64) error {
+	if d.conn == nil {
+		log.Printf("[storage.Device.TouchDevice] Cannot update device: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := d.conn.Exec("UPDATE devices SET last_seen_at = datetime('now', 'localtime') WHERE id = ?;", id)
+
+	if err != nil {
+		log.Printf("[storage.Device.TouchDevice] Failed to update last seen of device %d: %v", id, err)
+	}
+
+	return err
+}
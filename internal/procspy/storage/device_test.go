@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"procspy/internal/procspy/domain"
+	"testing"
+)
+
+// TestDevice_InsertDevice testa inserção e busca de dispositivo por hash
+func TestDevice_InsertDevice(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewDevice(conn)
+	device := domain.NewDevice("user1", "notebook")
+
+	if err := storage.InsertDevice(device, "hash1"); err != nil {
+		t.Fatalf("InsertDevice() erro = %v", err)
+	}
+
+	found, err := storage.GetDeviceByTokenHash("hash1")
+	if err != nil {
+		t.Fatalf("GetDeviceByTokenHash() erro = %v", err)
+	}
+
+	if found == nil || found.ID != device.ID || found.User != "user1" {
+		t.Errorf("Dispositivo inesperado: %+v", found)
+	}
+
+	missing, err := storage.GetDeviceByTokenHash("other")
+	if err != nil || missing != nil {
+		t.Errorf("GetDeviceByTokenHash() inexistente = %+v, %v", missing, err)
+	}
+
+	// Hash de token é único
+	if err := storage.InsertDevice(domain.NewDevice("user2", "pc"), "hash1"); err == nil {
+		t.Error("InsertDevice() com hash duplicado deveria retornar erro")
+	}
+}
+
+// TestDevice_RevokeDevice testa revogação de dispositivo
+func TestDevice_RevokeDevice(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewDevice(conn)
+	device := domain.NewDevice("user1", "notebook")
+	storage.InsertDevice(device, "hash1")
+
+	found, err := storage.RevokeDevice(device.ID)
+	if err != nil || !found {
+		t.Fatalf("RevokeDevice() = %v, %v", found, err)
+	}
+
+	devices, _ := storage.GetDevices("user1")
+	if len(devices) != 1 || !devices[0].IsRevoked() {
+		t.Errorf("Dispositivo deveria estar revogado: %+v", devices)
+	}
+}
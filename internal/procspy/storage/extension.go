@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+)
+
+type Extension struct {
+	conn *DbConnection
+}
+
+func NewExtension(dbConn *DbConnection) *Extension {
+	ret := &Extension{
+		conn: dbConn,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Extension.NewExtension] Failed to initialize extension storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+func (e *Extension) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS extension_requests (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user TEXT NOT NULL,
+	target TEXT NOT NULL,
+	duration REAL DEFAULT 0,
+	reason TEXT DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending',
+	decided_by TEXT DEFAULT '',
+	note TEXT DEFAULT '',
+	grant_id INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	decided_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_extension_requests_user ON extension_requests(user, status);
+`
+	if e.conn == nil {
+		log.Printf("[storage.Extension.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := e.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Extension.Init] Failed to create extension tables: %v", err)
+	}
+
+	return err
+}
+
+func (e *Extension) Close() error {
+	if e.conn == nil {
+		log.Printf("[storage.Extension.Close] Database connection is already closed")
+		return nil
+	}
+
+	return e.conn.Close()
+}
+
+// InsertExtension queues a pending request and sets its ID.
+func (e *Extension) InsertExtension(extension *domain.Extension) error {
+	insert := `
+INSERT INTO extension_requests
+(
+	user,
+	target,
+	duration,
+	reason
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?
+);`
+
+	if e.conn == nil {
+		log.Printf("[storage.Extension.InsertExtension] Cannot insert extension: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	conn, err := e.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Extension.InsertExtension] Failed to get database connection: %v", err)
+		return err
+	}
+
+	res, err := conn.Exec(insert, extension.User, extension.Target, extension.Duration.Seconds(), extension.Reason)
+
+	if err != nil {
+		log.Printf("[storage.Extension.InsertExtension] Failed to insert extension of target '%s' for user '%s': %v", extension.Target, extension.User, err)
+		return err
+	}
+
+	extension.ID, err = res.LastInsertId()
+	extension.Status = domain.EXTENSION_PENDING
+
+	return err
+}
+
+const selectExtension = `
+SELECT
+	id,
+	user,
+	target,
+	duration,
+	coalesce(reason, ''),
+	status,
+	coalesce(decided_by, ''),
+	coalesce(note, ''),
+	coalesce(grant_id, 0),
+	coalesce(created_at, ''),
+	coalesce(decided_at, '')
+FROM
+	extension_requests
+`
+
+func scanExtension(row interface{ Scan(dest ...any) error }) (*domain.Extension, error) {
+	ret := &domain.Extension{}
+	err := row.Scan(&ret.ID, &ret.User, &ret.Target, &ret.Duration, &ret.Reason, &ret.Status, &ret.DecidedBy, &ret.Note, &ret.GrantID, &ret.CreatedAt, &ret.DecidedAt)
+	return ret, err
+}
+
+// GetExtension returns a request of a user, or nil when it does not exist.
+func (e *Extension) GetExtension(user string, id int64) (*domain.Extension, error) {
+	if e.conn == nil {
+		log.Printf("[storage.Extension.GetExtension] Cannot query extension: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := e.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Extension.GetExtension] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	ret, err := scanExtension(conn.QueryRow(selectExtension+"WHERE user = ? and id = ?;", user, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Extension.GetExtension] Failed to query extension %d of user '%s': %v", id, user, err)
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// GetExtensions returns the requests of a user, newest first. An empty
+// status returns all of them.
+func (e *Extension) GetExtensions(user string, status string) ([]*domain.Extension, error) {
+	if e.conn == nil {
+		log.Printf("[storage.Extension.GetExtensions] Cannot query extensions: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := e.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Extension.GetExtensions] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(selectExtension+"WHERE user = ? and (? = '' or status = ?) ORDER BY id DESC;", user, status, status)
+
+	if err != nil {
+		log.Printf("[storage.Extension.GetExtensions] Failed to query extensions for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]*domain.Extension, 0)
+
+	for rows.Next() {
+		extension, err := scanExtension(rows)
+		if err != nil {
+			log.Printf("[storage.Extension.GetExtensions] Failed to scan extension row for user '%s': %v", user, err)
+			return nil, err
+		}
+		ret = append(ret, extension)
+	}
+
+	return ret, nil
+}
+
+// DecideExtension stores the decision on a pending request. It returns
+// false when the request does not exist or was already decided.
+func (e *Extension) DecideExtension(extension *domain.Extension) (bool, error) {
+	update := `
+UPDATE extension_requests SET
+	status = ?,
+	decided_by = ?,
+	note = ?,
+	grant_id = ?,
+	decided_at = datetime('now', 'localtime')
+WHERE
+	user = ?
+	and id = ?
+	and status = 'pending';`
+
+	if e.conn == nil {
+		log.Printf("[storage.Extension.DecideExtension] Cannot update extension: database connection is nil")
+		return false, errors.New("db is nil")
+	}
+
+	affected, err := e.conn.ExecAffected(update, extension.Status, extension.DecidedBy, extension.Note, extension.GrantID, extension.User, extension.ID)
+
+	if err != nil {
+		log.Printf("[storage.Extension.DecideExtension] Failed to decide extension %d of user '%s': %v", extension.ID, extension.User, err)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
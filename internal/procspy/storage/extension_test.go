@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"procspy/internal/procspy/domain"
+	"testing"
+)
+
+// TestExtension_DecideExtension testa a fila de pedidos e a decisão única
+func TestExtension_DecideExtension(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewExtension(conn)
+
+	first := &domain.Extension{User: "user1", Target: "games", Duration: 900, Reason: "terminar a partida"}
+	second := &domain.Extension{User: "user1", Target: "videos"}
+
+	for _, extension := range []*domain.Extension{first, second} {
+		if err := storage.InsertExtension(extension); err != nil {
+			t.Fatalf("InsertExtension() erro = %v", err)
+		}
+	}
+
+	pending, err := storage.GetExtensions("user1", domain.EXTENSION_PENDING)
+	if err != nil || len(pending) != 2 || pending[0].ID != second.ID {
+		t.Fatalf("GetExtensions() pendentes = %+v, %v, esperado 2, mais novo primeiro", pending, err)
+	}
+
+	first.Status = domain.EXTENSION_APPROVED
+	first.DecidedBy = "mom"
+	first.GrantID = 7
+
+	if found, err := storage.DecideExtension(first); err != nil || !found {
+		t.Fatalf("DecideExtension() = %v, %v", found, err)
+	}
+
+	// Um pedido já decidido não muda
+	first.Status = domain.EXTENSION_DENIED
+	if found, _ := storage.DecideExtension(first); found {
+		t.Error("DecideExtension() repetido deveria retornar false")
+	}
+
+	found, err := storage.GetExtension("user1", first.ID)
+	if err != nil || found.Status != domain.EXTENSION_APPROVED || found.DecidedBy != "mom" || found.GrantID != 7 || found.DecidedAt == "" || found.Reason != "terminar a partida" {
+		t.Errorf("Pedido inesperado: %+v, %v", found, err)
+	}
+
+	if missing, err := storage.GetExtension("user2", first.ID); err != nil || missing != nil {
+		t.Errorf("GetExtension() de outro usuário = %+v, %v, esperado nil", missing, err)
+	}
+
+	all, _ := storage.GetExtensions("user1", "")
+	pending, _ = storage.GetExtensions("user1", domain.EXTENSION_PENDING)
+	if len(all) != 2 || len(pending) != 1 {
+		t.Errorf("GetExtensions() = %d todos e %d pendentes, esperado 2 e 1", len(all), len(pending))
+	}
+}
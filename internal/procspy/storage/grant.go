@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+	"time"
+)
+
+type Grant struct {
+	conn *DbConnection
+}
+
+func NewGrant(dbConn *DbConnection) *Grant {
+	ret := &Grant{
+		conn: dbConn,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Grant.NewGrant] Failed to initialize grant storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+// Init creates the grants table. Expiry is stored as local time text, like
+// the other timestamps, so it compares with datetime('now', 'localtime').
+func (g *Grant) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS grants (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user TEXT NOT NULL,
+	target TEXT NOT NULL,
+	duration REAL NOT NULL,
+	reason TEXT DEFAULT '',
+	created_by TEXT DEFAULT '',
+	expires_at TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	revoked_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_grants_user ON grants(user, expires_at);
+`
+	if g.conn == nil {
+		log.Printf("[storage.Grant.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := g.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Grant.Init] Failed to create grant tables: %v", err)
+	}
+
+	return err
+}
+
+func (g *Grant) Close() error {
+	if g.conn == nil {
+		log.Printf("[storage.Grant.Close] Database connection is already closed")
+		return nil
+	}
+
+	return g.conn.Close()
+}
+
+// InsertGrant stores a grant and sets its ID.
+func (g *Grant) InsertGrant(grant *domain.Grant) error {
+	insert := `
+INSERT INTO grants
+(
+	user,
+	target,
+	duration,
+	reason,
+	created_by,
+	expires_at
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?,
+	?,
+	?
+);`
+
+	if g.conn == nil {
+		log.Printf("[storage.Grant.InsertGrant] Cannot insert grant: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	conn, err := g.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Grant.InsertGrant] Failed to get database connection: %v", err)
+		return err
+	}
+
+	expires := grant.ExpiresAt.In(time.Local).Format(time.DateTime)
+
+	res, err := conn.Exec(insert, grant.User, grant.Target, grant.Duration.Seconds(), grant.Reason, grant.CreatedBy, expires)
+
+	if err != nil {
+		log.Printf("[storage.Grant.InsertGrant] Failed to insert grant of target '%s' for user '%s': %v", grant.Target, grant.User, err)
+		return err
+	}
+
+	grant.ID, err = res.LastInsertId()
+
+	return err
+}
+
+const selectGrant = `
+SELECT
+	id,
+	user,
+	target,
+	duration,
+	coalesce(reason, ''),
+	coalesce(created_by, ''),
+	expires_at,
+	coalesce(created_at, ''),
+	coalesce(revoked_at, '')
+FROM
+	grants
+`
+
+func scanGrant(row interface{ Scan(dest ...any) error }) (*domain.Grant, error) {
+	ret := &domain.Grant{}
+	expires := ""
+
+	err := row.Scan(&ret.ID, &ret.User, &ret.Target, &ret.Duration, &ret.Reason, &ret.CreatedBy, &expires, &ret.CreatedAt, &ret.RevokedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret.ExpiresAt, err = time.ParseInLocation(time.DateTime, expires, time.Local)
+
+	return ret, err
+}
+
+// GetGrants returns the grants of a user that expire after a moment,
+// revoked ones included, newest first.
+func (g *Grant) GetGrants(user string, since time.Time) ([]*domain.Grant, error) {
+	if g.conn == nil {
+		log.Printf("[storage.Grant.GetGrants] Cannot query grants: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := g.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Grant.GetGrants] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(selectGrant+"WHERE user = ? and expires_at > ? ORDER BY id DESC;", user, since.In(time.Local).Format(time.DateTime))
+
+	if err != nil {
+		log.Printf("[storage.Grant.GetGrants] Failed to query grants for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]*domain.Grant, 0)
+
+	for rows.Next() {
+		grant, err := scanGrant(rows)
+		if err != nil {
+			log.Printf("[storage.Grant.GetGrants] Failed to scan grant row for user '%s': %v", user, err)
+			return nil, err
+		}
+		ret = append(ret, grant)
+	}
+
+	return ret, nil
+}
+
+// RevokeGrant marks a grant of a user as revoked. It returns false when the
+// grant does not exist or was already revoked.
+func (g *Grant) RevokeGrant(user string, id int64) (bool, error) {
+	if g.conn == nil {
+		log.Printf("[storage.Grant.RevokeGrant] Cannot revoke grant: database connection is nil")
+		return false, errors.New("db is nil")
+	}
+
+	affected, err := g.conn.ExecAffected("UPDATE grants SET revoked_at = datetime('now', 'localtime') WHERE id = ? and user = ? and revoked_at IS NULL;", id, user)
+
+	if err != nil {
+		log.Printf("[storage.Grant.RevokeGrant] Failed to revoke grant %d of user '%s': %v", id, user, err)
+		return false, err
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"procspy/internal/procspy/domain"
+	"testing"
+	"time"
+)
+
+// TestGrant_InsertGrant testa inserção e listagem de concessões por expiração
+func TestGrant_InsertGrant(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewGrant(conn)
+	now := time.Now().Truncate(time.Second)
+
+	active := &domain.Grant{User: "user1", Target: "games", Duration: 1800, Reason: "homework done", CreatedBy: "mom", ExpiresAt: now.Add(time.Hour)}
+	expired := &domain.Grant{User: "user1", Target: "games", Duration: 600, ExpiresAt: now.Add(-time.Hour)}
+	other := &domain.Grant{User: "user2", Target: "games", Duration: 600, ExpiresAt: now.Add(time.Hour)}
+
+	for _, grant := range []*domain.Grant{active, expired, other} {
+		if err := storage.InsertGrant(grant); err != nil {
+			t.Fatalf("InsertGrant() erro = %v", err)
+		}
+	}
+
+	grants, err := storage.GetGrants("user1", now)
+	if err != nil {
+		t.Fatalf("GetGrants() erro = %v", err)
+	}
+
+	if len(grants) != 1 {
+		t.Fatalf("GetGrants() = %d concessões, esperado 1", len(grants))
+	}
+
+	found := grants[0]
+	if found.ID != active.ID || found.Duration != 1800 || found.Reason != "homework done" || found.CreatedBy != "mom" || !found.ExpiresAt.Equal(active.ExpiresAt) {
+		t.Errorf("Concessão inesperada: %+v", found)
+	}
+
+	grants, _ = storage.GetGrants("user1", now.Add(-2*time.Hour))
+	if len(grants) != 2 || grants[0].ID != expired.ID {
+		t.Errorf("GetGrants() desde 2h atrás = %+v, esperado as duas concessões, mais nova primeiro", grants)
+	}
+}
+
+// TestGrant_RevokeGrant testa revogação de concessão somente do próprio usuário
+func TestGrant_RevokeGrant(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewGrant(conn)
+	grant := &domain.Grant{User: "user1", Target: "games", Duration: 1800, ExpiresAt: time.Now().Add(time.Hour)}
+	storage.InsertGrant(grant)
+
+	if found, err := storage.RevokeGrant("user2", grant.ID); err != nil || found {
+		t.Errorf("RevokeGrant() de outro usuário = %v, %v, esperado false", found, err)
+	}
+
+	if found, err := storage.RevokeGrant("user1", grant.ID); err != nil || !found {
+		t.Fatalf("RevokeGrant() = %v, %v", found, err)
+	}
+
+	if found, _ := storage.RevokeGrant("user1", grant.ID); found {
+		t.Error("RevokeGrant() repetido deveria retornar false")
+	}
+
+	grants, _ := storage.GetGrants("user1", time.Now())
+	if len(grants) != 1 || !grants[0].IsRevoked() {
+		t.Errorf("Concessão deveria estar revogada: %+v", grants)
+	}
+}
@@ -0,0 +1,362 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+)
+
+// Ledger is the client side, per-day record of elapsed seconds per target.
+// It survives restarts and lets the client enforce limits while the server
+// is unreachable.
+type Ledger struct {
+	conn *DbConnection
+}
+
+func NewLedger(dbConn *DbConnection) *Ledger {
+	ret := &Ledger{
+		conn: dbConn,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Ledger.NewLedger] Failed to initialize ledger storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+func (l *Ledger) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS ledger (
+	day TEXT NOT NULL,
+	user TEXT NOT NULL,
+	name TEXT NOT NULL,
+	elapsed REAL DEFAULT 0,
+	pending REAL DEFAULT 0,
+	server_elapsed REAL DEFAULT 0,
+	updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	PRIMARY KEY (day, user, name)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_targets (
+	user TEXT PRIMARY KEY,
+	targets TEXT NOT NULL,
+	day TEXT DEFAULT '',
+	updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+DELETE FROM ledger
+WHERE
+	day < date('now', 'localtime', '-30 day');
+`
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := l.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Ledger.Init] Failed to create ledger tables: %v", err)
+		return err
+	}
+
+	err = l.conn.AddColumn("ledger_targets", "day", "TEXT DEFAULT ''")
+
+	if err != nil {
+		log.Printf("[storage.Ledger.Init] Failed to migrate ledger tables: %v", err)
+	}
+
+	return err
+}
+
+func (l *Ledger) Close() error {
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.Close] Database connection is already closed")
+		return nil
+	}
+
+	return l.conn.Close()
+}
+
+// AddElapsed records locally observed usage. The amount is also kept as
+// pending until the server acknowledges the corresponding match.
+func (l *Ledger) AddElapsed(user string, day string, name string, elapsed float64) error {
+	upsert := `
+INSERT INTO ledger
+(
+	day,
+	user,
+	name,
+	elapsed,
+	pending
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?,
+	?
+)
+ON CONFLICT (day, user, name) DO UPDATE SET
+	elapsed = elapsed + excluded.elapsed,
+	pending = pending + excluded.pending,
+	updated_at = datetime('now', 'localtime');`
+
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.AddElapsed] Cannot add elapsed: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := l.conn.Exec(upsert, day, user, name, elapsed, elapsed)
+
+	if err != nil {
+		log.Printf("[storage.Ledger.AddElapsed] Failed to add elapsed for user '%s', target '%s' on %s: %v", user, name, day, err)
+	}
+
+	return err
+}
+
+// Ack removes an amount from the pending counter once the server has
+// stored the match that carried it.
+func (l *Ledger) Ack(user string, day string, name string, elapsed float64) error {
+	update := `
+UPDATE ledger SET
+	pending = max(0, pending - ?),
+	updated_at = datetime('now', 'localtime')
+WHERE
+	day = ?
+	and user = ?
+	and name = ?;`
+
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.Ack] Cannot ack elapsed: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := l.conn.Exec(update, elapsed, day, user, name)
+
+	if err != nil {
+		log.Printf("[storage.Ledger.Ack] Failed to ack elapsed for user '%s', target '%s' on %s: %v", user, name, day, err)
+	}
+
+	return err
+}
+
+// GetElapsed returns the elapsed seconds known locally for a target on a day.
+func (l *Ledger) GetElapsed(user string, day string, name string) (float64, error) {
+	elapsed, _, err := l.get(user, day, name)
+	return elapsed, err
+}
+
+// SumElapsed returns the elapsed seconds known locally for a target from one
+// day to another, both included.
+func (l *Ledger) SumElapsed(user string, name string, from string, to string) (float64, error) {
+	query := `
+SELECT
+	coalesce(sum(elapsed), 0)
+FROM
+	ledger
+WHERE
+	user = ?
+	and name = ?
+	and day >= ?
+	and day <= ?;`
+
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.SumElapsed] Cannot query ledger: database connection is nil")
+		return 0, errors.New("db is nil")
+	}
+
+	conn, err := l.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Ledger.SumElapsed] Failed to get database connection: %v", err)
+		return 0, err
+	}
+
+	var elapsed float64
+
+	err = conn.QueryRow(query, user, name, from, to).Scan(&elapsed)
+
+	if err != nil {
+		log.Printf("[storage.Ledger.SumElapsed] Failed to sum ledger for user '%s', target '%s' from %s to %s: %v", user, name, from, to, err)
+		return 0, err
+	}
+
+	return elapsed, nil
+}
+
+// Reconcile merges the server total for a target with the local ledger and
+// returns the elapsed time that must be enforced. See MergeElapsed.
+func (l *Ledger) Reconcile(user string, day string, name string, serverElapsed float64) (float64, error) {
+	local, pending, err := l.get(user, day, name)
+
+	if err != nil {
+		return serverElapsed, err
+	}
+
+	merged := MergeElapsed(local, pending, serverElapsed)
+
+	upsert := `
+INSERT INTO ledger
+(
+	day,
+	user,
+	name,
+	elapsed,
+	server_elapsed
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?,
+	?
+)
+ON CONFLICT (day, user, name) DO UPDATE SET
+	elapsed = excluded.elapsed,
+	server_elapsed = excluded.server_elapsed,
+	updated_at = datetime('now', 'localtime');`
+
+	err = l.conn.Exec(upsert, day, user, name, merged, serverElapsed)
+
+	if err != nil {
+		log.Printf("[storage.Ledger.Reconcile] Failed to store reconciled elapsed for user '%s', target '%s' on %s: %v", user, name, day, err)
+		return merged, err
+	}
+
+	return merged, nil
+}
+
+// MergeElapsed is the reconciliation rule between the local ledger and the
+// server. The server total already contains every acknowledged match, so
+// adding the pending (not yet acknowledged) amount gives the deduplicated
+// sum. The local total wins when it is higher, e.g. when the server lost
+// data or the other side was reset, so usage is never forgotten.
+func MergeElapsed(local float64, pending float64, server float64) float64 {
+	return math.Max(local, server+pending)
+}
+
+func (l *Ledger) get(user string, day string, name string) (float64, float64, error) {
+	query := `
+SELECT
+	elapsed,
+	pending
+FROM
+	ledger
+WHERE
+	day = ?
+	and user = ?
+	and name = ?;`
+
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.get] Cannot query ledger: database connection is nil")
+		return 0, 0, errors.New("db is nil")
+	}
+
+	conn, err := l.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Ledger.get] Failed to get database connection: %v", err)
+		return 0, 0, err
+	}
+
+	var elapsed float64
+	var pending float64
+
+	err = conn.QueryRow(query, day, user, name).Scan(&elapsed, &pending)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Ledger.get] Failed to query ledger for user '%s', target '%s' on %s: %v", user, name, day, err)
+		return 0, 0, err
+	}
+
+	return elapsed, pending, nil
+}
+
+// SaveTargets keeps the last target list received from the server, with the
+// day of its usage totals, so the client can keep enforcing after a restart
+// without connectivity.
+func (l *Ledger) SaveTargets(user string, day string, targets string) error {
+	upsert := `
+INSERT INTO ledger_targets
+(
+	user,
+	targets,
+	day
+)
+VALUES
+(
+	?,
+	?,
+	?
+)
+ON CONFLICT (user) DO UPDATE SET
+	targets = excluded.targets,
+	day = excluded.day,
+	updated_at = datetime('now', 'localtime');`
+
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.SaveTargets] Cannot save targets: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := l.conn.Exec(upsert, user, targets, day)
+
+	if err != nil {
+		log.Printf("[storage.Ledger.SaveTargets] Failed to save targets for user '%s': %v", user, err)
+	}
+
+	return err
+}
+
+// LoadTargets returns the last saved target list and the day of its usage
+// totals, or empty strings when nothing was saved yet.
+func (l *Ledger) LoadTargets(user string) (string, string, error) {
+	query := `
+SELECT
+	targets,
+	day
+FROM
+	ledger_targets
+WHERE
+	user = ?;`
+
+	if l.conn == nil {
+		log.Printf("[storage.Ledger.LoadTargets] Cannot load targets: database connection is nil")
+		return "", "", errors.New("db is nil")
+	}
+
+	conn, err := l.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Ledger.LoadTargets] Failed to get database connection: %v", err)
+		return "", "", err
+	}
+
+	var targets string
+	var day string
+	err = conn.QueryRow(query, user).Scan(&targets, &day)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Ledger.LoadTargets] Failed to load targets for user '%s': %v", user, err)
+		return "", "", err
+	}
+
+	return targets, day, nil
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"testing"
+)
+
+// TestNewLedger testa criação de storage do ledger
+func TestNewLedger(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	ledger := NewLedger(conn)
+	if ledger == nil {
+		t.Fatal("NewLedger retornou nil")
+	}
+}
+
+// TestLedger_AddElapsed testa acumulação de tempo no ledger
+func TestLedger_AddElapsed(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	ledger := NewLedger(conn)
+
+	ledger.AddElapsed("user1", "2025-01-01", "games", 30)
+	ledger.AddElapsed("user1", "2025-01-01", "games", 15)
+	ledger.AddElapsed("user1", "2025-01-02", "games", 100)
+
+	elapsed, err := ledger.GetElapsed("user1", "2025-01-01", "games")
+	if err != nil {
+		t.Fatalf("GetElapsed() erro = %v", err)
+	}
+
+	if elapsed != 45 {
+		t.Errorf("elapsed = %.2f, esperado 45.00", elapsed)
+	}
+}
+
+// TestLedger_GetElapsed_EmptyResult testa busca sem registros
+func TestLedger_GetElapsed_EmptyResult(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	ledger := NewLedger(conn)
+
+	elapsed, err := ledger.GetElapsed("user1", "2025-01-01", "games")
+	if err != nil {
+		t.Fatalf("GetElapsed() erro = %v", err)
+	}
+
+	if elapsed != 0 {
+		t.Errorf("elapsed = %.2f, esperado 0", elapsed)
+	}
+}
+
+// TestLedger_Reconcile testa a regra de merge entre ledger local e servidor
+func TestLedger_Reconcile(t *testing.T) {
+	t.Run("Pendente somado ao total do servidor", func(t *testing.T) {
+		conn := NewDbConnection(":memory:")
+		defer conn.Close()
+
+		ledger := NewLedger(conn)
+
+		// 60s já confirmados pelo servidor, 30s ainda pendentes
+		ledger.AddElapsed("user1", "2025-01-01", "games", 60)
+		ledger.Ack("user1", "2025-01-01", "games", 60)
+		ledger.AddElapsed("user1", "2025-01-01", "games", 30)
+
+		merged, err := ledger.Reconcile("user1", "2025-01-01", "games", 60)
+		if err != nil {
+			t.Fatalf("Reconcile() erro = %v", err)
+		}
+
+		if merged != 90 {
+			t.Errorf("merged = %.2f, esperado 90.00", merged)
+		}
+	})
+
+	t.Run("Servidor com uso de outro dispositivo", func(t *testing.T) {
+		conn := NewDbConnection(":memory:")
+		defer conn.Close()
+
+		ledger := NewLedger(conn)
+		ledger.AddElapsed("user1", "2025-01-01", "games", 10)
+		ledger.Ack("user1", "2025-01-01", "games", 10)
+
+		merged, _ := ledger.Reconcile("user1", "2025-01-01", "games", 500)
+		if merged != 500 {
+			t.Errorf("merged = %.2f, esperado 500.00", merged)
+		}
+
+		// O valor reconciliado fica persistido no ledger
+		elapsed, _ := ledger.GetElapsed("user1", "2025-01-01", "games")
+		if elapsed != 500 {
+			t.Errorf("elapsed = %.2f, esperado 500.00", elapsed)
+		}
+	})
+
+	t.Run("Servidor sem dados não apaga uso local", func(t *testing.T) {
+		conn := NewDbConnection(":memory:")
+		defer conn.Close()
+
+		ledger := NewLedger(conn)
+		ledger.AddElapsed("user1", "2025-01-01", "games", 200)
+		ledger.Ack("user1", "2025-01-01", "games", 200)
+
+		merged, _ := ledger.Reconcile("user1", "2025-01-01", "games", 0)
+		if merged != 200 {
+			t.Errorf("merged = %.2f, esperado 200.00", merged)
+		}
+	})
+}
+
+// TestMergeElapsed testa a regra de merge isoladamente
+func TestMergeElapsed(t *testing.T) {
+	tests := []struct {
+		name     string
+		local    float64
+		pending  float64
+		server   float64
+		expected float64
+	}{
+		{"Tudo sincronizado", 100, 0, 100, 100},
+		{"Pendente não confirmado", 100, 40, 60, 100},
+		{"Servidor maior", 100, 0, 300, 300},
+		{"Servidor maior com pendente", 100, 20, 300, 320},
+		{"Local maior", 300, 0, 100, 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MergeElapsed(tt.local, tt.pending, tt.server)
+			if result != tt.expected {
+				t.Errorf("MergeElapsed(%.2f, %.2f, %.2f) = %.2f, esperado %.2f",
+					tt.local, tt.pending, tt.server, result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestLedger_SaveTargets testa persistência da última lista de targets
+func TestLedger_SaveTargets(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	ledger := NewLedger(conn)
+
+	data, day, err := ledger.LoadTargets("user1")
+	if err != nil {
+		t.Fatalf("LoadTargets() erro = %v", err)
+	}
+	if data != "" || day != "" {
+		t.Errorf("LoadTargets() = %s em %s, esperado vazio", data, day)
+	}
+
+	ledger.SaveTargets("user1", "2025-01-01", `{"targets":[]}`)
+	ledger.SaveTargets("user1", "2025-01-02", `{"targets":[{"name":"games"}]}`)
+
+	data, day, err = ledger.LoadTargets("user1")
+	if err != nil {
+		t.Fatalf("LoadTargets() erro = %v", err)
+	}
+	if data != `{"targets":[{"name":"games"}]}` || day != "2025-01-02" {
+		t.Errorf("LoadTargets() = %s em %s, esperado a última lista salva", data, day)
+	}
+}
+
+// TestLedger_SumElapsed testa a soma do uso local entre dois dias
+func TestLedger_SumElapsed(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	ledger := NewLedger(conn)
+
+	ledger.AddElapsed("user1", "2025-01-01", "games", 10)
+	ledger.AddElapsed("user1", "2025-01-02", "games", 20)
+	ledger.AddElapsed("user1", "2025-01-03", "games", 40)
+	ledger.AddElapsed("user1", "2025-01-02", "videos", 80)
+	ledger.AddElapsed("user2", "2025-01-02", "games", 160)
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected float64
+	}{
+		{"Todos os dias", "2025-01-01", "2025-01-03", 70},
+		{"Intervalo fechado", "2025-01-02", "2025-01-03", 60},
+		{"Um dia", "2025-01-02", "2025-01-02", 20},
+		{"Sem uso", "2025-02-01", "2025-02-28", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ledger.SumElapsed("user1", "games", tt.from, tt.to)
+			if err != nil || result != tt.expected {
+				t.Errorf("SumElapsed(%s, %s) = %.0f, %v, esperado %.0f", tt.from, tt.to, result, err, tt.expected)
+			}
+		})
+	}
+}
+
+// TestLedger_NilConnection testa operações com conexão nil
+func TestLedger_NilConnection(t *testing.T) {
+	ledger := &Ledger{conn: nil}
+
+	if err := ledger.AddElapsed("user1", "2025-01-01", "games", 10); err == nil {
+		t.Error("AddElapsed() deveria retornar erro com conexão nil")
+	}
+
+	if _, err := ledger.GetElapsed("user1", "2025-01-01", "games"); err == nil {
+		t.Error("GetElapsed() deveria retornar erro com conexão nil")
+	}
+
+	if err := ledger.Close(); err != nil {
+		t.Errorf("Close() com conn nil deveria retornar nil, obteve erro = %v", err)
+	}
+}
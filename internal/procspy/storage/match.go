@@ -2,8 +2,11 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"procspy/internal/procspy/domain"
+	"strings"
+	"time"
 )
 
 type Match struct {
@@ -46,6 +49,31 @@ CREATE TABLE IF NOT EXISTS matches_old (
 	elapsed int DEFAULT 60,
 	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
 );
+`
+	// Matches stored before created_utc existed, or by a writer that does not
+	// set it, were stamped with the local time of the server.
+	stamp := `
+UPDATE %[1]s
+SET
+	created_utc = datetime(created_at, 'utc'),
+	utc_offset = CAST(round((julianday(created_at) - julianday(datetime(created_at, 'utc'))) * 86400) AS INTEGER)
+WHERE
+	%[2]s;
+`
+	backfill := ""
+
+	for _, table := range []string{"matches", "matches_old"} {
+		backfill += fmt.Sprintf(stamp, table, "created_utc IS NULL")
+		backfill += fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %[1]s_created_utc AFTER INSERT ON %[1]s WHEN NEW.created_utc IS NULL BEGIN", table)
+		backfill += fmt.Sprintf(stamp, table, "rowid = NEW.rowid") + "END;\n"
+	}
+
+	backfill += "CREATE INDEX IF NOT EXISTS matches_user_created_utc ON matches (user, created_utc);"
+
+	archive := `
+CREATE UNIQUE INDEX IF NOT EXISTS matches_event_id ON matches (event_id);
+
+CREATE INDEX IF NOT EXISTS matches_old_event_id ON matches_old (event_id);
 
 INSERT INTO matches_old
 SELECT
@@ -55,7 +83,12 @@ SELECT
 	pattern,
 	match,
 	elapsed,
-	created_at
+	created_at,
+	event_id,
+	created_utc,
+	utc_offset,
+	field,
+	idle
 FROM
 	matches
 WHERE
@@ -76,6 +109,31 @@ WHERE
 
 	if err != nil {
 		log.Printf("[storage.Match.Init] Failed to create match tables: %v", err)
+		return err
+	}
+
+	for _, table := range []string{"matches", "matches_old"} {
+		for _, column := range [][2]string{{"event_id", "TEXT DEFAULT NULL"}, {"created_utc", "TEXT DEFAULT NULL"}, {"utc_offset", "INTEGER DEFAULT NULL"}, {"field", "TEXT DEFAULT ''"}, {"idle", "REAL DEFAULT 0"}} {
+			err = m.conn.AddColumn(table, column[0], column[1])
+
+			if err != nil {
+				log.Printf("[storage.Match.Init] Failed to migrate table '%s': %v", table, err)
+				return err
+			}
+		}
+	}
+
+	err = m.conn.Exec(backfill)
+
+	if err != nil {
+		log.Printf("[storage.Match.Init] Failed to backfill UTC timestamps of matches: %v", err)
+		return err
+	}
+
+	err = m.conn.Exec(archive)
+
+	if err != nil {
+		log.Printf("[storage.Match.Init] Failed to archive old matches: %v", err)
 	}
 
 	return err
@@ -90,51 +148,104 @@ func (m *Match) Close() error {
 	return m.conn.Close()
 }
 
-func (m *Match) InsertMatch(match *domain.Match) error {
-	insert := `
+const insertMatchQuery = `
 INSERT INTO matches
 (
+	event_id,
 	user,
 	name,
 	pattern,
 	match,
-	elapsed
+	elapsed,
+	created_utc,
+	utc_offset,
+	field,
+	idle
 )
-VALUES
-(	
+SELECT
+	?,
+	?,
+	?,
+	?,
+	?,
 	?,
 	?,
 	?,
 	?,
 	?
-);`
+WHERE NOT EXISTS (
+	SELECT 1 FROM matches_old WHERE event_id = ?
+)
+ON CONFLICT (event_id) DO NOTHING;`
+
+// insertMatchArgs stamps a match with the moment the client saw it, in UTC,
+// and the offset of the client's zone at that moment. A match without it
+// is stamped now.
+func insertMatchArgs(match *domain.Match) []any {
+	eventID := nullIfEmpty(match.EventID)
+	created := match.CreatedAt
+
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	_, offset := created.Zone()
 
+	return []any{eventID, match.User, match.Name, match.Pattern, match.Match, match.Elapsed, utcText(created), offset, match.Field, match.Idle, eventID}
+}
+
+// utcText formats a moment as the UTC text stored in created_utc.
+func utcText(moment time.Time) string {
+	return moment.UTC().Format(time.DateTime)
+}
+
+// zonedText converts a created_utc text to the local text of a zone.
+func zonedText(value string, loc *time.Location) string {
+	moment, err := time.ParseInLocation(time.DateTime, value, time.UTC)
+
+	if err != nil {
+		return value
+	}
+
+	return moment.In(loc).Format(time.DateTime)
+}
+
+// InsertMatch stores a match. A match whose event_id was already stored is
+// ignored and reported as success, so clients can safely retry.
+func (m *Match) InsertMatch(match *domain.Match) error {
 	if m.conn == nil {
 		log.Printf("[storage.Match.InsertMatch] Cannot insert match: database connection is nil")
 		return errors.New("db is nil")
 	}
 
-	err := m.conn.Exec(insert, match.User, match.Name, match.Pattern, match.Match, match.Elapsed)
+	affected, err := m.conn.ExecAffected(insertMatchQuery, insertMatchArgs(match)...)
 
 	if err != nil {
 		log.Printf("[storage.Match.InsertMatch] Failed to insert match for user '%s', pattern '%s': %v", match.User, match.Pattern, err)
+		return err
 	}
 
-	return err
+	if affected == 0 {
+		log.Printf("[storage.Match.InsertMatch] Duplicate match '%s' for user '%s' ignored", match.EventID, match.User)
+	}
+
+	return nil
 }
 
-func (m *Match) GetMatches(user string) (map[string]float64, error) {
+// GetMatches returns the usage of each target of a user from a moment on,
+// usually the midnight that started the user's day.
+func (m *Match) GetMatches(user string, since time.Time) (map[string]float64, error) {
 	query := `
 SELECT
 	name,
 	sum(elapsed) elapsed,
-    min(created_at) first_elapsed,
-    max(created_at) last_elapsed	
+    min(created_utc) first_elapsed,
+    max(created_utc) last_elapsed	
 FROM
 	matches
 WHERE
 	user = ?
-	and date(created_at) >= date('now', 'localtime')
+	and created_utc >= ?
 GROUP BY
 	name
 ORDER BY	
@@ -147,7 +258,7 @@ ORDER BY
 		return nil, err
 	}
 
-	rows, err := conn.Query(query, user)
+	rows, err := conn.Query(query, user, utcText(since))
 
 	if err != nil {
 		log.Printf("[storage.Match.GetMatches] Failed to query matches for user '%s': %v", user, err)
@@ -176,19 +287,21 @@ ORDER BY
 	return ret, nil
 }
 
-func (m *Match) GetMatchesInfo(user string) (map[string]*domain.MatchInfo, error) {
+// GetMatchesInfo returns the usage of each target of a user from a moment
+// on, with its first and last match in the zone of that moment.
+func (m *Match) GetMatchesInfo(user string, since time.Time) (map[string]*domain.MatchInfo, error) {
 	query := `
 SELECT
 	name,
 	sum(elapsed) elapsed,
-    min(created_at) first,
-    max(created_at) last,
+    min(created_utc) first,
+    max(created_utc) last,
 	count(*) ocurrences
 FROM
 	matches
 WHERE
 	user = ?
-	and date(created_at) >= date('now', 'localtime')
+	and created_utc >= ?
 GROUP BY
 	name
 ORDER BY	
@@ -201,7 +314,7 @@ ORDER BY
 		return nil, err
 	}
 
-	rows, err := conn.Query(query, user)
+	rows, err := conn.Query(query, user, utcText(since))
 
 	if err != nil {
 		log.Printf("[storage.Match.GetMatchesInfo] Failed to query match info for user '%s': %v", user, err)
@@ -229,11 +342,172 @@ ORDER BY
 
 		ret[name] = &domain.MatchInfo{
 			Elapsed:    elapsed,
-			FirstMatch: first,
-			LastMatch:  last,
+			FirstMatch: zonedText(first, since.Location()),
+			LastMatch:  zonedText(last, since.Location()),
 			Ocurrences: ocurrences,
 		}
 	}
 
 	return ret, nil
 }
+
+// GetUnionElapsed returns the usage of a set of targets from a moment on
+// counting each minute once, however many of them ran in it. It is the
+// usage of a budget group whose members are the targets.
+func (m *Match) GetUnionElapsed(user string, names []string, since time.Time) (float64, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	query := `
+SELECT
+	coalesce(sum(elapsed), 0)
+FROM (
+	SELECT
+		max(elapsed) elapsed
+	FROM (
+		SELECT
+			strftime('%Y-%m-%d %H:%M', created_utc) minute,
+			sum(elapsed) elapsed
+		FROM
+			matches
+		WHERE
+			user = ?
+			and name IN (?` + strings.Repeat(", ?", len(names)-1) + `)
+			and created_utc >= ?
+		GROUP BY
+			minute,
+			name
+	)
+	GROUP BY
+		minute
+);
+`
+	conn, err := m.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Match.GetUnionElapsed] Failed to get database connection: %v", err)
+		return 0, err
+	}
+
+	args := []any{user}
+	for _, name := range names {
+		args = append(args, name)
+	}
+
+	args = append(args, utcText(since))
+
+	var ret float64
+	err = conn.QueryRow(query, args...).Scan(&ret)
+
+	if err != nil {
+		log.Printf("[storage.Match.GetUnionElapsed] Failed to query usage of %v for user '%s': %v", names, user, err)
+		return 0, err
+	}
+
+	return ret, nil
+}
+
+// matchesSince selects the matches of a user from a moment on from both
+// tables. Archived matches are counted once even when the archive copied
+// them more than once.
+const matchesSince = `
+	SELECT id, name, elapsed, created_utc FROM matches WHERE user = ? and created_utc >= ?
+	UNION
+	SELECT id, name, elapsed, created_utc FROM matches_old WHERE user = ? and created_utc >= ?
+`
+
+// GetElapsedSince returns the usage of each target of a user from a moment
+// on, such as the midnight that started the week.
+func (m *Match) GetElapsedSince(user string, since time.Time) (map[string]float64, error) {
+	query := `
+SELECT
+	name,
+	sum(elapsed)
+FROM (` + matchesSince + `)
+GROUP BY
+	name;
+`
+	conn, err := m.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Match.GetElapsedSince] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	day := utcText(since)
+	rows, err := conn.Query(query, user, day, user, day)
+
+	if err != nil {
+		log.Printf("[storage.Match.GetElapsedSince] Failed to query usage since %s for user '%s': %v", since.Format(time.RFC3339), user, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make(map[string]float64)
+
+	for rows.Next() {
+		var name string
+		var elapsed float64
+
+		if err := rows.Scan(&name, &elapsed); err != nil {
+			log.Printf("[storage.Match.GetElapsedSince] Failed to scan usage row for user '%s': %v", user, err)
+			return nil, err
+		}
+
+		ret[name] = elapsed
+	}
+
+	return ret, nil
+}
+
+// GetDailyElapsed returns the usage of each target of a user per day
+// (YYYY-MM-DD) from a moment on. Days start at midnight of the zone of
+// that moment, so they are summed here rather than by SQLite.
+func (m *Match) GetDailyElapsed(user string, since time.Time) (map[string]map[string]float64, error) {
+	query := `
+SELECT
+	name,
+	created_utc,
+	elapsed
+FROM (` + matchesSince + `);
+`
+	conn, err := m.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Match.GetDailyElapsed] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	day := utcText(since)
+	rows, err := conn.Query(query, user, day, user, day)
+
+	if err != nil {
+		log.Printf("[storage.Match.GetDailyElapsed] Failed to query daily usage since %s for user '%s': %v", since.Format(time.RFC3339), user, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make(map[string]map[string]float64)
+
+	for rows.Next() {
+		var name, created string
+		var elapsed float64
+
+		if err := rows.Scan(&name, &created, &elapsed); err != nil {
+			log.Printf("[storage.Match.GetDailyElapsed] Failed to scan daily usage row for user '%s': %v", user, err)
+			return nil, err
+		}
+
+		if ret[name] == nil {
+			ret[name] = make(map[string]float64)
+		}
+
+		date, _, _ := strings.Cut(zonedText(created, since.Location()), " ")
+		ret[name][date] += elapsed
+	}
+
+	return ret, nil
+}
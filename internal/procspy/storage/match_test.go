@@ -3,8 +3,15 @@ package storage
 import (
 	"procspy/internal/procspy/domain"
 	"testing"
+	"time"
 )
 
+// today retorna a meia-noite que iniciou o dia local
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
 // TestNewMatch testa criação de storage de match
 func TestNewMatch(t *testing.T) {
 	conn := NewDbConnection(":memory:")
@@ -43,7 +50,7 @@ func TestMatch_GetMatches(t *testing.T) {
 	storage.InsertMatch(domain.NewMatch("user1", "browsers", "chrome", "chrome.exe", 200.0))
 
 	// Busca matches
-	matches, err := storage.GetMatches("user1")
+	matches, err := storage.GetMatches("user1", today())
 	if err != nil {
 		t.Fatalf("GetMatches() erro = %v", err)
 	}
@@ -74,7 +81,7 @@ func TestMatch_GetMatchesInfo(t *testing.T) {
 	storage.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam.exe", 50.0))
 
 	// Busca info
-	info, err := storage.GetMatchesInfo("user1")
+	info, err := storage.GetMatchesInfo("user1", today())
 	if err != nil {
 		t.Fatalf("GetMatchesInfo() erro = %v", err)
 	}
@@ -124,7 +131,7 @@ func TestMatch_GetMatches_EmptyResult(t *testing.T) {
 	storage := NewMatch(conn)
 
 	// Busca matches de usuário que não existe
-	matches, err := storage.GetMatches("user_inexistente")
+	matches, err := storage.GetMatches("user_inexistente", today())
 	if err != nil {
 		t.Fatalf("GetMatches() erro = %v", err)
 	}
@@ -135,6 +142,92 @@ func TestMatch_GetMatches_EmptyResult(t *testing.T) {
 }
 
 // TestMatch_GetMatchesInfo_EmptyResult testa busca de info sem resultados
+// TestMatch_GetUnionElapsed testa o uso de um grupo de targets contando cada minuto uma vez
+func TestMatch_GetUnionElapsed(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewMatch(conn)
+
+	insert := "INSERT INTO matches (user, name, pattern, match, elapsed, created_at) VALUES (?, ?, '', '', ?, datetime('now', 'localtime', 'start of day', ?));"
+	rows := []struct {
+		user    string
+		name    string
+		elapsed float64
+		offset  string
+	}{
+		// games e videos no mesmo minuto contam uma vez
+		{"user1", "games", 60, "+10 minutes"},
+		{"user1", "videos", 60, "+10 minutes"},
+		// dois registros de 30s do mesmo target somam o minuto
+		{"user1", "games", 30, "+20 minutes"},
+		{"user1", "games", 30, "+20 minutes"},
+		{"user1", "videos", 60, "+30 minutes"},
+		// targets fora do grupo e outros usuários são ignorados
+		{"user1", "school", 60, "+40 minutes"},
+		{"user2", "games", 60, "+50 minutes"},
+	}
+
+	for _, row := range rows {
+		if err := conn.Exec(insert, row.user, row.name, row.elapsed, row.offset); err != nil {
+			t.Fatalf("Falha ao inserir match: %v", err)
+		}
+	}
+
+	elapsed, err := storage.GetUnionElapsed("user1", []string{"games", "videos"}, today())
+	if err != nil {
+		t.Fatalf("GetUnionElapsed() erro = %v", err)
+	}
+
+	if elapsed != 180 {
+		t.Errorf("GetUnionElapsed() = %.0f, esperado 180", elapsed)
+	}
+
+	if elapsed, _ := storage.GetUnionElapsed("user1", nil, today()); elapsed != 0 {
+		t.Errorf("GetUnionElapsed() sem targets = %.0f, esperado 0", elapsed)
+	}
+}
+
+// TestMatch_GetElapsedSince testa o uso acumulado desde um dia, incluindo matches arquivados
+func TestMatch_GetElapsedSince(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewMatch(conn)
+
+	storage.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam.exe", 60))
+
+	archive := "INSERT INTO matches_old (id, user, name, pattern, match, elapsed, created_at) VALUES (?, ?, 'games', '', '', ?, date('now', 'localtime', ?));"
+	rows := []struct {
+		id      int
+		user    string
+		elapsed float64
+		offset  string
+	}{
+		{100, "user1", 600, "-2 days"},
+		// mesmo match copiado duas vezes pelo arquivamento
+		{101, "user1", 300, "-3 days"},
+		{101, "user1", 300, "-3 days"},
+		{102, "user1", 900, "-20 days"},
+		{103, "user2", 900, "-2 days"},
+	}
+
+	for _, row := range rows {
+		if err := conn.Exec(archive, row.id, row.user, row.elapsed, row.offset); err != nil {
+			t.Fatalf("Falha ao arquivar match: %v", err)
+		}
+	}
+
+	elapsed, err := storage.GetElapsedSince("user1", today().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("GetElapsedSince() erro = %v", err)
+	}
+
+	if elapsed["games"] != 960 {
+		t.Errorf("GetElapsedSince() = %.0f, esperado 960", elapsed["games"])
+	}
+}
+
 func TestMatch_GetMatchesInfo_EmptyResult(t *testing.T) {
 	conn := NewDbConnection(":memory:")
 	defer conn.Close()
@@ -142,7 +235,7 @@ func TestMatch_GetMatchesInfo_EmptyResult(t *testing.T) {
 	storage := NewMatch(conn)
 
 	// Busca info de usuário que não existe
-	info, err := storage.GetMatchesInfo("user_inexistente")
+	info, err := storage.GetMatchesInfo("user_inexistente", today())
 	if err != nil {
 		t.Fatalf("GetMatchesInfo() erro = %v", err)
 	}
@@ -174,13 +267,13 @@ func TestMatch_InsertMatch_MultipleUsers(t *testing.T) {
 	storage.InsertMatch(domain.NewMatch("user2", "games", "steam", "steam.exe", 200.0))
 
 	// Busca matches de user1
-	matches1, err := storage.GetMatches("user1")
+	matches1, err := storage.GetMatches("user1", today())
 	if err != nil {
 		t.Fatalf("GetMatches(user1) erro = %v", err)
 	}
 
 	// Busca matches de user2
-	matches2, err := storage.GetMatches("user2")
+	matches2, err := storage.GetMatches("user2", today())
 	if err != nil {
 		t.Fatalf("GetMatches(user2) erro = %v", err)
 	}
@@ -198,3 +291,141 @@ func TestMatch_InsertMatch_MultipleUsers(t *testing.T) {
 		}
 	}
 }
+
+// TestMatch_InsertMatch_Duplicate testa que reenvio do mesmo evento não duplica o uso
+func TestMatch_InsertMatch_Duplicate(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewMatch(conn)
+	match := domain.NewMatch("user1", "games", "steam", "steam.exe", 100.0)
+
+	if err := storage.InsertMatch(match); err != nil {
+		t.Fatalf("InsertMatch() erro = %v", err)
+	}
+
+	// Retry do client após timeout: deve ser tratado como sucesso
+	if err := storage.InsertMatch(match); err != nil {
+		t.Errorf("InsertMatch() duplicado deveria retornar nil, obteve erro = %v", err)
+	}
+
+	// Matches sem event_id (clients antigos) continuam sendo inseridos
+	legacy := domain.NewMatch("user1", "games", "steam", "steam.exe", 10.0)
+	legacy.EventID = ""
+	storage.InsertMatch(legacy)
+	storage.InsertMatch(legacy)
+
+	matches, _ := storage.GetMatches("user1", today())
+	if matches["games"] != 120.0 {
+		t.Errorf("games elapsed = %.2f, esperado 120.00", matches["games"])
+	}
+}
+
+// TestMatch_Init_MigratesLegacyTables testa migração de banco sem event_id
+func TestMatch_Init_MigratesLegacyTables(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	legacy := `
+CREATE TABLE matches (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user TEXT NOT NULL,
+	name TEXT NOT NULL,
+	pattern TEXT NOT NULL,
+	match TEXT NOT NULL,
+	elapsed int DEFAULT 60,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+INSERT INTO matches (user, name, pattern, match, elapsed) VALUES ('user1', 'games', 'steam', 'steam', 30);
+`
+	if err := conn.Exec(legacy); err != nil {
+		t.Fatalf("Exec() erro = %v", err)
+	}
+
+	storage := NewMatch(conn)
+
+	if err := storage.InsertMatch(domain.NewMatch("user1", "games", "steam", "steam.exe", 10.0)); err != nil {
+		t.Fatalf("InsertMatch() erro = %v", err)
+	}
+
+	matches, _ := storage.GetMatches("user1", today())
+	if matches["games"] != 40.0 {
+		t.Errorf("games elapsed = %.2f, esperado 40.00", matches["games"])
+	}
+}
+
+// TestMatch_Timezone testa o dia dos matches no fuso do usuário e o offset do client
+func TestMatch_Timezone(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewMatch(conn)
+	client := time.FixedZone("BRT", -3*3600)
+
+	rows := []struct {
+		created time.Time
+		elapsed float64
+	}{
+		// 23:30 de domingo para o client, 02:30 de segunda em UTC
+		{time.Date(2025, 3, 9, 23, 30, 0, 0, client), 60},
+		{time.Date(2025, 3, 10, 9, 0, 0, 0, client), 30},
+		{time.Date(2025, 3, 10, 21, 15, 0, 0, client), 30},
+	}
+
+	for _, row := range rows {
+		match := domain.NewMatch("user1", "games", "steam", "steam.exe", row.elapsed)
+		match.CreatedAt = row.created
+		if err := storage.InsertMatch(match); err != nil {
+			t.Fatalf("InsertMatch() erro = %v", err)
+		}
+	}
+
+	var created string
+	var offset int
+	db, _ := conn.GetConn()
+	db.QueryRow("SELECT created_utc, utc_offset FROM matches ORDER BY id LIMIT 1;").Scan(&created, &offset)
+	if created != "2025-03-10 02:30:00" || offset != -3*3600 {
+		t.Errorf("Match gravado em %s com offset %d, esperado 2025-03-10 02:30:00 e %d", created, offset, -3*3600)
+	}
+
+	daily, err := storage.GetDailyElapsed("user1", time.Date(2025, 3, 9, 0, 0, 0, 0, client))
+	if err != nil {
+		t.Fatalf("GetDailyElapsed() erro = %v", err)
+	}
+
+	if daily["games"]["2025-03-09"] != 60 || daily["games"]["2025-03-10"] != 60 {
+		t.Errorf("Uso diário no fuso do usuário = %v, esperado 60 em cada dia", daily["games"])
+	}
+
+	// Em UTC o primeiro match já é de segunda
+	daily, _ = storage.GetDailyElapsed("user1", time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC))
+	if daily["games"]["2025-03-10"] != 90 || daily["games"]["2025-03-11"] != 30 {
+		t.Errorf("Uso diário em UTC = %v, esperado 90 e 30", daily["games"])
+	}
+
+	info, _ := storage.GetMatchesInfo("user1", time.Date(2025, 3, 10, 0, 0, 0, 0, client))
+	if info["games"] == nil || info["games"].Elapsed != 60 || info["games"].FirstMatch != "2025-03-10 09:00:00" || info["games"].LastMatch != "2025-03-10 21:15:00" {
+		t.Errorf("Info do dia no fuso do usuário incorreta: %+v", info["games"])
+	}
+}
+
+// TestMatch_Idle testa a gravação do tempo ocioso do match
+func TestMatch_Idle(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewMatch(conn)
+	match := domain.NewMatch("user1", "games", "steam", "steam.exe", 10)
+	match.Idle = 20
+
+	if err := storage.InsertMatch(match); err != nil {
+		t.Fatalf("InsertMatch() erro = %v", err)
+	}
+
+	var elapsed, idle float64
+	db, _ := conn.GetConn()
+	db.QueryRow("SELECT elapsed, idle FROM matches WHERE event_id = ?;", match.EventID).Scan(&elapsed, &idle)
+	if elapsed != 10 || idle != 20 {
+		t.Errorf("Match gravado com elapsed %.0f e idle %.0f, esperado 10 e 20", elapsed, idle)
+	}
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+	"time"
+)
+
+type Override struct {
+	conn *DbConnection
+}
+
+func NewOverride(dbConn *DbConnection) *Override {
+	ret := &Override{
+		conn: dbConn,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Override.NewOverride] Failed to initialize override storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+// Init creates the overrides table, one row per user. Until is stored as
+// local time text, or empty when the override has no end.
+func (o *Override) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS user_overrides (
+	user TEXT PRIMARY KEY,
+	mode TEXT NOT NULL,
+	until TEXT DEFAULT '',
+	reason TEXT DEFAULT '',
+	created_by TEXT DEFAULT '',
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+`
+	if o.conn == nil {
+		log.Printf("[storage.Override.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := o.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Override.Init] Failed to create override tables: %v", err)
+	}
+
+	return err
+}
+
+func (o *Override) Close() error {
+	if o.conn == nil {
+		log.Printf("[storage.Override.Close] Database connection is already closed")
+		return nil
+	}
+
+	return o.conn.Close()
+}
+
+// GetOverride returns the last override set for a user, or nil when none
+// was ever set.
+func (o *Override) GetOverride(user string) (*domain.Override, error) {
+	query := `
+SELECT
+	user,
+	mode,
+	coalesce(until, ''),
+	coalesce(reason, ''),
+	coalesce(created_by, ''),
+	coalesce(created_at, '')
+FROM
+	user_overrides
+WHERE
+	user = ?;`
+
+	if o.conn == nil {
+		log.Printf("[storage.Override.GetOverride] Cannot query override: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := o.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Override.GetOverride] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	ret := &domain.Override{}
+	until := ""
+
+	err = conn.QueryRow(query, user).Scan(&ret.User, &ret.Mode, &until, &ret.Reason, &ret.CreatedBy, &ret.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Override.GetOverride] Failed to query override of user '%s': %v", user, err)
+		return nil, err
+	}
+
+	if until != "" {
+		ret.Until, err = time.ParseInLocation(time.DateTime, until, time.Local)
+	}
+
+	return ret, err
+}
+
+// SetOverride stores the override of a user, replacing the previous one.
+func (o *Override) SetOverride(override *domain.Override) error {
+	upsert := `
+INSERT INTO user_overrides
+(
+	user,
+	mode,
+	until,
+	reason,
+	created_by,
+	created_at
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?,
+	?,
+	datetime('now', 'localtime')
+)
+ON CONFLICT(user) DO UPDATE SET
+	mode = excluded.mode,
+	until = excluded.until,
+	reason = excluded.reason,
+	created_by = excluded.created_by,
+	created_at = excluded.created_at;`
+
+	if o.conn == nil {
+		log.Printf("[storage.Override.SetOverride] Cannot store override: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	until := ""
+
+	if !override.Until.IsZero() {
+		until = override.Until.In(time.Local).Format(time.DateTime)
+	}
+
+	err := o.conn.Exec(upsert, override.User, override.Mode, until, override.Reason, override.CreatedBy)
+
+	if err != nil {
+		log.Printf("[storage.Override.SetOverride] Failed to store override of user '%s': %v", override.User, err)
+	}
+
+	return err
+}
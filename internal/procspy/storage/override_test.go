@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"procspy/internal/procspy/domain"
+	"testing"
+	"time"
+)
+
+// TestOverride_SetOverride testa a gravação e a substituição do modo do usuário
+func TestOverride_SetOverride(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewOverride(conn)
+
+	if found, err := storage.GetOverride("user1"); err != nil || found != nil {
+		t.Fatalf("GetOverride() sem override = %+v, %v, esperado nil", found, err)
+	}
+
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	locked := &domain.Override{User: "user1", Mode: domain.OVERRIDE_LOCKED, Until: until, Reason: "jantar", CreatedBy: "mom"}
+
+	if err := storage.SetOverride(locked); err != nil {
+		t.Fatalf("SetOverride() erro = %v", err)
+	}
+
+	found, err := storage.GetOverride("user1")
+	if err != nil || found.Mode != domain.OVERRIDE_LOCKED || !found.Until.Equal(until) || found.Reason != "jantar" || found.CreatedBy != "mom" {
+		t.Fatalf("Override inesperado: %+v, %v", found, err)
+	}
+
+	// Um novo modo substitui o anterior
+	storage.SetOverride(&domain.Override{User: "user1", Mode: domain.OVERRIDE_UNLIMITED})
+
+	found, _ = storage.GetOverride("user1")
+	if found.Mode != domain.OVERRIDE_UNLIMITED || !found.Until.IsZero() || found.Reason != "" {
+		t.Errorf("Override inesperado após substituição: %+v", found)
+	}
+}
@@ -0,0 +1,381 @@
+package storage
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+)
+
+const DEFAULT_SPOOL_MAX_ITEMS = 100000
+
+// Spool is a bounded, on-disk FIFO of payloads waiting to be sent to the
+// server. Items are kept until explicitly deleted, so nothing is lost on a
+// restart or while the server is offline. Items the server refuses are kept
+// aside as dead letters, so they never block the items behind them. The
+// bound applies to the items waiting, whatever their kind, and separately
+// to the dead letters.
+type Spool struct {
+	conn     *DbConnection
+	maxItems int
+	dropped  atomic.Int64
+}
+
+type SpoolItem struct {
+	ID      int64
+	Kind    string
+	Payload string
+	Retries int
+}
+
+type SpoolStats struct {
+	Depth      int    `json:"depth"`
+	MaxRetries int    `json:"max_retries"`
+	Oldest     string `json:"oldest,omitempty"`
+	NextRetry  string `json:"next_retry,omitempty"`
+	Dead       int    `json:"dead"`
+}
+
+func NewSpool(dbConn *DbConnection, maxItems int) *Spool {
+	if maxItems <= 0 {
+		maxItems = DEFAULT_SPOOL_MAX_ITEMS
+	}
+
+	ret := &Spool{
+		conn:     dbConn,
+		maxItems: maxItems,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Spool.NewSpool] Failed to initialize spool storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+func (s *Spool) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS spool (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	retries INTEGER DEFAULT 0,
+	last_error TEXT DEFAULT NULL,
+	next_attempt TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	dead INTEGER DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS spool_kind_id ON spool (kind, id);
+`
+	if s.conn == nil {
+		log.Printf("[storage.Spool.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := s.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Spool.Init] Failed to create spool tables: %v", err)
+		return err
+	}
+
+	err = s.conn.AddColumn("spool", "dead", "INTEGER DEFAULT 0")
+
+	if err != nil {
+		log.Printf("[storage.Spool.Init] Failed to migrate spool table: %v", err)
+	}
+
+	return err
+}
+
+func (s *Spool) Close() error {
+	if s.conn == nil {
+		log.Printf("[storage.Spool.Close] Database connection is already closed")
+		return nil
+	}
+
+	return s.conn.Close()
+}
+
+// Push appends a payload. When the spool is full the oldest waiting items,
+// of any kind, are dropped to make room; dead letters are never dropped for
+// it.
+func (s *Spool) Push(kind string, payload string) error {
+	insert := `
+INSERT INTO spool
+(
+	kind,
+	payload
+)
+VALUES
+(
+	?,
+	?
+);`
+
+	trim := `
+DELETE FROM spool
+WHERE
+	dead = 0
+	and id <= (
+		SELECT id FROM spool WHERE dead = 0 ORDER BY id DESC LIMIT 1 OFFSET ?
+	);`
+
+	if s.conn == nil {
+		log.Printf("[storage.Spool.Push] Cannot push item: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := s.conn.Exec(insert, kind, payload)
+
+	if err != nil {
+		log.Printf("[storage.Spool.Push] Failed to push '%s' item: %v", kind, err)
+		return err
+	}
+
+	depth, err := s.count("dead = 0")
+
+	if err != nil || depth <= s.maxItems {
+		return err
+	}
+
+	err = s.conn.Exec(trim, s.maxItems)
+
+	if err != nil {
+		log.Printf("[storage.Spool.Push] Failed to trim items: %v", err)
+		return err
+	}
+
+	dropped := depth - s.maxItems
+	s.dropped.Add(int64(dropped))
+	log.Printf("[storage.Spool.Push] Spool is full (%d items), dropped %d oldest items", s.maxItems, dropped)
+
+	return nil
+}
+
+// Next returns up to limit items of a kind in insertion order, once the
+// oldest of them is due for delivery. While it waits for its retry nothing
+// of the kind is returned, so no item is ever delivered ahead of an older one.
+func (s *Spool) Next(kind string, limit int) ([]*SpoolItem, error) {
+	query := `
+SELECT
+	id,
+	kind,
+	payload,
+	retries
+FROM
+	spool
+WHERE
+	kind = ?
+	and dead = 0
+	and (
+		SELECT next_attempt FROM spool WHERE kind = ? and dead = 0 ORDER BY id ASC LIMIT 1
+	) <= datetime('now', 'localtime')
+ORDER BY
+	id ASC
+LIMIT ?;`
+
+	if s.conn == nil {
+		log.Printf("[storage.Spool.Next] Cannot read items: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := s.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Spool.Next] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(query, kind, kind, limit)
+
+	if err != nil {
+		log.Printf("[storage.Spool.Next] Failed to query '%s' items: %v", kind, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]*SpoolItem, 0)
+
+	for rows.Next() {
+		item := &SpoolItem{}
+		if err := rows.Scan(&item.ID, &item.Kind, &item.Payload, &item.Retries); err != nil {
+			log.Printf("[storage.Spool.Next] Failed to scan '%s' item: %v", kind, err)
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+
+	return ret, nil
+}
+
+// Delete removes a delivered item.
+func (s *Spool) Delete(id int64) error {
+	if s.conn == nil {
+		log.Printf("[storage.Spool.Delete] Cannot delete item: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := s.conn.Exec("DELETE FROM spool WHERE id = ?;", id)
+
+	if err != nil {
+		log.Printf("[storage.Spool.Delete] Failed to delete item %d: %v", id, err)
+	}
+
+	return err
+}
+
+// Retry increments the retry counter of an item and postpones its next
+// attempt by backoff seconds.
+func (s *Spool) Retry(id int64, reason string, backoff int) error {
+	update := `
+UPDATE spool SET
+	retries = retries + 1,
+	last_error = ?,
+	next_attempt = datetime('now', 'localtime', '+' || ? || ' seconds')
+WHERE
+	id = ?;`
+
+	if s.conn == nil {
+		log.Printf("[storage.Spool.Retry] Cannot update item: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := s.conn.Exec(update, reason, backoff, id)
+
+	if err != nil {
+		log.Printf("[storage.Spool.Retry] Failed to schedule retry for item %d: %v", id, err)
+	}
+
+	return err
+}
+
+// DeadLetter sets aside an item the server refused, keeping it for
+// inspection without delivering it again. Only the newest dead letters are
+// kept, as many as the items waiting.
+func (s *Spool) DeadLetter(id int64, reason string) error {
+	update := `
+UPDATE spool SET
+	dead = 1,
+	last_error = ?
+WHERE
+	id = ?;`
+
+	trim := `
+DELETE FROM spool
+WHERE
+	dead = 1
+	and id <= (
+		SELECT id FROM spool WHERE dead = 1 ORDER BY id DESC LIMIT 1 OFFSET ?
+	);`
+
+	if s.conn == nil {
+		log.Printf("[storage.Spool.DeadLetter] Cannot update item: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := s.conn.Exec(update, reason, id)
+
+	if err != nil {
+		log.Printf("[storage.Spool.DeadLetter] Failed to dead-letter item %d: %v", id, err)
+		return err
+	}
+
+	err = s.conn.Exec(trim, s.maxItems)
+
+	if err != nil {
+		log.Printf("[storage.Spool.DeadLetter] Failed to trim dead letters: %v", err)
+	}
+
+	return err
+}
+
+// Depth returns how many items of a kind are waiting, dead letters aside.
+func (s *Spool) Depth(kind string) (int, error) {
+	return s.count("kind = ? and dead = 0", kind)
+}
+
+// count returns how many items match a where clause.
+func (s *Spool) count(where string, args ...any) (int, error) {
+	if s.conn == nil {
+		log.Printf("[storage.Spool.count] Cannot count items: database connection is nil")
+		return 0, errors.New("db is nil")
+	}
+
+	conn, err := s.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Spool.count] Failed to get database connection: %v", err)
+		return 0, err
+	}
+
+	var depth int
+	err = conn.QueryRow("SELECT count(*) FROM spool WHERE "+where+";", args...).Scan(&depth)
+
+	if err != nil {
+		log.Printf("[storage.Spool.count] Failed to count items: %v", err)
+	}
+
+	return depth, err
+}
+
+// Dropped returns how many items were discarded because the spool was full
+// since it was opened.
+func (s *Spool) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Stats returns queue metrics per kind.
+func (s *Spool) Stats() (map[string]*SpoolStats, error) {
+	query := `
+SELECT
+	kind,
+	sum(dead = 0) depth,
+	coalesce(max(CASE WHEN dead = 0 THEN retries END), 0) max_retries,
+	coalesce(min(CASE WHEN dead = 0 THEN created_at END), '') oldest,
+	coalesce(min(CASE WHEN dead = 0 THEN next_attempt END), '') next_retry,
+	sum(dead) dead
+FROM
+	spool
+GROUP BY
+	kind;`
+
+	if s.conn == nil {
+		log.Printf("[storage.Spool.Stats] Cannot read stats: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := s.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Spool.Stats] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(query)
+
+	if err != nil {
+		log.Printf("[storage.Spool.Stats] Failed to query spool stats: %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make(map[string]*SpoolStats)
+
+	for rows.Next() {
+		var kind string
+		stats := &SpoolStats{}
+		if err := rows.Scan(&kind, &stats.Depth, &stats.MaxRetries, &stats.Oldest, &stats.NextRetry, &stats.Dead); err != nil {
+			log.Printf("[storage.Spool.Stats] Failed to scan spool stats: %v", err)
+			return nil, err
+		}
+		ret[kind] = stats
+	}
+
+	return ret, nil
+}
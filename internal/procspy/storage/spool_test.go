@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"testing"
+)
+
+// TestNewSpool testa criação de storage do spool
+func TestNewSpool(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	spool := NewSpool(conn, 0)
+	if spool == nil {
+		t.Fatal("NewSpool retornou nil")
+	}
+
+	if spool.maxItems != DEFAULT_SPOOL_MAX_ITEMS {
+		t.Errorf("maxItems = %d, esperado %d", spool.maxItems, DEFAULT_SPOOL_MAX_ITEMS)
+	}
+}
+
+// TestSpool_PushNext testa ordem de entrega dos itens
+func TestSpool_PushNext(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	spool := NewSpool(conn, 10)
+	spool.Push("match", "a")
+	spool.Push("command", "x")
+	spool.Push("match", "b")
+
+	items, err := spool.Next("match", 10)
+	if err != nil {
+		t.Fatalf("Next() erro = %v", err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("Esperado 2 itens, obteve %d", len(items))
+	}
+
+	if items[0].Payload != "a" || items[1].Payload != "b" {
+		t.Errorf("Itens fora de ordem: %s, %s", items[0].Payload, items[1].Payload)
+	}
+}
+
+// TestSpool_Bounded testa descarte dos itens mais antigos quando cheio
+func TestSpool_Bounded(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	spool := NewSpool(conn, 2)
+	spool.Push("match", "a")
+	spool.Push("match", "b")
+	spool.Push("match", "c")
+
+	depth, _ := spool.Depth("match")
+	if depth != 2 {
+		t.Errorf("Depth = %d, esperado 2", depth)
+	}
+
+	if spool.Dropped() != 1 {
+		t.Errorf("Dropped = %d, esperado 1", spool.Dropped())
+	}
+
+	items, _ := spool.Next("match", 10)
+	if len(items) != 2 || items[0].Payload != "b" {
+		t.Errorf("Item mais antigo deveria ter sido descartado")
+	}
+
+	// O limite vale para todos os tipos juntos
+	spool.Push("command", "x")
+
+	if depth, _ := spool.Depth("match"); depth != 1 {
+		t.Errorf("Depth de match = %d, esperado 1 após um command", depth)
+	}
+
+	// Dead letters não contam nem são descartadas para abrir espaço
+	items, _ = spool.Next("match", 10)
+	spool.DeadLetter(items[0].ID, "400")
+	spool.Push("command", "y")
+
+	stats, _ := spool.Stats()
+	if stats["match"].Dead != 1 || stats["command"].Depth != 2 {
+		t.Errorf("Stats = match %+v, command %+v, esperado 1 dead letter e 2 commands", stats["match"], stats["command"])
+	}
+}
+
+// TestSpool_DeleteRetry testa remoção e reagendamento de itens
+func TestSpool_DeleteRetry(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	spool := NewSpool(conn, 10)
+	spool.Push("match", "a")
+	spool.Push("match", "b")
+
+	items, _ := spool.Next("match", 10)
+
+	if err := spool.Delete(items[0].ID); err != nil {
+		t.Errorf("Delete() erro = %v", err)
+	}
+
+	if err := spool.Retry(items[1].ID, "timeout", 60); err != nil {
+		t.Errorf("Retry() erro = %v", err)
+	}
+
+	// Item reagendado não está disponível antes do backoff
+	items, _ = spool.Next("match", 10)
+	if len(items) != 0 {
+		t.Errorf("Esperado 0 itens disponíveis, obteve %d", len(items))
+	}
+
+	stats, err := spool.Stats()
+	if err != nil {
+		t.Fatalf("Stats() erro = %v", err)
+	}
+
+	if stats["match"].Depth != 1 || stats["match"].MaxRetries != 1 {
+		t.Errorf("Stats = %+v, esperado depth 1 e max_retries 1", stats["match"])
+	}
+}
+
+// TestSpool_HeadOfLine testa que nenhum item é entregue antes do mais antigo
+func TestSpool_HeadOfLine(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	spool := NewSpool(conn, 10)
+	spool.Push("match", "a")
+	spool.Push("match", "b")
+	spool.Push("command", "x")
+
+	items, _ := spool.Next("match", 10)
+	spool.Retry(items[0].ID, "timeout", 60)
+
+	// "b" já está disponível, mas "a" ainda aguarda o backoff
+	if items, _ := spool.Next("match", 10); len(items) != 0 {
+		t.Errorf("Esperado 0 itens antes do mais antigo, obteve %d", len(items))
+	}
+
+	// Outros tipos não são afetados
+	if items, _ := spool.Next("command", 10); len(items) != 1 {
+		t.Errorf("Esperado 1 command disponível, obteve %d", len(items))
+	}
+
+	if err := spool.DeadLetter(items[0].ID, "HTTP 400"); err != nil {
+		t.Fatalf("DeadLetter() erro = %v", err)
+	}
+
+	items, _ = spool.Next("match", 10)
+	if len(items) != 1 || items[0].Payload != "b" {
+		t.Errorf("Esperado só o item b após o dead letter, obteve %d itens", len(items))
+	}
+
+	if depth, _ := spool.Depth("match"); depth != 1 {
+		t.Errorf("Depth = %d, esperado 1 sem o dead letter", depth)
+	}
+
+	stats, _ := spool.Stats()
+	if stats["match"].Depth != 1 || stats["match"].Dead != 1 {
+		t.Errorf("Stats = %+v, esperado depth 1 e dead 1", stats["match"])
+	}
+}
+
+// TestSpool_NilConnection testa operações com conexão nil
+func TestSpool_NilConnection(t *testing.T) {
+	spool := &Spool{conn: nil}
+
+	if err := spool.Push("match", "a"); err == nil {
+		t.Error("Push() deveria retornar erro com conexão nil")
+	}
+
+	if _, err := spool.Next("match", 1); err == nil {
+		t.Error("Next() deveria retornar erro com conexão nil")
+	}
+}
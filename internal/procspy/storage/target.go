@@ -0,0 +1,1003 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"procspy/internal/procspy/domain"
+)
+
+type Target struct {
+	conn *DbConnection
+}
+
+func NewTarget(dbConn *DbConnection) *Target {
+	ret := &Target{
+		conn: dbConn,
+	}
+
+	err := ret.Init()
+
+	if err != nil {
+		log.Printf("[storage.Target.NewTarget] Failed to initialize target storage: %v", err)
+		panic(err)
+	}
+
+	return ret
+}
+
+func (t *Target) Init() error {
+	create := `
+CREATE TABLE IF NOT EXISTS users (
+	name TEXT PRIMARY KEY,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE TABLE IF NOT EXISTS targets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user TEXT NOT NULL,
+	name TEXT NOT NULL,
+	pattern TEXT NOT NULL,
+	source TEXT DEFAULT '',
+	kill INTEGER DEFAULT 0,
+	limit_command TEXT DEFAULT '',
+	check_command TEXT DEFAULT '',
+	warning_command TEXT DEFAULT '',
+	schedule TEXT DEFAULT '',
+	limit_seconds REAL DEFAULT 0,
+	weekday_limits TEXT DEFAULT '',
+	warning TEXT DEFAULT '',
+	groups TEXT DEFAULT '',
+	weekly_limit REAL DEFAULT 0,
+	monthly_limit REAL DEFAULT 0,
+	rollover TEXT DEFAULT '',
+	fields TEXT DEFAULT '',
+	exclude TEXT DEFAULT '',
+	idle_after REAL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	UNIQUE (user, name)
+);
+
+CREATE TABLE IF NOT EXISTS target_groups (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user TEXT NOT NULL,
+	name TEXT NOT NULL,
+	limit_seconds REAL DEFAULT 0,
+	weekday_limits TEXT DEFAULT '',
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+	UNIQUE (user, name)
+);
+
+CREATE TABLE IF NOT EXISTS calendar_entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user TEXT NOT NULL,
+	name TEXT NOT NULL,
+	start_date TEXT NOT NULL,
+	end_date TEXT DEFAULT '',
+	profile TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE TABLE IF NOT EXISTS target_sources (
+	user TEXT PRIMARY KEY,
+	url TEXT NOT NULL,
+	etag TEXT DEFAULT '',
+	last_modified TEXT DEFAULT '',
+	content_hash TEXT DEFAULT '',
+	status TEXT DEFAULT '',
+	last_error TEXT DEFAULT '',
+	checked_at TIMESTAMP DEFAULT NULL,
+	updated_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS target_weekdays (
+	target_id INTEGER NOT NULL,
+	weekday INTEGER NOT NULL,
+	factor REAL NOT NULL,
+	PRIMARY KEY (target_id, weekday)
+);
+`
+	if t.conn == nil {
+		log.Printf("[storage.Target.Init] Cannot create tables: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := t.conn.Exec(create)
+
+	if err != nil {
+		log.Printf("[storage.Target.Init] Failed to create target tables: %v", err)
+		return err
+	}
+
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{"schedule", "TEXT DEFAULT ''"},
+		{"limit_seconds", "REAL DEFAULT 0"},
+		{"weekday_limits", "TEXT DEFAULT ''"},
+		{"warning", "TEXT DEFAULT ''"},
+		{"groups", "TEXT DEFAULT ''"},
+		{"weekly_limit", "REAL DEFAULT 0"},
+		{"monthly_limit", "REAL DEFAULT 0"},
+		{"rollover", "TEXT DEFAULT ''"},
+		{"fields", "TEXT DEFAULT ''"},
+		{"exclude", "TEXT DEFAULT ''"},
+		{"idle_after", "REAL DEFAULT 0"},
+	}
+
+	for _, column := range columns {
+		err = t.conn.AddColumn("targets", column.name, column.definition)
+
+		if err != nil {
+			log.Printf("[storage.Target.Init] Failed to migrate table 'targets': %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Target) Close() error {
+	if t.conn == nil {
+		log.Printf("[storage.Target.Close] Database connection is already closed")
+		return nil
+	}
+
+	return t.conn.Close()
+}
+
+func (t *Target) InsertUser(user string) error {
+	if t.conn == nil {
+		log.Printf("[storage.Target.InsertUser] Cannot insert user: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	err := t.conn.Exec("INSERT INTO users (name) VALUES (?) ON CONFLICT(name) DO NOTHING;", user)
+
+	if err != nil {
+		log.Printf("[storage.Target.InsertUser] Failed to insert user '%s': %v", user, err)
+	}
+
+	return err
+}
+
+func (t *Target) GetUsers() ([]string, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.GetUsers] Cannot query users: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.GetUsers] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query("SELECT name FROM users ORDER BY name;")
+
+	if err != nil {
+		log.Printf("[storage.Target.GetUsers] Failed to query users: %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]string, 0)
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Printf("[storage.Target.GetUsers] Failed to scan user row: %v", err)
+			return nil, err
+		}
+		ret = append(ret, name)
+	}
+
+	return ret, nil
+}
+
+func (t *Target) UserExists(user string) (bool, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.UserExists] Cannot query user: database connection is nil")
+		return false, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.UserExists] Failed to get database connection: %v", err)
+		return false, err
+	}
+
+	var count int
+	err = conn.QueryRow("SELECT count(*) FROM users WHERE name = ?;", user).Scan(&count)
+
+	if err != nil {
+		log.Printf("[storage.Target.UserExists] Failed to query user '%s': %v", user, err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+const selectTarget = `
+SELECT
+	id,
+	user,
+	name,
+	pattern,
+	coalesce(source, ''),
+	kill,
+	coalesce(limit_command, ''),
+	coalesce(check_command, ''),
+	coalesce(warning_command, ''),
+	coalesce(schedule, ''),
+	coalesce(limit_seconds, 0),
+	coalesce(weekday_limits, ''),
+	coalesce(warning, ''),
+	coalesce(groups, ''),
+	coalesce(weekly_limit, 0),
+	coalesce(monthly_limit, 0),
+	coalesce(rollover, ''),
+	coalesce(fields, ''),
+	coalesce(exclude, ''),
+	coalesce(idle_after, 0)
+FROM
+	targets
+`
+
+func scanTarget(row interface{ Scan(dest ...any) error }) (*domain.Target, error) {
+	ret := &domain.Target{}
+	var schedule, weekdayLimits, groups, rollover, fields, exclude string
+
+	err := row.Scan(&ret.ID, &ret.User, &ret.Name, &ret.Pattern, &ret.Source, &ret.Kill, &ret.LimitCommand, &ret.CheckCommand, &ret.WarningCommand, &schedule, &ret.Limit, &weekdayLimits, &ret.Warning, &groups, &ret.WeeklyLimit, &ret.MonthlyLimit, &rollover, &fields, &exclude, &ret.IdleAfter)
+
+	if err != nil {
+		return ret, err
+	}
+
+	if err = unmarshalColumn(schedule, &ret.Schedule); err != nil {
+		return ret, err
+	}
+
+	if err = unmarshalColumn(weekdayLimits, &ret.WeekdayLimits); err != nil {
+		return ret, err
+	}
+
+	if err = unmarshalColumn(groups, &ret.Groups); err != nil {
+		return ret, err
+	}
+
+	if err = unmarshalColumn(rollover, &ret.Rollover); err != nil {
+		return ret, err
+	}
+
+	if err = unmarshalColumn(fields, &ret.Fields); err != nil {
+		return ret, err
+	}
+
+	err = unmarshalColumn(exclude, &ret.Exclude)
+
+	return ret, err
+}
+
+// marshalColumn stores a map as JSON, or an empty string when the target
+// has none.
+func marshalColumn[M ~map[int]V, V any](value M) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+
+	ret, err := json.Marshal(value)
+
+	return string(ret), err
+}
+
+func marshalList(values []string) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	ret, err := json.Marshal(values)
+
+	return string(ret), err
+}
+
+func unmarshalColumn(data string, value any) error {
+	if data == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(data), value)
+}
+
+// targetArgs returns the values of the columns written by insert and update,
+// in the order of the queries, with maps and lists encoded as JSON.
+func targetArgs(target *domain.Target) ([]any, error) {
+	schedule, err := marshalColumn(target.Schedule)
+
+	if err != nil {
+		return nil, err
+	}
+
+	weekdayLimits, err := marshalColumn(target.WeekdayLimits)
+
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := marshalList(target.Groups)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rollover := ""
+
+	if target.Rollover != nil {
+		data, err := json.Marshal(target.Rollover)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rollover = string(data)
+	}
+
+	exclude, err := marshalList(target.Exclude)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields := ""
+
+	if target.Fields != nil {
+		data, err := json.Marshal(target.Fields)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields = string(data)
+	}
+
+	return []any{target.Name, target.Pattern, target.Source, target.Kill, target.LimitCommand, target.CheckCommand, target.WarningCommand, schedule, target.Limit, weekdayLimits, target.Warning, groups, target.WeeklyLimit, target.MonthlyLimit, rollover, fields, exclude, target.IdleAfter}, nil
+}
+
+// GetTargets returns the targets of a user with their weekday factors, in
+// creation order.
+func (t *Target) GetTargets(user string) ([]*domain.Target, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.GetTargets] Cannot query targets: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.GetTargets] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(selectTarget+"WHERE user = ? ORDER BY id;", user)
+
+	if err != nil {
+		log.Printf("[storage.Target.GetTargets] Failed to query targets for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	ret := make([]*domain.Target, 0)
+
+	for rows.Next() {
+		target, err := scanTarget(rows)
+		if err != nil {
+			rows.Close()
+			log.Printf("[storage.Target.GetTargets] Failed to scan target row for user '%s': %v", user, err)
+			return nil, err
+		}
+		ret = append(ret, target)
+	}
+
+	rows.Close()
+
+	for _, target := range ret {
+		if target.Weekdays, err = t.getWeekdays(conn, target.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// GetTarget returns a target of a user by ID, or nil when it does not exist.
+func (t *Target) GetTarget(user string, id int64) (*domain.Target, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.GetTarget] Cannot query target: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.GetTarget] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	ret, err := scanTarget(conn.QueryRow(selectTarget+"WHERE user = ? and id = ?;", user, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Target.GetTarget] Failed to query target %d for user '%s': %v", id, user, err)
+		return nil, err
+	}
+
+	ret.Weekdays, err = t.getWeekdays(conn, ret.ID)
+
+	return ret, err
+}
+
+func (t *Target) getWeekdays(conn *sql.DB, id int64) (map[int]float64, error) {
+	rows, err := conn.Query("SELECT weekday, factor FROM target_weekdays WHERE target_id = ?;", id)
+
+	if err != nil {
+		log.Printf("[storage.Target.getWeekdays] Failed to query weekdays of target %d: %v", id, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make(map[int]float64)
+
+	for rows.Next() {
+		var day int
+		var factor float64
+		if err := rows.Scan(&day, &factor); err != nil {
+			log.Printf("[storage.Target.getWeekdays] Failed to scan weekday of target %d: %v", id, err)
+			return nil, err
+		}
+		ret[day] = factor
+	}
+
+	return ret, nil
+}
+
+// InsertTarget stores a target for its user, registering the user when
+// needed, and sets its ID.
+func (t *Target) InsertTarget(target *domain.Target) error {
+	return t.inTx("InsertTarget", func(tx *sql.Tx) error {
+		return insertTarget(tx, target)
+	})
+}
+
+// UpdateTarget replaces a target and its weekday factors. It returns false
+// when the target does not exist for the user.
+func (t *Target) UpdateTarget(target *domain.Target) (bool, error) {
+	update := `
+UPDATE targets SET
+	name = ?,
+	pattern = ?,
+	source = ?,
+	kill = ?,
+	limit_command = ?,
+	check_command = ?,
+	warning_command = ?,
+	schedule = ?,
+	limit_seconds = ?,
+	weekday_limits = ?,
+	warning = ?,
+	groups = ?,
+	weekly_limit = ?,
+	monthly_limit = ?,
+	rollover = ?,
+	fields = ?,
+	exclude = ?,
+	idle_after = ?,
+	updated_at = datetime('now', 'localtime')
+WHERE
+	user = ?
+	and id = ?;`
+
+	found := false
+
+	args, err := targetArgs(target)
+
+	if err != nil {
+		log.Printf("[storage.Target.UpdateTarget] Failed to marshal target '%s': %v", target.Name, err)
+		return false, err
+	}
+
+	err = t.inTx("UpdateTarget", func(tx *sql.Tx) error {
+		res, err := tx.Exec(update, append(args, target.User, target.ID)...)
+
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		found = true
+
+		return replaceWeekdays(tx, target)
+	})
+
+	return found, err
+}
+
+// DeleteTarget removes a target of a user. It returns false when the target
+// does not exist.
+func (t *Target) DeleteTarget(user string, id int64) (bool, error) {
+	found := false
+
+	err := t.inTx("DeleteTarget", func(tx *sql.Tx) error {
+		res, err := tx.Exec("DELETE FROM targets WHERE user = ? and id = ?;", user, id)
+
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		found = true
+
+		_, err = tx.Exec("DELETE FROM target_weekdays WHERE target_id = ?;", id)
+
+		return err
+	})
+
+	return found, err
+}
+
+// ReplaceTargets swaps all targets and groups of a user in a single
+// transaction, used when importing a target list.
+func (t *Target) ReplaceTargets(user string, targets []*domain.Target, groups []*domain.Group) error {
+	return t.inTx("ReplaceTargets", func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM target_weekdays WHERE target_id IN (SELECT id FROM targets WHERE user = ?);", user)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("DELETE FROM targets WHERE user = ?;", user)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO users (name) VALUES (?) ON CONFLICT(name) DO NOTHING;", user)
+
+		if err != nil {
+			return err
+		}
+
+		for _, target := range targets {
+			target.User = user
+
+			if err := insertTarget(tx, target); err != nil {
+				return err
+			}
+		}
+
+		return replaceGroups(tx, user, groups)
+	})
+}
+
+// GetGroups returns the budget groups of a user, in creation order.
+func (t *Target) GetGroups(user string) ([]*domain.Group, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.GetGroups] Cannot query groups: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.GetGroups] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query("SELECT name, coalesce(limit_seconds, 0), coalesce(weekday_limits, '') FROM target_groups WHERE user = ? ORDER BY id;", user)
+
+	if err != nil {
+		log.Printf("[storage.Target.GetGroups] Failed to query groups for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]*domain.Group, 0)
+
+	for rows.Next() {
+		group := &domain.Group{}
+		var weekdayLimits string
+
+		err := rows.Scan(&group.Name, &group.Limit, &weekdayLimits)
+
+		if err == nil {
+			err = unmarshalColumn(weekdayLimits, &group.WeekdayLimits)
+		}
+
+		if err != nil {
+			log.Printf("[storage.Target.GetGroups] Failed to scan group row for user '%s': %v", user, err)
+			return nil, err
+		}
+
+		ret = append(ret, group)
+	}
+
+	return ret, nil
+}
+
+// ReplaceGroups swaps all budget groups of a user.
+func (t *Target) ReplaceGroups(user string, groups []*domain.Group) error {
+	return t.inTx("ReplaceGroups", func(tx *sql.Tx) error {
+		return replaceGroups(tx, user, groups)
+	})
+}
+
+func replaceGroups(tx *sql.Tx, user string, groups []*domain.Group) error {
+	_, err := tx.Exec("DELETE FROM target_groups WHERE user = ?;", user)
+
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		weekdayLimits, err := marshalColumn(group.WeekdayLimits)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO target_groups (user, name, limit_seconds, weekday_limits) VALUES (?, ?, ?, ?);", user, group.Name, group.Limit, weekdayLimits)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCalendar returns the calendar entries of a user in the order they
+// were stored.
+func (t *Target) GetCalendar(user string) (domain.Calendar, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.GetCalendar] Cannot query calendar: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.GetCalendar] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query("SELECT name, start_date, coalesce(end_date, ''), profile FROM calendar_entries WHERE user = ? ORDER BY id;", user)
+
+	if err != nil {
+		log.Printf("[storage.Target.GetCalendar] Failed to query calendar for user '%s': %v", user, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make(domain.Calendar, 0)
+
+	for rows.Next() {
+		entry := &domain.CalendarEntry{}
+
+		if err := rows.Scan(&entry.Name, &entry.From, &entry.To, &entry.Profile); err != nil {
+			log.Printf("[storage.Target.GetCalendar] Failed to scan calendar row for user '%s': %v", user, err)
+			return nil, err
+		}
+
+		ret = append(ret, entry)
+	}
+
+	return ret, nil
+}
+
+// ReplaceCalendar swaps all calendar entries of a user.
+func (t *Target) ReplaceCalendar(user string, calendar domain.Calendar) error {
+	return t.inTx("ReplaceCalendar", func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM calendar_entries WHERE user = ?;", user)
+
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range calendar {
+			_, err = tx.Exec("INSERT INTO calendar_entries (user, name, start_date, end_date, profile) VALUES (?, ?, ?, ?, ?);", user, entry.Name, entry.From, entry.To, entry.Profile)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+const selectTargetSource = `
+SELECT
+	user,
+	url,
+	coalesce(etag, ''),
+	coalesce(last_modified, ''),
+	coalesce(content_hash, ''),
+	coalesce(status, ''),
+	coalesce(last_error, ''),
+	coalesce(checked_at, ''),
+	coalesce(updated_at, '')
+FROM
+	target_sources
+`
+
+func scanTargetSource(row interface{ Scan(dest ...any) error }) (*domain.TargetSource, error) {
+	ret := &domain.TargetSource{}
+	err := row.Scan(&ret.User, &ret.URL, &ret.ETag, &ret.LastModified, &ret.Hash, &ret.Status, &ret.LastError, &ret.CheckedAt, &ret.UpdatedAt)
+	return ret, err
+}
+
+// GetSource returns the cache state of the remote target list of a user,
+// or nil when it was never fetched.
+func (t *Target) GetSource(user string) (*domain.TargetSource, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.GetSource] Cannot query target source: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.GetSource] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	ret, err := scanTargetSource(conn.QueryRow(selectTargetSource+"WHERE user = ?;", user))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		log.Printf("[storage.Target.GetSource] Failed to query target source of user '%s': %v", user, err)
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (t *Target) GetSources() ([]*domain.TargetSource, error) {
+	if t.conn == nil {
+		log.Printf("[storage.Target.GetSources] Cannot query target sources: database connection is nil")
+		return nil, errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.GetSources] Failed to get database connection: %v", err)
+		return nil, err
+	}
+
+	rows, err := conn.Query(selectTargetSource + "ORDER BY user;")
+
+	if err != nil {
+		log.Printf("[storage.Target.GetSources] Failed to query target sources: %v", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ret := make([]*domain.TargetSource, 0)
+
+	for rows.Next() {
+		source, err := scanTargetSource(rows)
+		if err != nil {
+			log.Printf("[storage.Target.GetSources] Failed to scan target source row: %v", err)
+			return nil, err
+		}
+		ret = append(ret, source)
+	}
+
+	return ret, nil
+}
+
+// SaveSource records a revalidation of a remote target list. checked_at is
+// always refreshed; updated_at only when the targets were replaced.
+func (t *Target) SaveSource(source *domain.TargetSource) error {
+	upsert := `
+INSERT INTO target_sources
+(
+	user,
+	url,
+	etag,
+	last_modified,
+	content_hash,
+	status,
+	last_error,
+	checked_at,
+	updated_at
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	datetime('now', 'localtime'),
+	CASE WHEN ? THEN datetime('now', 'localtime') ELSE NULL END
+)
+ON CONFLICT(user) DO UPDATE SET
+	url = excluded.url,
+	etag = excluded.etag,
+	last_modified = excluded.last_modified,
+	content_hash = excluded.content_hash,
+	status = excluded.status,
+	last_error = excluded.last_error,
+	checked_at = excluded.checked_at,
+	updated_at = coalesce(excluded.updated_at, target_sources.updated_at);`
+
+	if t.conn == nil {
+		log.Printf("[storage.Target.SaveSource] Cannot save target source: database connection is nil")
+		return errors.New("db is nil")
+	}
+
+	updated := source.Status == domain.TARGET_SOURCE_UPDATED
+
+	err := t.conn.Exec(upsert, source.User, source.URL, source.ETag, source.LastModified, source.Hash, source.Status, source.LastError, updated)
+
+	if err != nil {
+		log.Printf("[storage.Target.SaveSource] Failed to save target source of user '%s': %v", source.User, err)
+	}
+
+	return err
+}
+
+func (t *Target) inTx(operation string, fn func(tx *sql.Tx) error) error {
+	if t.conn == nil {
+		log.Printf("[storage.Target.%s] Cannot write targets: database connection is nil", operation)
+		return errors.New("db is nil")
+	}
+
+	conn, err := t.conn.GetConn()
+
+	if err != nil {
+		log.Printf("[storage.Target.%s] Failed to get database connection: %v", operation, err)
+		return err
+	}
+
+	tx, err := conn.Begin()
+
+	if err != nil {
+		log.Printf("[storage.Target.%s] Failed to begin transaction: %v", operation, err)
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		log.Printf("[storage.Target.%s] Failed to write targets: %v", operation, err)
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Printf("[storage.Target.%s] Failed to commit transaction: %v", operation, err)
+	}
+
+	return err
+}
+
+func insertTarget(tx *sql.Tx, target *domain.Target) error {
+	insert := `
+INSERT INTO targets
+(
+	name,
+	pattern,
+	source,
+	kill,
+	limit_command,
+	check_command,
+	warning_command,
+	schedule,
+	limit_seconds,
+	weekday_limits,
+	warning,
+	groups,
+	weekly_limit,
+	monthly_limit,
+	rollover,
+	fields,
+	exclude,
+	idle_after,
+	user
+)
+VALUES
+(
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?,
+	?
+);`
+
+	args, err := targetArgs(target)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO users (name) VALUES (?) ON CONFLICT(name) DO NOTHING;", target.User)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(insert, append(args, target.User)...)
+
+	if err != nil {
+		return err
+	}
+
+	target.ID, err = res.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	return replaceWeekdays(tx, target)
+}
+
+func replaceWeekdays(tx *sql.Tx, target *domain.Target) error {
+	_, err := tx.Exec("DELETE FROM target_weekdays WHERE target_id = ?;", target.ID)
+
+	if err != nil {
+		return err
+	}
+
+	for day, factor := range target.Weekdays {
+		_, err = tx.Exec("INSERT INTO target_weekdays (target_id, weekday, factor) VALUES (?, ?, ?);", target.ID, day, factor)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
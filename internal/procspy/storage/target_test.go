@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"procspy/internal/procspy/domain"
+	"slices"
+	"testing"
+)
+
+// TestTarget_InsertTarget testa inserção de target com fatores por dia
+func TestTarget_InsertTarget(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewTarget(conn)
+
+	target := &domain.Target{User: "user1", Name: "games", Pattern: "steam", Kill: true, Weekdays: map[int]float64{0: 2.0, 6: 1.5}, Fields: &domain.MatchFields{Cmdline: "minecraft", Mode: domain.FIELDS_OR}, Exclude: []string{"webview", "^teams$"}, IdleAfter: 300}
+	if err := storage.InsertTarget(target); err != nil {
+		t.Fatalf("InsertTarget() erro = %v", err)
+	}
+
+	if target.ID == 0 {
+		t.Error("ID não foi atribuído")
+	}
+
+	found, err := storage.GetTarget("user1", target.ID)
+	if err != nil || found == nil {
+		t.Fatalf("GetTarget() = %v, %v", found, err)
+	}
+
+	if !found.Kill || len(found.Weekdays) != 2 || found.Weekdays[6] != 1.5 {
+		t.Errorf("Target incorreto: %+v", found)
+	}
+
+	if found.Fields == nil || *found.Fields != *target.Fields {
+		t.Errorf("Campos de match incorretos: %v", found.Fields)
+	}
+
+	if !slices.Equal(found.Exclude, target.Exclude) {
+		t.Errorf("Padrões de exclusão incorretos: %v", found.Exclude)
+	}
+
+	if found.IdleAfter != target.IdleAfter {
+		t.Errorf("IdleAfter = %.0f, esperado %.0f", found.IdleAfter, target.IdleAfter)
+	}
+
+	if exists, _ := storage.UserExists("user1"); !exists {
+		t.Error("Usuário deveria ser registrado junto com o target")
+	}
+
+	if err := storage.InsertTarget(&domain.Target{User: "user1", Name: "games", Pattern: "x"}); err == nil {
+		t.Error("InsertTarget() deveria falhar com nome duplicado para o mesmo usuário")
+	}
+
+	if missing, err := storage.GetTarget("user2", target.ID); err != nil || missing != nil {
+		t.Errorf("GetTarget() = %v, %v, esperado nil para outro usuário", missing, err)
+	}
+}
+
+// TestTarget_UpdateDeleteTarget testa atualização e remoção de target
+func TestTarget_UpdateDeleteTarget(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewTarget(conn)
+
+	target := &domain.Target{User: "user1", Name: "games", Pattern: "steam", Weekdays: map[int]float64{0: 2.0}}
+	storage.InsertTarget(target)
+
+	target.Pattern = "roblox"
+	target.Weekdays = map[int]float64{1: 0.25}
+	target.Schedule = domain.Schedule{1: {"16:00-20:00"}, 0: {}}
+	target.Limit = 2700
+	target.WeekdayLimits = map[int]domain.Duration{6: 7200}
+	target.Warning = "5m before"
+	target.WeeklyLimit = 36000
+	target.MonthlyLimit = 108000
+	target.Rollover = &domain.Rollover{Cap: 3600, To: []int{0, 6}}
+
+	found, err := storage.UpdateTarget(target)
+	if err != nil || !found {
+		t.Fatalf("UpdateTarget() = %v, %v", found, err)
+	}
+
+	stored, _ := storage.GetTarget("user1", target.ID)
+	if stored.Pattern != "roblox" || len(stored.Weekdays) != 1 || stored.Weekdays[1] != 0.25 {
+		t.Errorf("Target não foi atualizado: %+v", stored)
+	}
+
+	if len(stored.Schedule) != 2 || stored.Schedule[1][0] != "16:00-20:00" || stored.Schedule[0] == nil {
+		t.Errorf("Schedule não foi atualizado: %+v", stored.Schedule)
+	}
+
+	if stored.Limit != 2700 || stored.WeekdayLimits[6] != 7200 || stored.Warning != "5m before" {
+		t.Errorf("Limites não foram atualizados: %v, %v, '%s'", stored.Limit, stored.WeekdayLimits, stored.Warning)
+	}
+
+	if stored.WeeklyLimit != 36000 || stored.MonthlyLimit != 108000 {
+		t.Errorf("Cotas não foram atualizadas: %v, %v", stored.WeeklyLimit, stored.MonthlyLimit)
+	}
+
+	if stored.Rollover == nil || stored.Rollover.Cap != 3600 || len(stored.Rollover.To) != 2 {
+		t.Errorf("Rollover não foi atualizado: %v", stored.Rollover)
+	}
+
+	if found, _ := storage.DeleteTarget("user1", target.ID); !found {
+		t.Error("DeleteTarget() deveria remover o target")
+	}
+
+	if found, _ := storage.DeleteTarget("user1", target.ID); found {
+		t.Error("DeleteTarget() não deveria encontrar target já removido")
+	}
+}
+
+// TestTarget_ReplaceTargets testa substituição de todos os targets do usuário
+func TestTarget_ReplaceTargets(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewTarget(conn)
+	storage.InsertTarget(&domain.Target{User: "user1", Name: "old", Pattern: "old"})
+	storage.InsertTarget(&domain.Target{User: "user2", Name: "other", Pattern: "other"})
+
+	err := storage.ReplaceTargets("user1", []*domain.Target{
+		{Name: "games", Pattern: "steam", Groups: []string{"entertainment"}},
+		{Name: "browsers", Pattern: "chrome"},
+	}, []*domain.Group{
+		{Name: "entertainment", Limit: 5400, WeekdayLimits: map[int]domain.Duration{6: 7200}},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceTargets() erro = %v", err)
+	}
+
+	targets, _ := storage.GetTargets("user1")
+	if len(targets) != 2 || targets[0].Name != "games" || targets[0].User != "user1" {
+		t.Errorf("Targets incorretos após substituição: %+v", targets)
+	}
+
+	if len(targets[0].Groups) != 1 || targets[0].Groups[0] != "entertainment" || targets[1].Groups != nil {
+		t.Errorf("Grupos dos targets incorretos: %v, %v", targets[0].Groups, targets[1].Groups)
+	}
+
+	groups, _ := storage.GetGroups("user1")
+	if len(groups) != 1 || groups[0].Name != "entertainment" || groups[0].Limit != 5400 || groups[0].WeekdayLimits[6] != 7200 {
+		t.Errorf("Grupos incorretos após substituição: %+v", groups)
+	}
+
+	// Uma nova importação substitui também os grupos
+	storage.ReplaceTargets("user1", []*domain.Target{{Name: "games", Pattern: "steam"}}, nil)
+	if groups, _ := storage.GetGroups("user1"); len(groups) != 0 {
+		t.Errorf("Grupos deveriam ser removidos, obteve %+v", groups)
+	}
+
+	// Targets de outros usuários não são afetados
+	if others, _ := storage.GetTargets("user2"); len(others) != 1 {
+		t.Errorf("Esperado 1 target para user2, obteve %d", len(others))
+	}
+
+	users, _ := storage.GetUsers()
+	if len(users) != 2 {
+		t.Errorf("Esperado 2 usuários, obteve %v", users)
+	}
+}
+
+// TestTarget_NilConnection testa operações com conexão nil
+func TestTarget_NilConnection(t *testing.T) {
+	storage := &Target{conn: nil}
+
+	if err := storage.InsertTarget(&domain.Target{}); err == nil {
+		t.Error("InsertTarget() deveria retornar erro com conexão nil")
+	}
+
+	if _, err := storage.GetTargets("user1"); err == nil {
+		t.Error("GetTargets() deveria retornar erro com conexão nil")
+	}
+
+	if err := storage.Close(); err != nil {
+		t.Errorf("Close() com conn nil deveria retornar nil, obteve erro = %v", err)
+	}
+}
+
+// TestTarget_SaveSource testa o estado do cache de targets remotos
+func TestTarget_SaveSource(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewTarget(conn)
+
+	if source, err := storage.GetSource("user1"); err != nil || source != nil {
+		t.Errorf("GetSource() = %v, %v, esperado nil", source, err)
+	}
+
+	source := domain.NewTargetSource("user1", "http://example.com/user1.json")
+	source.ETag = `"v1"`
+	source.Status = domain.TARGET_SOURCE_UPDATED
+	storage.SaveSource(source)
+
+	// Falha posterior mantém a data da última atualização
+	source.Status = domain.TARGET_SOURCE_STALE
+	source.LastError = "connection refused"
+	storage.SaveSource(source)
+
+	stored, err := storage.GetSource("user1")
+	if err != nil || stored == nil {
+		t.Fatalf("GetSource() = %v, %v", stored, err)
+	}
+
+	if stored.ETag != `"v1"` || !stored.IsStale() || stored.UpdatedAt == "" || stored.CheckedAt == "" {
+		t.Errorf("Estado do cache incorreto: %+v", stored)
+	}
+
+	sources, _ := storage.GetSources()
+	if len(sources) != 1 {
+		t.Errorf("Esperado 1 source, obteve %d", len(sources))
+	}
+}
+
+// TestTarget_ReplaceCalendar testa a gravação do calendário de um usuário
+func TestTarget_ReplaceCalendar(t *testing.T) {
+	conn := NewDbConnection(":memory:")
+	defer conn.Close()
+
+	storage := NewTarget(conn)
+
+	err := storage.ReplaceCalendar("user1", domain.Calendar{
+		{Name: "Férias", From: "2025-07-01", To: "2025-07-31", Profile: domain.PROFILE_WEEKEND},
+		{Name: "Natal", From: "2025-12-25", Profile: "sunday"},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceCalendar() erro = %v", err)
+	}
+
+	calendar, err := storage.GetCalendar("user1")
+	if err != nil {
+		t.Fatalf("GetCalendar() erro = %v", err)
+	}
+
+	if len(calendar) != 2 || calendar[0].Name != "Férias" || calendar[0].To != "2025-07-31" || calendar[1].To != "" || calendar[1].Profile != "sunday" {
+		t.Errorf("Calendário incorreto: %v", calendar)
+	}
+
+	storage.ReplaceCalendar("user1", nil)
+	if calendar, _ := storage.GetCalendar("user1"); len(calendar) != 0 {
+		t.Errorf("Calendário deveria ser removido, obteve %v", calendar)
+	}
+}